@@ -0,0 +1,115 @@
+// cli.go
+package main // Command-line flags and the subcommands that run instead of the server
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	"example.com/api-gateway/config"
+)
+
+// listFlag collects a flag given several times.
+type listFlag []string
+
+func (l *listFlag) String() string     { return strings.Join(*l, ",") }
+func (l *listFlag) Set(v string) error { *l = append(*l, v); return nil }
+
+// parseFlags reads the flags shared by the server and the subcommands and
+// returns the config sources and the remaining arguments.
+//
+//	gateway [--config file]... [--set path=value]... [subcommand]
+func parseFlags(args []string, stderr io.Writer) (config.Sources, []string, error) {
+	var files, set listFlag
+	fs := flag.NewFlagSet("gateway", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Var(&files, "config", "config `file`; repeat to layer files, later ones override (default "+config.DefaultPath+")")
+	fs.Var(&set, "set", "override one field as `path=value`, e.g. server.port=9090 (repeatable, wins over GATEWAY_* variables)")
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, usage)
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return config.Sources{}, nil, err
+	}
+	if len(files) == 0 {
+		files = listFlag{config.DefaultPath}
+	}
+	return config.Sources{Files: files, Set: set}, fs.Args(), nil
+}
+
+// usage lists the flags and subcommands.
+const usage = "usage: gateway [--config file]... [--set path=value]... [config check [file...] | secret keygen | secret seal keyfile]"
+
+// runCommand runs a subcommand and returns the process exit code:
+// 0 ok, 1 failed, 2 usage.
+//
+//	gateway config check [file...]   validate the config (CI, pre-deploy)
+//	gateway secret keygen            print a new key for secrets.key_file
+//	gateway secret seal keyfile      seal stdin for an enc: reference
+func runCommand(src config.Sources, args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	switch {
+	case len(args) >= 2 && args[0] == "config" && args[1] == "check":
+		if len(args) > 2 {
+			src.Files = args[2:]
+		}
+		return configCheck(src, stdout, stderr)
+	case len(args) == 2 && args[0] == "secret" && args[1] == "keygen":
+		key, err := config.NewSecretKey()
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			return 1
+		}
+		fmt.Fprintln(stdout, key)
+		return 0
+	case len(args) == 3 && args[0] == "secret" && args[1] == "seal":
+		return secretSeal(args[2], stdin, stdout, stderr)
+	}
+	fmt.Fprintln(stderr, usage)
+	return 2
+}
+
+// secretSeal seals stdin (trailing newline trimmed) with the key in
+// keyFile, e.g. gateway secret seal key < jwt.txt > config/jwt.sealed.
+func secretSeal(keyFile string, stdin io.Reader, stdout, stderr io.Writer) int {
+	key, err := config.ReadSecretKey(keyFile)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	plain, err := io.ReadAll(stdin)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	sealed, err := config.SealSecret(key, []byte(strings.TrimRight(string(plain), "\r\n")))
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	fmt.Fprintln(stdout, sealed)
+	return 0
+}
+
+// configCheck loads src as the server would (layering, strict decoding,
+// overrides, Validate) and prints every problem.
+func configCheck(src config.Sources, stdout, stderr io.Writer) int {
+	name := strings.Join(src.Files, " + ")
+	_, err := src.Load()
+	if err == nil {
+		fmt.Fprintf(stdout, "%s: ok\n", name)
+		return 0
+	}
+	var errs config.Errors
+	if !errors.As(err, &errs) {
+		fmt.Fprintf(stderr, "%s: %v\n", name, err)
+		return 1
+	}
+	for _, e := range errs {
+		fmt.Fprintf(stderr, "%s: %v\n", name, e)
+	}
+	fmt.Fprintf(stderr, "%d problem(s)\n", len(errs))
+	return 1
+}
@@ -0,0 +1,68 @@
+package main
+
+
+import (
+"bytes"
+"os"
+"path/filepath"
+"strings"
+"testing"
+"example.com/api-gateway/config"
+)
+
+
+const validYAML = "server:\n  port: 8080\nsecurity:\n  jwt:\n    secret: s3cret\n    ttl_minutes: 15\ndatabase:\n  driver: sqlite\n  dsn: app.db\n"
+
+
+// run calls runCommand with in-memory stdio.
+func run(t *testing.T, stdin string, args ...string) (int, string, string) {
+t.Helper()
+var stdout, stderr bytes.Buffer
+code := runCommand(config.Sources{}, args, strings.NewReader(stdin), &stdout, &stderr)
+return code, stdout.String(), stderr.String()
+}
+
+
+func writeFile(t *testing.T, name, body string) string {
+t.Helper()
+p := filepath.Join(t.TempDir(), name)
+if err := os.WriteFile(p, []byte(body), 0o600); err != nil { t.Fatal(err) }
+return p
+}
+
+
+func TestConfigCheckCommand(t *testing.T) {
+t.Setenv("JWT_SECRET", "")
+good := writeFile(t, "good.yaml", validYAML)
+if code, out, errOut := run(t, "", "config", "check", good); code != 0 || out != good+": ok\n" || errOut != "" { t.Fatalf("valid: %d %q %q", code, out, errOut) }
+
+bad := writeFile(t, "bad.yaml", "server:\n  port: 0\n  cros: {}\nsecurity:\n  jwt:\n    secret: s3cret\n    ttl_minutes: 15\ndatabase:\n  driver: oracle\n  dsn: app.db\n")
+code, out, errOut := run(t, "", "config", "check", bad)
+if code != 1 || out != "" { t.Fatalf("invalid: %d %q", code, out) }
+for _, want := range []string{"server.port", "server.cros", "database.driver", "3 problem(s)"} {
+if !strings.Contains(errOut, want) { t.Errorf("missing %q in:\n%s", want, errOut) }
+}
+if lines := strings.Count(errOut, bad+": "); lines != 3 { t.Fatalf("want one line per problem, got %d:\n%s", lines, errOut) }
+
+if code, _, errOut := run(t, "", "config", "check", filepath.Join(t.TempDir(), "missing.yaml")); code != 1 || errOut == "" { t.Fatalf("missing file: %d %q", code, errOut) }
+}
+
+
+func TestSecretSealCommand(t *testing.T) {
+code, key, _ := run(t, "", "secret", "keygen")
+if code != 0 { t.Fatalf("keygen: %d", code) }
+keyFile := writeFile(t, "key", key)
+
+code, sealed, errOut := run(t, "jwt-secret\n", "secret", "seal", keyFile)
+if code != 0 || strings.Contains(sealed, "jwt-secret") { t.Fatalf("seal: %d %q %q", code, sealed, errOut) }
+p, err := config.NewSealedFileProvider(keyFile)
+if err != nil { t.Fatal(err) }
+if plain, err := p.Resolve(writeFile(t, "jwt.sealed", sealed)); err != nil || plain != "jwt-secret" { t.Fatalf("open: %q %v", plain, err) }
+
+_, other, _ := run(t, "", "secret", "keygen")
+wrong, _ := config.NewSealedFileProvider(writeFile(t, "other", other))
+if _, err := wrong.Resolve(writeFile(t, "jwt2.sealed", sealed)); err == nil { t.Fatal("opened with another key") }
+
+if code, _, _ := run(t, "x", "secret", "seal", filepath.Join(t.TempDir(), "nokey")); code != 1 { t.Fatalf("missing key file: %d", code) }
+if code, _, errOut := run(t, "", "secret"); code != 2 || !strings.Contains(errOut, "usage:") { t.Fatalf("usage: %d %q", code, errOut) }
+}
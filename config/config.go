@@ -1,21 +1,40 @@
 package config // Centralized configuration types + loader
 
-import (
-	"fmt"      // For formatted errors
-	"os"       // For env overrides
-	"strings"  // Utility for parsing lists
-
-	"gopkg.in/yaml.v3" // YAML parsing
-)
-
 // Root holds the entire configuration tree in parent→child nesting.
 type Root struct {
+	Environment string `yaml:"environment"` // development|staging|production (production refuses the default JWT secret)
 	Server    Server    `yaml:"server"`    // HTTP server options
 	Security  Security  `yaml:"security"`  // Auth and JWT
 	RateLimit RateLimit `yaml:"rate_limit"` // Rate limiting configuration
 	Redis     Redis     `yaml:"redis"`     // Redis modes + credentials
 	Database  Database  `yaml:"database"`  // DB driver + DSN/config
 	Logging   Logging   `yaml:"logging"`   // Zap logging
+	Tracing   Tracing   `yaml:"tracing"`   // OpenTelemetry spans + exporter
+	Health    Health    `yaml:"health"`    // readiness checks (/health/ready)
+	Secrets   Secrets   `yaml:"secrets"`   // file:/env:/enc: secret references
+}
+
+// Health configures /health/ready.
+type Health struct {
+	TimeoutMS int        `yaml:"timeout_ms"` // per check (default 2000)
+	Upstreams []Upstream `yaml:"upstreams"`  // extra HTTP dependencies probed by readiness
+}
+
+// Upstream is an HTTP dependency whose health URL is probed.
+type Upstream struct {
+	Name     string `yaml:"name"`
+	URL      string `yaml:"url"`      // GET; 5xx or no answer = down
+	Optional bool   `yaml:"optional"` // report only, do not fail readiness
+}
+
+// Tracing configures OpenTelemetry spans. W3C trace context (traceparent)
+// is always propagated, even when nothing is exported.
+type Tracing struct {
+	Exporter    string   `yaml:"exporter"`     // otlp|stdout|none (default none)
+	Endpoint    string   `yaml:"endpoint"`     // OTLP/HTTP collector host:port (default localhost:4318)
+	Insecure    bool     `yaml:"insecure"`     // plain HTTP to the collector
+	ServiceName string   `yaml:"service_name"` // resource service.name (default api-gateway)
+	SampleRatio *float64 `yaml:"sample_ratio"` // share of new traces recorded, 0..1 (unset = all, 0 = none); callers' sampling decision wins
 }
 
 // Server groups HTTP listen + CORS + timeouts.
@@ -24,14 +43,80 @@ type Server struct {
 	Port     int      `yaml:"port"`
 	CORS     CORS     `yaml:"cors"`
 	Timeouts Timeouts `yaml:"timeouts"`
+	Admin    Admin    `yaml:"admin"` // optional internal listener
+	Shutdown Shutdown `yaml:"shutdown"` // draining on SIGTERM/SIGINT
+}
+
+// Admin is the optional internal listener hosting metrics, health, pprof
+// and the admin APIs. When Address is set those endpoints leave the public
+// listener (which keeps only /health for load balancers).
+type Admin struct {
+	Address string    `yaml:"address"` // host:port, e.g. 127.0.0.1:9090 ("" = admin APIs stay public behind JWT)
+	Pprof   bool      `yaml:"pprof"`   // mount /debug/pprof
+	Auth    AdminAuth `yaml:"auth"`
+}
+
+// AdminAuth guards the admin listener. At least one method must be set;
+// with an allow-list and credentials, both must pass.
+type AdminAuth struct {
+	BasicUser     string   `yaml:"basic_user"`
+	BasicPassword string   `yaml:"basic_password" secret:"true"`
+	BearerToken   string   `yaml:"bearer_token" secret:"true"`
+	AllowIPs      []string `yaml:"allow_ips"` // IPs or CIDRs, matched against the TCP peer (not X-Forwarded-For)
+}
+
+// Shutdown controls connection draining on SIGTERM/SIGINT.
+type Shutdown struct {
+	DelayMS   int `yaml:"delay_ms"`   // keep serving after /health/ready turns 503, so endpoints are removed first
+	TimeoutMS int `yaml:"timeout_ms"` // deadline for in-flight requests (default 20000)
 }
 
 // CORS defines cross-origin allowlist and methods.
 type CORS struct {
-	AllowedOrigins []string `yaml:"allowed_origins"`
-	AllowedMethods []string `yaml:"allowed_methods"`
-	AllowedHeaders []string `yaml:"allowed_headers"`
-	AllowCredentials bool   `yaml:"allow_credentials"`
+	AllowedOrigins   []string    `yaml:"allowed_origins"`   // exact origins, https://*.example.com for subdomains, or "*"
+	AllowedMethods   []string    `yaml:"allowed_methods"`   // default GET, POST, PATCH, DELETE
+	AllowedHeaders   []string    `yaml:"allowed_headers"`   // request headers; "*" echoes what the preflight asks for
+	ExposedHeaders   []string    `yaml:"exposed_headers"`   // readable by scripts (default rate-limit headers, Retry-After, X-Request-Id)
+	AllowCredentials bool        `yaml:"allow_credentials"` // cookies; the origin is echoed, so not with "*"
+	MaxAgeS          int         `yaml:"max_age_s"`         // preflight cache (default 600)
+	Routes           []CORSRoute `yaml:"routes"`            // per path prefix overrides, longest prefix wins
+}
+
+// CORSRoute overrides CORS for paths under Prefix; unset fields inherit
+// (allowed_origins: [] turns CORS off there).
+type CORSRoute struct {
+	Prefix           string   `yaml:"prefix"` // e.g. /auth/
+	AllowedOrigins   []string `yaml:"allowed_origins"`
+	AllowedMethods   []string `yaml:"allowed_methods"`
+	AllowedHeaders   []string `yaml:"allowed_headers"`
+	ExposedHeaders   []string `yaml:"exposed_headers"`
+	AllowCredentials *bool    `yaml:"allow_credentials"`
+	MaxAgeS          int      `yaml:"max_age_s"`
+}
+
+// For returns the policy for route: c with the route's fields laid over.
+func (c CORS) For(route CORSRoute) CORS {
+	out := c
+	out.Routes = nil
+	if route.AllowedOrigins != nil {
+		out.AllowedOrigins = route.AllowedOrigins
+	}
+	if route.AllowedMethods != nil {
+		out.AllowedMethods = route.AllowedMethods
+	}
+	if route.AllowedHeaders != nil {
+		out.AllowedHeaders = route.AllowedHeaders
+	}
+	if route.ExposedHeaders != nil {
+		out.ExposedHeaders = route.ExposedHeaders
+	}
+	if route.AllowCredentials != nil {
+		out.AllowCredentials = *route.AllowCredentials
+	}
+	if route.MaxAgeS != 0 {
+		out.MaxAgeS = route.MaxAgeS
+	}
+	return out
 }
 
 // Timeouts for HTTP server.
@@ -51,7 +136,7 @@ type Security struct {
 type JWT struct {
 	Issuer     string `yaml:"issuer"`
 	Audience   string `yaml:"audience"`
-	Secret     string `yaml:"secret"`      // May be overridden by env JWT_SECRET
+	Secret     string `yaml:"secret" secret:"true"` // May be overridden by env JWT_SECRET
 	TTLMinutes int    `yaml:"ttl_minutes"`
 	RefreshTTL int    `yaml:"refresh_ttl_minutes"`
 }
@@ -59,9 +144,38 @@ type JWT struct {
 // RateLimit config supports memory or redis.
 type RateLimit struct {
 	Enabled            bool   `yaml:"enabled"`
-	Strategy           string `yaml:"strategy"` // memory|redis
+	Strategy           string `yaml:"strategy"` // memory|redis|hybrid
+	FailureMode        string `yaml:"failure_mode"` // redis down: open|closed|local (default open); one strategy runs at a time, so one mode covers it
 	RequestsPerMinute  int    `yaml:"requests_per_minute"`
 	Burst              int    `yaml:"burst"`
+
+	// Memory strategy tuning (zero values fall back to sane defaults).
+	Shards            int `yaml:"shards"`              // lock shards for the bucket map
+	MaxKeys           int `yaml:"max_keys"`            // cap on tracked keys (also hybrid); least recently used are evicted
+	IdleTTLMS         int `yaml:"idle_ttl_ms"`         // drop buckets idle at least this long
+	JanitorIntervalMS int `yaml:"janitor_interval_ms"` // how often idle buckets are swept
+
+	// Hybrid strategy tuning: local counting, batched sync to Redis.
+	SyncIntervalMS  int `yaml:"sync_interval_ms"`  // how often local counts are pushed/pulled
+	MaxLocalPending int `yaml:"max_local_pending"` // unsynced hits per key before an inline flush
+
+	AccessListRefreshMS int `yaml:"access_list_refresh_ms"` // reload allow/deny lists from Redis this often
+
+	Concurrency Concurrency `yaml:"concurrency"` // in-flight caps + load shedding
+}
+
+// Concurrency caps in-flight requests globally and per route, queueing a
+// bounded number of waiters. With Adaptive set the global cap follows AIMD
+// on observed latency so a slow DB sheds load instead of piling up.
+type Concurrency struct {
+	Enabled         bool `yaml:"enabled"`
+	MaxInFlight     int  `yaml:"max_in_flight"`     // global cap (adaptive upper bound)
+	PerKeyInFlight  int  `yaml:"per_key_in_flight"` // per route template; 0 = no per-route cap
+	QueueSize       int  `yaml:"queue_size"`        // waiters allowed once a cap is reached
+	QueueTimeoutMS  int  `yaml:"queue_timeout_ms"`  // max time a waiter queues before 503
+	Adaptive        bool `yaml:"adaptive"`          // AIMD on latency
+	MinInFlight     int  `yaml:"min_in_flight"`     // adaptive lower bound
+	TargetLatencyMS int  `yaml:"target_latency_ms"` // latency above this shrinks the cap
 }
 
 // Redis supports standalone or sentinel modes.
@@ -71,42 +185,159 @@ type Redis struct {
 	MasterName string   `yaml:"master_name"` // sentinel master logical name
 	DB         int      `yaml:"db"`
 	Username   string   `yaml:"username"`
-	Password   string   `yaml:"password"`
+	Password   string   `yaml:"password" secret:"true"`
 	TLS        bool     `yaml:"tls"`
+	Required   bool     `yaml:"required"` // refuse to start when Redis is unreachable
+	HealthCheckIntervalMS int `yaml:"health_check_interval_ms"` // reconnect detection ping period
 }
 
 // Database driver selection + DSN per driver.
 type Database struct {
 	Driver string `yaml:"driver"` // mysql|postgres|sqlite
-	DSN    string `yaml:"dsn"`    // DSN string per driver
+	DSN    string `yaml:"dsn" secret:"opt-in"` // DSN string per driver; a reference only with secrets.dsn
 }
 
 // Logging config for zap.
 type Logging struct {
-	Level    string `yaml:"level"` // debug|info|warn|error
-	JSON     bool   `yaml:"json"`
+	Level    string `yaml:"level"` // debug|info|warn|error (changeable at runtime via PUT /api/log-level)
+	Levels   map[string]string `yaml:"levels"` // per-logger overrides, e.g. rate: debug (children included)
+	RedisLevel string `yaml:"redis_level"` // threshold for entries stored in Redis (default: level)
+	Redis      LogStore `yaml:"redis"`    // Redis log storage (stream)
+	Sinks      []LogSink `yaml:"sinks"`   // extra outputs (syslog, http, file)
+	JSON     bool   `yaml:"json"`     // console output as JSON instead of colored text
+	File     LogFile `yaml:"file"`    // rotating local file
+	Redaction Redaction `yaml:"redaction"` // applied to every output
+	Capture   Capture   `yaml:"capture"`   // admin-toggled request/response capture
 	Sampling bool   `yaml:"sampling"`
 }
 
-// Load reads config/config.yaml, applies env overrides, and returns Root.
+// Redaction masks or hashes sensitive values before any log output.
+type Redaction struct {
+	Enabled  bool             `yaml:"enabled"`
+	Mode     string           `yaml:"mode"`     // mask|hash (default mask)
+	HashKey  string           `yaml:"hash_key" secret:"true"` // HMAC key for hash mode
+	Fields   []RedactField    `yaml:"fields"`   // field names redacted entirely (case-insensitive)
+	Builtin  []string         `yaml:"builtin"`  // email|bearer|jwt|password|card
+	Patterns []RedactPattern  `yaml:"patterns"` // extra regexes; a (?P<v>...) group limits the redacted part
+}
+
+// Capture bounds the debug capture admins enable via /api/capture.
+type Capture struct {
+	MaxBodyBytes   int `yaml:"max_body_bytes"`   // per body (default 4096)
+	MaxDurationSec int `yaml:"max_duration_sec"` // longest rule window (default 3600)
+	RefreshMS      int `yaml:"refresh_ms"`       // rule sync from Redis (default 5000)
+}
+
+// RedactField redacts the whole value of a field.
+type RedactField struct {
+	Name string `yaml:"name"`
+	Mode string `yaml:"mode"` // default: redaction.mode
+}
+
+// RedactPattern redacts regex matches inside string values and messages.
+type RedactPattern struct {
+	Name  string `yaml:"name"`
+	Regex string `yaml:"regex"`
+	Mode  string `yaml:"mode"` // default: redaction.mode
+}
+
+// LogFile configures the rotating local log file.
+type LogFile struct {
+	Path       string `yaml:"path"`         // default logs/app.log; "-" disables the file
+	Encoding   string `yaml:"encoding"`     // json|console (default json)
+	MaxSizeMB  int    `yaml:"max_size_mb"`  // default 25
+	MaxBackups int    `yaml:"max_backups"`  // default 7
+	MaxAgeDays int    `yaml:"max_age_days"` // default 30
+	Compress   bool   `yaml:"compress"`
+}
+
+// LogSink is one extra log output with its own level, batching and retry.
+type LogSink struct {
+	Name            string `yaml:"name"`              // label for metrics (default: type)
+	Type            string `yaml:"type"`              // syslog|http|file
+	Level           string `yaml:"level"`             // threshold (default: logging.level)
+	BufferSize      int    `yaml:"buffer_size"`       // queued entries before dropping (default 1024)
+	BatchSize       int    `yaml:"batch_size"`        // entries per send (default 100)
+	FlushIntervalMS int    `yaml:"flush_interval_ms"` // max wait before a partial batch is sent (default 1000)
+	MaxRetries      int    `yaml:"max_retries"`       // extra attempts per batch (default 3, -1 = none)
+	RetryBackoffMS  int    `yaml:"retry_backoff_ms"`  // first retry delay, doubled each attempt (default 200)
+	Syslog          SyslogSink `yaml:"syslog"`
+	HTTP            HTTPSink   `yaml:"http"`
+	File            FileSink   `yaml:"file"`
+}
+
+// SyslogSink sends RFC 5424 messages.
+type SyslogSink struct {
+	Network  string `yaml:"network"`  // udp|tcp|unix|unixgram
+	Address  string `yaml:"address"`  // host:port or socket path
+	Facility int    `yaml:"facility"` // 0-23 (default 16 = local0)
+	AppName  string `yaml:"app_name"` // default api-gateway
+}
+
+// HTTPSink POSTs batches to a collector (Loki/Elasticsearch-style ingest).
+type HTTPSink struct {
+	URL       string            `yaml:"url"`
+	Format    string            `yaml:"format"`     // ndjson|json (default ndjson)
+	Headers   map[string]string `yaml:"headers"`    // e.g. Authorization
+	TimeoutMS int               `yaml:"timeout_ms"` // per request (default 5000)
+}
+
+// FileSink writes one rotating NDJSON file per category (logger name).
+type FileSink struct {
+	Dir        string `yaml:"dir"`         // default logs
+	MaxSizeMB  int    `yaml:"max_size_mb"` // default 25
+	MaxBackups int    `yaml:"max_backups"` // default 7
+	MaxAgeDays int    `yaml:"max_age_days"`
+	Compress   bool   `yaml:"compress"`
+}
+
+// LogStore configures the Redis stream holding structured logs.
+type LogStore struct {
+	Stream      string `yaml:"stream"`       // stream key (default logs:stream)
+	MaxLen      int64  `yaml:"max_len"`      // approximate cap, XADD MAXLEN ~ (default 10000)
+	ShipperPath string `yaml:"shipper_path"` // optional NDJSON file fed by a consumer group
+	Async       AsyncLog `yaml:"async"`     // background writer feeding the stream
+	Retention   Retention `yaml:"retention"` // per-day list lifetimes + archival
+}
+
+// Retention sets how long the per-day log lists live in Redis. Entries are
+// grouped per day and class (access, a configured level, or the default);
+// each class list expires on its own.
+type Retention struct {
+	Days       int            `yaml:"days"`        // default lifetime in days (default 7)
+	Levels     map[string]int `yaml:"levels"`      // per-level days, e.g. { ERROR: 30 }
+	AccessDays int            `yaml:"access_days"` // HTTP access entries (0 = same as days)
+	Archive    LogArchive     `yaml:"archive"`
+}
+
+// LogArchive exports day lists to gzipped NDJSON files before they expire.
+type LogArchive struct {
+	Dir         string `yaml:"dir"`          // archive directory ("" disables archival)
+	IntervalMin int    `yaml:"interval_min"` // how often expiring lists are checked (default 60)
+	AheadHours  int    `yaml:"ahead_hours"`  // archive lists expiring within this window (default 24)
+	KeepDays    int    `yaml:"keep_days"`    // delete archive files older than this (0 = keep forever)
+}
+
+// AsyncLog tunes the background writer that saves entries to Redis.
+type AsyncLog struct {
+	Buffer          int    `yaml:"buffer"`            // queued entries before dropping (default 2048)
+	BatchSize       int    `yaml:"batch_size"`        // entries per pipelined write (default 100)
+	FlushIntervalMS int    `yaml:"flush_interval_ms"` // max wait for a partial batch (default 200)
+	SpillPath       string `yaml:"spill_path"`        // optional NDJSON file holding entries while Redis is down
+	SpillMaxMB      int    `yaml:"spill_max_mb"`      // spill file cap (default 64)
+}
+
+// Load reads config/config.yaml (plus its environment overlay and the
+// GATEWAY_* overrides), validates, and returns Root.
 func Load() (Root, error) {
-	var cfg Root // Holder
+	return LoadFile(DefaultPath)
+}
 
-	raw, err := os.ReadFile("config/config.yaml") // Read YAML file
-	if err != nil {
-		return cfg, fmt.Errorf("read config: %w", err) // File missing or unreadable
-	}
-	if err := yaml.Unmarshal(raw, &cfg); err != nil { // Parse YAML
-		return cfg, fmt.Errorf("parse config: %w", err)
-	}
+// DefaultPath is the config file read by Load.
+const DefaultPath = "config/config.yaml"
 
-	// Env overrides (minimal: JWT_SECRET)
-	if s := os.Getenv("JWT_SECRET"); s != "" {
-		cfg.Security.JWT.Secret = s // Override secret from environment
-	}
-	// Optional: allow comma-separated CORS origins via env CORS_ORIGINS
-	if o := os.Getenv("CORS_ORIGINS"); o != "" {
-		cfg.Server.CORS.AllowedOrigins = strings.Split(o, ",")
-	}
-	return cfg, nil // Ready to use
-}
\ No newline at end of file
+// LoadFile is Load for the file at path; see Sources for the layering.
+// Invalid config yields Errors.
+func LoadFile(path string) (Root, error) {
+	return Sources{Files: []string{path}}.Load()
+}
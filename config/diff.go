@@ -0,0 +1,78 @@
+// config/diff.go
+package config // Field-level comparison of two configs by YAML path
+
+import (
+	"reflect"
+	"strings"
+)
+
+// Diff returns the YAML paths ("server.cors.allowed_origins") of the
+// fields that differ between a and b, in declaration order. Structs are
+// walked; slices and maps compare as a whole.
+func Diff(a, b Root) []string {
+	var out []string
+	diff(reflect.ValueOf(a), reflect.ValueOf(b), "", &out)
+	return out
+}
+
+func diff(a, b reflect.Value, prefix string, out *[]string) {
+	if a.Kind() != reflect.Struct {
+		if !reflect.DeepEqual(a.Interface(), b.Interface()) {
+			*out = append(*out, prefix)
+		}
+		return
+	}
+	t := a.Type()
+	for i := 0; i < t.NumField(); i++ {
+		name := yamlName(t.Field(i))
+		if name == "" {
+			continue
+		}
+		if prefix != "" {
+			name = prefix + "." + name
+		}
+		diff(a.Field(i), b.Field(i), name, out)
+	}
+}
+
+// Assign copies the field at path (as returned by Diff) from src into dst.
+// Unknown paths are ignored.
+func Assign(dst *Root, src Root, path string) {
+	d, s := reflect.ValueOf(dst).Elem(), reflect.ValueOf(src)
+	for _, part := range strings.Split(path, ".") {
+		if d.Kind() != reflect.Struct {
+			return
+		}
+		i := fieldIndex(d.Type(), part)
+		if i < 0 {
+			return
+		}
+		d, s = d.Field(i), s.Field(i)
+	}
+	d.Set(s)
+}
+
+// fieldIndex finds the field of t whose YAML name is name.
+func fieldIndex(t reflect.Type, name string) int {
+	for i := 0; i < t.NumField(); i++ {
+		if yamlName(t.Field(i)) == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// yamlName is the key of f in YAML ("" for unexported or skipped fields).
+func yamlName(f reflect.StructField) string {
+	if !f.IsExported() {
+		return ""
+	}
+	name, _, _ := strings.Cut(f.Tag.Get("yaml"), ",")
+	switch name {
+	case "-":
+		return ""
+	case "":
+		return strings.ToLower(f.Name)
+	}
+	return name
+}
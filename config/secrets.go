@@ -0,0 +1,160 @@
+// config/secrets.go
+package config // Secret providers behind file:, env: and enc: references
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"golang.org/x/crypto/nacl/secretbox"
+)
+
+// Secrets configures how secret references are resolved. Fields tagged
+// secret:"true" (JWT secret, Redis password, admin credentials, redaction
+// hash key) may hold scheme:ref instead of the secret:
+//
+//	file:/run/secrets/jwt   contents of the file (trailing newline trimmed)
+//	env:DB_PASSWORD         the environment variable
+//	enc:config/jwt.sealed   a file sealed with key_file (gateway secret seal)
+//
+// Fields tagged secret:"opt-in" are resolved only when enabled here: a
+// SQLite DSN such as file:gateway.db?cache=shared is not a reference.
+type Secrets struct {
+	KeyFile   string `yaml:"key_file"`   // base64 32-byte key for enc: (gateway secret keygen)
+	RefreshMS int    `yaml:"refresh_ms"` // re-resolve every so often so rotated secrets apply (0 = only on reload)
+	DSN       bool   `yaml:"dsn"`        // database.dsn is a reference too
+}
+
+// SecretProvider resolves the references of one scheme.
+type SecretProvider interface {
+	Scheme() string                     // "file" for file:/run/secrets/jwt
+	Resolve(ref string) (string, error) // ref is what follows "scheme:"
+}
+
+// FileProvider reads file: references, e.g. Docker/Kubernetes secret mounts.
+type FileProvider struct{}
+
+// Scheme implements SecretProvider.
+func (FileProvider) Scheme() string { return "file" }
+
+// Resolve implements SecretProvider.
+func (FileProvider) Resolve(path string) (string, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(raw), "\r\n"), nil
+}
+
+// EnvProvider reads env: references.
+type EnvProvider struct{}
+
+// Scheme implements SecretProvider.
+func (EnvProvider) Scheme() string { return "env" }
+
+// Resolve implements SecretProvider.
+func (EnvProvider) Resolve(name string) (string, error) {
+	v, ok := os.LookupEnv(name)
+	if !ok || v == "" {
+		return "", fmt.Errorf("%s is not set", name)
+	}
+	return v, nil
+}
+
+// SealedFileProvider reads enc: references: files holding base64 of a NaCl
+// secretbox (nonce first) sealed with a local key, so the secret can sit
+// next to the config (or in git) and only the key has to be protected.
+type SealedFileProvider struct {
+	key *[32]byte // nil: enc: references fail
+}
+
+// NewSealedFileProvider loads the key from keyFile; "" gives a provider
+// whose references fail with a hint to set secrets.key_file.
+func NewSealedFileProvider(keyFile string) (*SealedFileProvider, error) {
+	if keyFile == "" {
+		return &SealedFileProvider{}, nil
+	}
+	key, err := ReadSecretKey(keyFile)
+	if err != nil {
+		return nil, err
+	}
+	return &SealedFileProvider{key: key}, nil
+}
+
+// Scheme implements SecretProvider.
+func (*SealedFileProvider) Scheme() string { return "enc" }
+
+// Resolve implements SecretProvider.
+func (p *SealedFileProvider) Resolve(path string) (string, error) {
+	if p.key == nil {
+		return "", errors.New("enc: references need secrets.key_file")
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	box, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(raw)))
+	if err != nil || len(box) < 24+secretbox.Overhead {
+		return "", fmt.Errorf("%s: not a sealed secret", path)
+	}
+	var nonce [24]byte
+	copy(nonce[:], box)
+	plain, ok := secretbox.Open(nil, box[24:], &nonce, p.key)
+	if !ok {
+		return "", fmt.Errorf("%s: cannot be opened with the key (wrong key or corrupted)", path)
+	}
+	return string(plain), nil
+}
+
+// NewSecretKey returns a random key for SealSecret, base64 encoded as
+// ReadSecretKey expects.
+func NewSecretKey() (string, error) {
+	var key [32]byte
+	if _, err := rand.Read(key[:]); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(key[:]), nil
+}
+
+// ReadSecretKey reads a base64 32-byte key file.
+func ReadSecretKey(path string) (*[32]byte, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	b, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(raw)))
+	if err != nil || len(b) != 32 {
+		return nil, fmt.Errorf("%s: not a base64 32-byte key", path)
+	}
+	var key [32]byte
+	copy(key[:], b)
+	return &key, nil
+}
+
+// SealSecret encrypts plain with key into the text SealedFileProvider
+// opens.
+func SealSecret(key *[32]byte, plain []byte) (string, error) {
+	var nonce [24]byte
+	if _, err := rand.Read(nonce[:]); err != nil {
+		return "", err
+	}
+	box := secretbox.Seal(nonce[:], plain, &nonce, key)
+	return base64.StdEncoding.EncodeToString(box), nil
+}
+
+// providers returns the default providers for sec, overridden by extra
+// (same scheme).
+func providers(sec Secrets, extra []SecretProvider) (map[string]SecretProvider, error) {
+	sealed, err := NewSealedFileProvider(sec.KeyFile)
+	if err != nil {
+		return nil, err
+	}
+	out := map[string]SecretProvider{}
+	for _, p := range append([]SecretProvider{FileProvider{}, EnvProvider{}, sealed}, extra...) {
+		out[p.Scheme()] = p
+	}
+	return out, nil
+}
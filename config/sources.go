@@ -0,0 +1,522 @@
+// config/sources.go
+package config // Layered files, GATEWAY_* variables, --set flags and ${ENV}/file: references
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// EnvPrefix starts the variable that overrides each field; the rest is the
+// YAML path in upper case with dots as underscores:
+// server.cors.allowed_origins is GATEWAY_SERVER_CORS_ALLOWED_ORIGINS.
+const EnvPrefix = "GATEWAY_"
+
+// legacyEnv are the overrides that predate GATEWAY_*; the GATEWAY_ name
+// wins when both are set.
+var legacyEnv = [][2]string{
+	{"JWT_SECRET", "security.jwt.secret"},
+	{"CORS_ORIGINS", "server.cors.allowed_origins"},
+}
+
+// Sources says where the config comes from, in increasing precedence:
+//
+//  1. Files, base first; each file overrides the ones before it. Mappings
+//     merge key by key, lists and scalars are replaced.
+//  2. The environment overlay next to the base file (config.production.yaml
+//     for config.yaml), when it exists. The environment is GATEWAY_ENVIRONMENT
+//     or the environment key of the files.
+//  3. GATEWAY_* variables (and JWT_SECRET, CORS_ORIGINS); empty ones are
+//     ignored. Lists take comma-separated items, maps and lists of objects
+//     YAML flow syntax ({http: debug}).
+//  4. Set, "path=value" pairs from --set, parsed like the variables.
+//
+// Values may then reference ${VAR} (or ${VAR:-default}), and secret
+// fields may name a secret (file:, env:, enc:; see Secrets) instead of
+// holding it, so secrets stay out of the YAML.
+type Sources struct {
+	Files     []string
+	Set       []string
+	Providers []SecretProvider // extra secret schemes, or replacements for file/env/enc
+}
+
+// Paths returns the files Load may read for environment env: Files, with
+// the overlay after the base even if it does not exist yet, so a watcher
+// sees it appear.
+func (s Sources) Paths(env string) []string {
+	ov := overlay(s.Files, env)
+	if ov == "" || slices.Contains(s.Files, ov) {
+		return slices.Clone(s.Files)
+	}
+	return slices.Insert(slices.Clone(s.Files), 1, ov)
+}
+
+// overlay names the environment overlay of the base file, or "".
+func overlay(files []string, env string) string {
+	if len(files) == 0 || env == "" {
+		return ""
+	}
+	ext := filepath.Ext(files[0])
+	return strings.TrimSuffix(files[0], ext) + "." + env + ext
+}
+
+// position locates a path: the layer it came from (set when there is more
+// than one file, and for overrides) and its line.
+type position struct {
+	source string
+	line   int
+}
+
+// layer is one parsed file or override.
+type layer struct {
+	source string
+	root   *yaml.Node // top-level mapping
+}
+
+var rootType = reflect.TypeOf(Root{})
+
+// Load reads and merges the sources, strictly (unknown keys are errors),
+// and validates the result. Invalid config yields Errors.
+func (s Sources) Load() (Root, error) {
+	var cfg Root
+	if len(s.Files) == 0 {
+		return cfg, errors.New("no config file")
+	}
+
+	var errs Errors
+	var layers []layer
+	for _, f := range s.Files {
+		l, lerrs, err := readLayer(f)
+		if err != nil {
+			return cfg, err
+		}
+		errs = append(errs, lerrs...)
+		if l.root != nil {
+			layers = append(layers, l)
+		}
+	}
+	env := os.Getenv(EnvPrefix + "ENVIRONMENT")
+	for i := len(layers) - 1; env == "" && i >= 0; i-- {
+		if v := lookup(layers[i].root, "environment"); v != nil {
+			env = v.Value
+		}
+	}
+	if ov := overlay(s.Files, env); ov != "" && !slices.Contains(s.Files, ov) {
+		if _, err := os.Stat(ov); err == nil {
+			l, lerrs, err := readLayer(ov)
+			if err != nil {
+				return cfg, err
+			}
+			errs = append(errs, lerrs...)
+			at := 0 // right after the base
+			if len(layers) > 0 && layers[0].source == s.Files[0] {
+				at = 1
+			}
+			if l.root != nil {
+				layers = slices.Insert(layers, at, l)
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return cfg, errs // syntax errors: nothing to locate the rest by
+	}
+
+	// Merge the files; positions follow the layer that set each path.
+	named := len(layers) > 1
+	doc := &yaml.Node{Kind: yaml.MappingNode}
+	pos := map[string]position{}
+	for _, l := range layers {
+		src := ""
+		if named {
+			src = l.source
+		}
+		walkKeys(l.root, rootType, "", src, pos, &errs)
+		before := len(errs)
+		resolveRefs(l.root, rootType, "", pos, &errs)
+		var probe Root // type errors, attributed to their file
+		if err := l.root.Decode(&probe); err != nil && len(errs) == before {
+			errs = append(errs, FieldError{Source: src, Msg: err.Error()})
+		}
+		merge(doc, l.root)
+	}
+
+	// Overrides: legacy variables, GATEWAY_*, then --set.
+	for _, kv := range legacyEnv {
+		if v := os.Getenv(kv[0]); v != "" {
+			override(doc, kv[1], v, kv[0], pos, &errs)
+		}
+	}
+	vars := envPaths()
+	names := make([]string, 0, len(vars))
+	for name := range vars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if v := os.Getenv(name); v != "" {
+			override(doc, vars[name], v, name, pos, &errs)
+		}
+	}
+	for _, kv := range s.Set {
+		path, v, ok := strings.Cut(kv, "=")
+		if !ok || path == "" {
+			errs = append(errs, FieldError{Source: "--set", Msg: fmt.Sprintf("%q is not path=value", kv)})
+			continue
+		}
+		override(doc, path, v, "--set", pos, &errs)
+	}
+
+	// Secret references, once secrets.key_file is final
+	var sec Secrets
+	if n := lookup(doc, "secrets"); n != nil {
+		_ = n.Decode(&sec) // a bad type is reported above
+	}
+	if provs, err := providers(sec, s.Providers); err != nil {
+		p := pos["secrets.key_file"]
+		errs = append(errs, FieldError{Path: "secrets.key_file", Source: p.source, Line: p.line, Msg: err.Error()})
+	} else {
+		resolveSecrets(doc, provs, secretPaths(sec), pos, &errs)
+	}
+
+	if err := doc.Decode(&cfg); err != nil && len(errs) == 0 {
+		errs = append(errs, FieldError{Msg: err.Error()})
+	}
+
+	// Every problem at once, located by YAML path and line; a field that
+	// already failed above is not reported twice
+	reported := map[string]bool{}
+	for _, e := range errs {
+		reported[e.Path] = true
+	}
+	if err := cfg.Validate(); err != nil {
+		for _, e := range err.(Errors) {
+			if reported[e.Path] {
+				continue
+			}
+			p := pos[e.Path]
+			e.Source, e.Line = p.source, p.line
+			errs = append(errs, e)
+		}
+	}
+	if len(errs) > 0 {
+		return cfg, errs
+	}
+	return cfg, nil
+}
+
+// readLayer parses one file. A syntax error is returned as Errors; an
+// empty file yields a layer without root.
+func readLayer(path string) (layer, Errors, error) {
+	l := layer{source: path}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return l, nil, fmt.Errorf("read config: %w", err)
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(raw, &doc); err != nil {
+		return l, Errors{{Source: path, Msg: err.Error()}}, nil
+	}
+	if len(doc.Content) == 0 {
+		return l, nil, nil
+	}
+	if doc.Content[0].Kind != yaml.MappingNode {
+		return l, Errors{{Source: path, Msg: "top level must be a mapping"}}, nil
+	}
+	l.root = doc.Content[0]
+	return l, nil, nil
+}
+
+// lookup returns the value of key in mapping n, or nil.
+func lookup(n *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		if n.Content[i].Value == key {
+			return n.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// merge lays src over dst: mappings merge key by key, anything else
+// replaces.
+func merge(dst, src *yaml.Node) {
+	for i := 0; i+1 < len(src.Content); i += 2 {
+		key, val := src.Content[i], src.Content[i+1]
+		cur := lookup(dst, key.Value)
+		switch {
+		case cur == nil:
+			dst.Content = append(dst.Content, key, val)
+		case cur.Kind == yaml.MappingNode && val.Kind == yaml.MappingNode:
+			merge(cur, val)
+		default:
+			*cur = *val
+		}
+	}
+}
+
+// envPaths maps each GATEWAY_* variable to its YAML path. Structs are
+// walked; every other field (scalar, list, map) is one variable.
+func envPaths() map[string]string {
+	out := map[string]string{}
+	var walk func(t reflect.Type, path string)
+	walk = func(t reflect.Type, path string) {
+		for i := 0; i < t.NumField(); i++ {
+			name := yamlName(t.Field(i))
+			if name == "" {
+				continue
+			}
+			p, ft := join(path, name), t.Field(i).Type
+			if ft.Kind() == reflect.Struct {
+				walk(ft, p)
+				continue
+			}
+			out[EnvPrefix+strings.ToUpper(strings.ReplaceAll(p, ".", "_"))] = p
+		}
+	}
+	walk(rootType, "")
+	return out
+}
+
+// fieldType resolves a YAML path (map keys included) against Root.
+func fieldType(path string) (reflect.Type, bool) {
+	t := rootType
+	for _, part := range strings.Split(path, ".") {
+		switch t.Kind() {
+		case reflect.Struct:
+			i := fieldIndex(t, part)
+			if i < 0 {
+				return nil, false
+			}
+			t = t.Field(i).Type
+		case reflect.Map:
+			t = t.Elem()
+		default:
+			return nil, false
+		}
+	}
+	return t, true
+}
+
+// override sets path to the value of an env variable or --set flag,
+// after checking that the value fits the field.
+func override(doc *yaml.Node, path, value, source string, pos map[string]position, errs *Errors) {
+	t, ok := fieldType(path)
+	if !ok {
+		*errs = append(*errs, FieldError{Path: path, Source: source, Msg: "unknown field"})
+		return
+	}
+	val, err := valueNode(t, value)
+	if err != nil {
+		*errs = append(*errs, FieldError{Path: path, Source: source, Msg: err.Error()})
+		return
+	}
+	for p := range pos {
+		if p == path || strings.HasPrefix(p, path+".") || strings.HasPrefix(p, path+"[") {
+			delete(pos, p)
+		}
+	}
+	pos[path] = position{source: source}
+	before := len(*errs)
+	walkKeys(val, t, path, source, pos, errs)
+	resolveRefs(val, t, path, pos, errs)
+	if len(*errs) > before {
+		return
+	}
+	if err := val.Decode(reflect.New(t).Interface()); err != nil {
+		*errs = append(*errs, FieldError{Path: path, Source: source, Msg: typeMsg(err)})
+		return
+	}
+
+	n := doc
+	parts := strings.Split(path, ".")
+	for _, part := range parts[:len(parts)-1] {
+		next := lookup(n, part)
+		if next == nil || next.Kind != yaml.MappingNode {
+			next = &yaml.Node{Kind: yaml.MappingNode}
+			setKey(n, part, next)
+		}
+		n = next
+	}
+	setKey(n, parts[len(parts)-1], val)
+}
+
+func setKey(n *yaml.Node, key string, val *yaml.Node) {
+	if cur := lookup(n, key); cur != nil {
+		*cur = *val
+		return
+	}
+	n.Content = append(n.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: key}, val)
+}
+
+// valueNode parses an override for a field of type t: strings verbatim,
+// lists of scalars comma-separated, everything else as YAML.
+func valueNode(t reflect.Type, value string) (*yaml.Node, error) {
+	if t.Kind() == reflect.String {
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value}, nil
+	}
+	if t.Kind() == reflect.Slice && scalar(t.Elem()) && !strings.HasPrefix(strings.TrimSpace(value), "[") {
+		seq := &yaml.Node{Kind: yaml.SequenceNode}
+		for _, item := range strings.Split(value, ",") {
+			n := &yaml.Node{Kind: yaml.ScalarNode, Value: strings.TrimSpace(item)}
+			if t.Elem().Kind() == reflect.String {
+				n.Tag = "!!str"
+			}
+			seq.Content = append(seq.Content, n)
+		}
+		return seq, nil
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(value), &doc); err != nil {
+		return nil, err
+	}
+	if len(doc.Content) == 0 {
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null"}, nil
+	}
+	clearLines(doc.Content[0]) // lines of an override say nothing
+	return doc.Content[0], nil
+}
+
+func scalar(t reflect.Type) bool {
+	return t.Kind() != reflect.Struct && t.Kind() != reflect.Slice && t.Kind() != reflect.Map
+}
+
+func clearLines(n *yaml.Node) {
+	n.Line, n.Column = 0, 0
+	for _, c := range n.Content {
+		clearLines(c)
+	}
+}
+
+// typeMsg drops yaml's "unmarshal errors" wrapping and line numbers, which
+// mean nothing for an override.
+func typeMsg(err error) string {
+	var te *yaml.TypeError
+	if !errors.As(err, &te) {
+		return err.Error()
+	}
+	msgs := make([]string, len(te.Errors))
+	for i, m := range te.Errors {
+		if _, rest, ok := strings.Cut(m, ": "); ok && strings.HasPrefix(m, "line ") {
+			m = rest
+		}
+		msgs[i] = m
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// refPattern matches ${VAR} and ${VAR:-default}.
+var refPattern = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)(?::-([^}]*))?\}`)
+
+// resolveRefs expands ${VAR} in the string values of n, which has type t.
+// Values of other types may use it too (port: ${PORT}).
+func resolveRefs(n *yaml.Node, t reflect.Type, path string, pos map[string]position, errs *Errors) {
+	eachString(n, t, path, func(n *yaml.Node, t reflect.Type, path string) {
+		v, err := expand(n.Value)
+		if err != nil {
+			p := pos[path]
+			*errs = append(*errs, FieldError{Path: path, Source: p.source, Line: p.line, Msg: err.Error()})
+			return
+		}
+		if v == n.Value {
+			return
+		}
+		n.Value, n.Style = v, 0
+		n.Tag = "" // resolve again: port: ${PORT} is an int
+		if t.Kind() == reflect.String {
+			n.Tag = "!!str"
+		}
+	})
+}
+
+// resolveSecrets replaces scheme:ref values of the fields in paths with
+// what the provider for scheme returns. Other fields are never touched, and
+// unknown schemes (https://..., user:pass@...) are left alone.
+func resolveSecrets(doc *yaml.Node, provs map[string]SecretProvider, paths map[string]bool, pos map[string]position, errs *Errors) {
+	eachString(doc, rootType, "", func(n *yaml.Node, t reflect.Type, path string) {
+		scheme, ref, ok := strings.Cut(n.Value, ":")
+		if !ok || !paths[path] || provs[scheme] == nil {
+			return
+		}
+		v, err := provs[scheme].Resolve(ref)
+		if err != nil {
+			p := pos[path]
+			*errs = append(*errs, FieldError{Path: path, Source: p.source, Line: p.line, Msg: err.Error()})
+			return
+		}
+		n.Value = v
+	})
+}
+
+// secretPaths lists the YAML paths of the string fields tagged
+// secret:"true", and secret:"opt-in" ones when sec enables them.
+func secretPaths(sec Secrets) map[string]bool {
+	out := map[string]bool{}
+	var walk func(t reflect.Type, path string)
+	walk = func(t reflect.Type, path string) {
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			name := yamlName(f)
+			if name == "" {
+				continue
+			}
+			p := join(path, name)
+			switch tag := f.Tag.Get("secret"); {
+			case f.Type.Kind() == reflect.Struct:
+				walk(f.Type, p)
+			case tag == "true", tag == "opt-in" && sec.DSN:
+				out[p] = true
+			}
+		}
+	}
+	walk(rootType, "")
+	return out
+}
+
+// eachString calls fn for every string scalar in n, which has type t.
+func eachString(n *yaml.Node, t reflect.Type, path string, fn func(n *yaml.Node, t reflect.Type, path string)) {
+	switch {
+	case t.Kind() == reflect.Struct && n.Kind == yaml.MappingNode:
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			if idx := fieldIndex(t, n.Content[i].Value); idx >= 0 {
+				eachString(n.Content[i+1], t.Field(idx).Type, join(path, n.Content[i].Value), fn)
+			}
+		}
+	case t.Kind() == reflect.Map && n.Kind == yaml.MappingNode:
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			eachString(n.Content[i+1], t.Elem(), join(path, n.Content[i].Value), fn)
+		}
+	case t.Kind() == reflect.Slice && n.Kind == yaml.SequenceNode:
+		for i, item := range n.Content {
+			eachString(item, t.Elem(), fmt.Sprintf("%s[%d]", path, i), fn)
+		}
+	case n.Kind == yaml.ScalarNode && n.ShortTag() == "!!str":
+		fn(n, t, path)
+	}
+}
+
+// expand resolves the ${VAR} references in one value.
+func expand(v string) (string, error) {
+	var missing []string
+	out := refPattern.ReplaceAllStringFunc(v, func(ref string) string {
+		m := refPattern.FindStringSubmatch(ref)
+		if val, ok := os.LookupEnv(m[1]); ok && val != "" {
+			return val
+		}
+		if strings.Contains(ref, ":-") {
+			return m[2]
+		}
+		missing = append(missing, "${"+m[1]+"}")
+		return ref
+	})
+	if len(missing) > 0 {
+		return "", fmt.Errorf("%s is not set", strings.Join(missing, ", "))
+	}
+	return out, nil
+}
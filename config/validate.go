@@ -0,0 +1,331 @@
+// config/validate.go
+package config // Strict decoding and validation with YAML paths
+
+import (
+	"fmt"
+	"net"
+	"net/netip"
+	"net/url"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultJWTSecret is the placeholder secret shipped in config.yaml; it is
+// refused when environment is production.
+const DefaultJWTSecret = "dev-change-me"
+
+// FieldError is one problem in the config, located by YAML path (and the
+// line in the file, when known).
+type FieldError struct {
+	Path   string
+	Source string // file when several are layered, or the GATEWAY_* variable / --set
+	Line   int
+	Msg    string
+}
+
+func (e FieldError) Error() string {
+	where := e.Source
+	if e.Line > 0 {
+		where = strings.TrimPrefix(fmt.Sprintf("%s line %d", e.Source, e.Line), " ")
+	}
+	switch {
+	case e.Path == "" && where == "":
+		return e.Msg
+	case e.Path == "":
+		return where + ": " + e.Msg
+	case where != "":
+		return fmt.Sprintf("%s (%s): %s", e.Path, where, e.Msg)
+	default:
+		return e.Path + ": " + e.Msg
+	}
+}
+
+// Errors lists every problem found, so one run reports them all.
+type Errors []FieldError
+
+func (e Errors) Error() string {
+	lines := make([]string, len(e))
+	for i, fe := range e {
+		lines[i] = fe.Error()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// walkKeys checks the mapping keys of n against t's yaml tags, recursing
+// into nested structs, slices and maps, and records where each path is
+// set. Keys that match no field are errors (a typo would otherwise
+// silently fall back to a default).
+func walkKeys(n *yaml.Node, t reflect.Type, path, source string, pos map[string]position, errs *Errors) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch {
+	case t.Kind() == reflect.Struct && n.Kind == yaml.MappingNode:
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			key, val := n.Content[i], n.Content[i+1]
+			p := join(path, key.Value)
+			pos[p] = position{source, key.Line}
+			idx := fieldIndex(t, key.Value)
+			if idx < 0 {
+				*errs = append(*errs, FieldError{Path: p, Source: source, Line: key.Line, Msg: "unknown field"})
+				continue
+			}
+			walkKeys(val, t.Field(idx).Type, p, source, pos, errs)
+		}
+	case t.Kind() == reflect.Slice && n.Kind == yaml.SequenceNode:
+		for i, item := range n.Content {
+			p := fmt.Sprintf("%s[%d]", path, i)
+			pos[p] = position{source, item.Line}
+			walkKeys(item, t.Elem(), p, source, pos, errs)
+		}
+	case t.Kind() == reflect.Map && n.Kind == yaml.MappingNode:
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			p := join(path, n.Content[i].Value)
+			pos[p] = position{source, n.Content[i].Line}
+			walkKeys(n.Content[i+1], t.Elem(), p, source, pos, errs)
+		}
+	}
+}
+
+func join(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// validator accumulates FieldErrors.
+type validator struct{ errs Errors }
+
+func (v *validator) add(path, format string, args ...any) {
+	v.errs = append(v.errs, FieldError{Path: path, Msg: fmt.Sprintf(format, args...)})
+}
+
+func (v *validator) check(ok bool, path, format string, args ...any) {
+	if !ok {
+		v.add(path, format, args...)
+	}
+}
+
+// oneOf accepts "" when allowed contains it.
+func (v *validator) oneOf(path, val string, allowed ...string) {
+	if !slices.Contains(allowed, val) {
+		shown := slices.DeleteFunc(slices.Clone(allowed), func(s string) bool { return s == "" })
+		v.add(path, "%q is not one of %s", val, strings.Join(shown, "|"))
+	}
+}
+
+func (v *validator) nonNegative(path string, n int) {
+	v.check(n >= 0, path, "must not be negative")
+}
+
+var logLevels = []string{"", "debug", "info", "warn", "error"}
+
+// Validate checks the whole config and returns Errors listing every
+// problem, or nil.
+func (c Root) Validate() error {
+	v := &validator{}
+	v.oneOf("environment", c.Environment, "", "development", "staging", "production")
+	c.Server.validate(v)
+	c.Security.JWT.validate(v, c.Environment)
+	c.RateLimit.validate(v)
+	c.Redis.validate(v)
+	v.oneOf("database.driver", c.Database.Driver, "sqlite", "mysql", "postgres")
+	v.check(c.Database.DSN != "", "database.dsn", "must be set")
+	c.Logging.validate(v)
+	v.oneOf("tracing.exporter", c.Tracing.Exporter, "", "none", "stdout", "otlp")
+	if r := c.Tracing.SampleRatio; r != nil {
+		v.check(*r >= 0 && *r <= 1, "tracing.sample_ratio", "must be between 0 and 1")
+	}
+	v.nonNegative("health.timeout_ms", c.Health.TimeoutMS)
+	v.nonNegative("secrets.refresh_ms", c.Secrets.RefreshMS)
+	for i, u := range c.Health.Upstreams {
+		p := fmt.Sprintf("health.upstreams[%d]", i)
+		v.check(u.Name != "", p+".name", "must be set")
+		v.check(isHTTPURL(u.URL), p+".url", "must be an http(s) URL")
+	}
+	if len(v.errs) == 0 {
+		return nil
+	}
+	return v.errs
+}
+
+func (s Server) validate(v *validator) {
+	v.check(s.Port >= 1 && s.Port <= 65535, "server.port", "must be between 1 and 65535")
+	v.nonNegative("server.timeouts.read_ms", s.Timeouts.ReadMS)
+	v.nonNegative("server.timeouts.read_header_ms", s.Timeouts.ReadHeaderMS)
+	v.nonNegative("server.timeouts.write_ms", s.Timeouts.WriteMS)
+	v.nonNegative("server.timeouts.idle_ms", s.Timeouts.IdleMS)
+	v.nonNegative("server.shutdown.delay_ms", s.Shutdown.DelayMS)
+	v.nonNegative("server.shutdown.timeout_ms", s.Shutdown.TimeoutMS)
+	s.CORS.validate(v, "server.cors")
+	for i, rt := range s.CORS.Routes {
+		p := fmt.Sprintf("server.cors.routes[%d]", i)
+		v.check(strings.HasPrefix(rt.Prefix, "/"), p+".prefix", "must start with /")
+		s.CORS.For(rt).validate(v, p)
+	}
+
+	a := s.Admin
+	if a.Address == "" {
+		return
+	}
+	if _, _, err := net.SplitHostPort(a.Address); err != nil {
+		v.add("server.admin.address", "must be host:port")
+	}
+	v.check((a.Auth.BasicUser == "") == (a.Auth.BasicPassword == ""), "server.admin.auth",
+		"basic_user and basic_password must be set together")
+	v.check(a.Auth.BasicUser != "" || a.Auth.BearerToken != "" || len(a.Auth.AllowIPs) > 0, "server.admin.auth",
+		"needs basic, bearer or allow_ips auth")
+	for i, ip := range a.Auth.AllowIPs {
+		_, perr := netip.ParsePrefix(ip)
+		_, aerr := netip.ParseAddr(ip)
+		v.check(perr == nil || aerr == nil, fmt.Sprintf("server.admin.auth.allow_ips[%d]", i), "%q is not an IP or CIDR", ip)
+	}
+}
+
+// validate checks one CORS policy; path is server.cors or a route.
+func (c CORS) validate(v *validator, path string) {
+	for i, o := range c.AllowedOrigins {
+		v.check(validOrigin(o), fmt.Sprintf("%s.allowed_origins[%d]", path, i),
+			"%q is not \"*\" or scheme://host[:port] (https://*.example.com for subdomains)", o)
+	}
+	v.check(!c.AllowCredentials || !slices.Contains(c.AllowedOrigins, "*"), path+".allow_credentials",
+		"cannot be combined with allowed_origins \"*\"; list the origins")
+	for i, m := range c.AllowedMethods {
+		v.check(m != "" && strings.ToUpper(m) == m && !strings.ContainsAny(m, " ,"), fmt.Sprintf("%s.allowed_methods[%d]", path, i),
+			"%q is not an upper-case method", m)
+	}
+	v.nonNegative(path+".max_age_s", c.MaxAgeS)
+}
+
+// validOrigin accepts "*", "null" and scheme://host[:port], where host may
+// start with "*." (any subdomain).
+func validOrigin(o string) bool {
+	if o == "*" || o == "null" {
+		return true
+	}
+	u, err := url.Parse(strings.Replace(o, "://*.", "://wildcard.", 1))
+	return err == nil && u.Scheme != "" && u.Host != "" && u.Path == "" && u.RawQuery == "" && u.User == nil &&
+		!strings.Contains(strings.TrimPrefix(o, u.Scheme+"://*."), "*")
+}
+
+func (j JWT) validate(v *validator, env string) {
+	switch {
+	case j.Secret == "":
+		v.add("security.jwt.secret", "must be set (or JWT_SECRET)")
+	case env == "production" && j.Secret == DefaultJWTSecret:
+		v.add("security.jwt.secret", "the shipped default secret is refused in production; set JWT_SECRET")
+	}
+	v.check(j.TTLMinutes > 0, "security.jwt.ttl_minutes", "must be positive")
+	v.nonNegative("security.jwt.refresh_ttl_minutes", j.RefreshTTL)
+}
+
+func (r RateLimit) validate(v *validator) {
+	if r.Enabled {
+		v.oneOf("rate_limit.strategy", r.Strategy, "memory", "redis", "hybrid")
+		v.check(r.RequestsPerMinute > 0, "rate_limit.requests_per_minute", "must be positive when rate limiting is enabled")
+	}
+	v.oneOf("rate_limit.failure_mode", r.FailureMode, "", "open", "closed", "local")
+	v.nonNegative("rate_limit.burst", r.Burst)
+	v.nonNegative("rate_limit.shards", r.Shards)
+	v.nonNegative("rate_limit.max_keys", r.MaxKeys)
+	v.nonNegative("rate_limit.idle_ttl_ms", r.IdleTTLMS)
+	v.nonNegative("rate_limit.janitor_interval_ms", r.JanitorIntervalMS)
+	v.nonNegative("rate_limit.sync_interval_ms", r.SyncIntervalMS)
+	v.nonNegative("rate_limit.max_local_pending", r.MaxLocalPending)
+	v.nonNegative("rate_limit.access_list_refresh_ms", r.AccessListRefreshMS)
+
+	c := r.Concurrency
+	if !c.Enabled {
+		return
+	}
+	v.check(c.MaxInFlight > 0, "rate_limit.concurrency.max_in_flight", "must be positive when concurrency limiting is enabled")
+	v.nonNegative("rate_limit.concurrency.per_key_in_flight", c.PerKeyInFlight)
+	v.nonNegative("rate_limit.concurrency.queue_size", c.QueueSize)
+	v.nonNegative("rate_limit.concurrency.queue_timeout_ms", c.QueueTimeoutMS)
+	v.check(c.MinInFlight <= c.MaxInFlight, "rate_limit.concurrency.min_in_flight", "must not exceed max_in_flight")
+}
+
+func (r Redis) validate(v *validator) {
+	v.oneOf("redis.mode", r.Mode, "", "standalone", "sentinel")
+	if r.Mode == "sentinel" {
+		v.check(r.MasterName != "", "redis.master_name", "must be set in sentinel mode")
+		v.check(len(r.Addresses) > 0, "redis.addresses", "must list the sentinel nodes")
+	}
+	v.nonNegative("redis.db", r.DB)
+	v.nonNegative("redis.health_check_interval_ms", r.HealthCheckIntervalMS)
+}
+
+func (l Logging) validate(v *validator) {
+	v.oneOf("logging.level", strings.ToLower(l.Level), logLevels...)
+	for _, name := range sortedKeys(l.Levels) {
+		v.oneOf("logging.levels."+name, strings.ToLower(l.Levels[name]), logLevels...)
+	}
+	v.oneOf("logging.redis_level", strings.ToLower(l.RedisLevel), logLevels...)
+	v.oneOf("logging.file.encoding", l.File.Encoding, "", "json", "console")
+
+	for i, s := range l.Sinks {
+		p := fmt.Sprintf("logging.sinks[%d]", i)
+		v.oneOf(p+".type", s.Type, "syslog", "http", "file")
+		v.oneOf(p+".level", strings.ToLower(s.Level), logLevels...)
+		switch s.Type {
+		case "syslog":
+			v.oneOf(p+".syslog.network", s.Syslog.Network, "", "udp", "tcp", "unix", "unixgram")
+			v.check(s.Syslog.Address != "", p+".syslog.address", "must be set")
+			v.check(s.Syslog.Facility >= 0 && s.Syslog.Facility <= 23, p+".syslog.facility", "must be between 0 and 23")
+		case "http":
+			v.check(isHTTPURL(s.HTTP.URL), p+".http.url", "must be an http(s) URL")
+			v.oneOf(p+".http.format", s.HTTP.Format, "", "ndjson", "json")
+		}
+	}
+
+	r := l.Redaction
+	if r.Enabled {
+		v.oneOf("logging.redaction.mode", r.Mode, "", "mask", "hash")
+		hashing := r.Mode == "hash"
+		for i, f := range r.Fields {
+			v.oneOf(fmt.Sprintf("logging.redaction.fields[%d].mode", i), f.Mode, "", "mask", "hash")
+			hashing = hashing || f.Mode == "hash"
+		}
+		for i, b := range r.Builtin {
+			v.oneOf(fmt.Sprintf("logging.redaction.builtin[%d]", i), b, "email", "bearer", "jwt", "password", "card")
+		}
+		for i, pt := range r.Patterns {
+			p := fmt.Sprintf("logging.redaction.patterns[%d]", i)
+			if _, err := regexp.Compile(pt.Regex); err != nil {
+				v.add(p+".regex", "%v", err)
+			}
+			v.oneOf(p+".mode", pt.Mode, "", "mask", "hash")
+			hashing = hashing || pt.Mode == "hash"
+		}
+		// without a key anyone can recompute the digests of guessable values
+		v.check(!hashing || r.HashKey != "", "logging.redaction.hash_key", "must be set when a rule hashes")
+	}
+
+	rt := l.Redis.Retention
+	v.nonNegative("logging.redis.retention.days", rt.Days)
+	v.nonNegative("logging.redis.retention.access_days", rt.AccessDays)
+	for _, lvl := range sortedKeys(rt.Levels) {
+		v.nonNegative("logging.redis.retention.levels."+lvl, rt.Levels[lvl])
+	}
+	v.nonNegative("logging.redis.retention.archive.keep_days", rt.Archive.KeepDays)
+}
+
+// sortedKeys keeps the error order stable across runs.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+func isHTTPURL(s string) bool {
+	u, err := url.Parse(s)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
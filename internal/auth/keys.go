@@ -0,0 +1,89 @@
+package auth // Rotating JWT signing/verification keys
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"example.com/api-gateway/config"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Keys holds the JWT config used for signing and, after a rotation, the
+// previous secrets. A retired secret still verifies tokens until the
+// longest-lived token it could have signed has expired, so rotating the
+// secret does not log everybody out.
+type Keys struct {
+	mu      sync.RWMutex
+	cfg     config.JWT
+	retired []retiredKey
+}
+
+type retiredKey struct {
+	secret string
+	until  time.Time
+}
+
+// NewKeys builds a key set signing with cfg.
+func NewKeys(cfg config.JWT) *Keys {
+	return &Keys{cfg: cfg}
+}
+
+// Config returns the current signing config.
+func (k *Keys) Config() config.JWT {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	return k.cfg
+}
+
+// Rotate makes cfg the signing config. When the secret changes, the old
+// one is kept for verification for the old token TTL. It reports whether
+// the secret changed.
+func (k *Keys) Rotate(cfg config.JWT) bool {
+	now := time.Now()
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	kept := k.retired[:0]
+	for _, r := range k.retired {
+		if now.Before(r.until) && r.secret != cfg.Secret {
+			kept = append(kept, r)
+		}
+	}
+	k.retired = kept
+	rotated := cfg.Secret != k.cfg.Secret
+	if rotated {
+		ttl := time.Duration(k.cfg.TTLMinutes) * time.Minute
+		k.retired = append(k.retired, retiredKey{secret: k.cfg.Secret, until: now.Add(ttl)})
+	}
+	k.cfg = cfg
+	return rotated
+}
+
+// Sign issues a token with the current secret.
+func (k *Keys) Sign(sub, role string) (string, error) {
+	return Sign(k.Config(), sub, role)
+}
+
+// Parse verifies token against the current secret, then the retired ones.
+func (k *Keys) Parse(token string) (*Claims, error) {
+	k.mu.RLock()
+	cfg, retired := k.cfg, append([]retiredKey(nil), k.retired...)
+	k.mu.RUnlock()
+
+	cl, err := Parse(cfg, token)
+	if err == nil || !errors.Is(err, jwt.ErrTokenSignatureInvalid) {
+		return cl, err
+	}
+	now := time.Now()
+	for _, r := range retired {
+		if now.After(r.until) {
+			continue
+		}
+		old := cfg
+		old.Secret = r.secret
+		if cl, rerr := Parse(old, token); rerr == nil || !errors.Is(rerr, jwt.ErrTokenSignatureInvalid) {
+			return cl, rerr
+		}
+	}
+	return nil, err
+}
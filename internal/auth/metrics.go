@@ -0,0 +1,28 @@
+// internal/auth/metrics.go
+package auth // Prometheus counters for authentication failures
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Failure reasons (the only values of the reason label).
+const (
+	ReasonMissingToken = "missing_token" // no "Authorization: Bearer"
+	ReasonExpiredToken = "expired_token" // valid signature, past exp
+	ReasonInvalidToken = "invalid_token" // bad signature, alg or claims
+	ReasonUnknownUser  = "unknown_user"  // login for an email not on file
+	ReasonInactiveUser = "inactive_user" // login for a deactivated account
+	ReasonBadPassword  = "bad_password"  // login with a wrong password
+)
+
+var failures = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "gateway_auth_failures_total",
+	Help: "Rejected authentications (token checks and logins), by reason.",
+}, []string{"reason"})
+
+// CountFailure records one rejected authentication; reason is one of the
+// Reason* constants.
+func CountFailure(reason string) {
+	failures.WithLabelValues(reason).Inc()
+}
@@ -0,0 +1,132 @@
+// internal/capture/body.go
+package capture // Size-limited body recording, decoded by content type
+
+import (
+	"encoding/json"
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"example.com/api-gateway/config"
+	"example.com/api-gateway/internal/redact"
+)
+
+// credentials masks what a capture must never store, whatever the
+// redaction config says: credential headers, password/token fields in
+// JSON and form bodies, and bearer tokens, JWTs and password=... in text.
+var credentials = mustRedactor(config.Redaction{
+	Enabled: true,
+	Fields: []config.RedactField{
+		{Name: "authorization"}, {Name: "proxy-authorization"}, {Name: "cookie"}, {Name: "set-cookie"}, {Name: "x-api-key"},
+		{Name: "password"}, {Name: "passwd"}, {Name: "secret"}, {Name: "client_secret"},
+		{Name: "token"}, {Name: "access_token"}, {Name: "accessToken"}, {Name: "refresh_token"}, {Name: "refreshToken"}, {Name: "id_token"},
+	},
+	Builtin: []string{"bearer", "jwt", "password"},
+})
+
+func mustRedactor(cfg config.Redaction) *redact.Redactor {
+	r, err := redact.New(cfg)
+	if err != nil {
+		panic(err)
+	}
+	return r
+}
+
+// Buffer keeps the first limit bytes written to it and counts the rest.
+type Buffer struct {
+	limit int
+	data  []byte
+	total int
+}
+
+// NewBuffer returns a buffer holding at most limit bytes.
+func NewBuffer(limit int) *Buffer { return &Buffer{limit: limit} }
+
+// Write never fails, so it can sit behind io.TeeReader.
+func (b *Buffer) Write(p []byte) (int, error) {
+	n := len(p)
+	b.total += n
+	if room := b.limit - len(b.data); room > 0 {
+		if len(p) > room {
+			p = p[:room]
+		}
+		b.data = append(b.data, p...)
+	}
+	return n, nil
+}
+
+// Limit drops buffered bytes beyond n (rules may ask for less than the
+// middleware buffered).
+func (b *Buffer) Limit(n int) {
+	if n > 0 && n < len(b.data) {
+		b.data = b.data[:n]
+	}
+}
+
+// Describe renders a captured body for the log entry:
+// 🔹 JSON is decoded, so field-name redaction rules apply to its keys
+// 🔹 form bodies become a key/value map for the same reason
+// 🔹 other text is kept as a string; binary types are summarized only
+// Truncated JSON cannot be decoded and is kept as text. Credentials are
+// masked in all three forms.
+func Describe(contentType string, b *Buffer) map[string]any {
+	out := map[string]any{"bytes": b.total}
+	if contentType != "" {
+		out["contentType"] = contentType
+	}
+	if b.total == 0 {
+		return out
+	}
+	truncated := b.total > len(b.data)
+	if truncated {
+		out["truncated"] = true
+	}
+	mt, _, _ := mime.ParseMediaType(contentType)
+	switch {
+	case isJSON(mt):
+		var v any
+		if !truncated && json.Unmarshal(b.data, &v) == nil {
+			out["json"] = credentials.Value("", v)
+			return out
+		}
+		out["text"] = credentials.String(string(b.data))
+	case mt == "application/x-www-form-urlencoded":
+		if vals, err := url.ParseQuery(string(b.data)); err == nil {
+			form := make(map[string]any, len(vals))
+			for k, v := range vals {
+				form[k] = strings.Join(v, ",")
+			}
+			out["form"] = credentials.Map(form)
+			return out
+		}
+		out["text"] = credentials.String(string(b.data))
+	case isText(mt) && utf8.Valid(b.data):
+		out["text"] = credentials.String(string(b.data))
+	default:
+		out["omitted"] = "binary content (" + strconv.Itoa(b.total) + " bytes)"
+	}
+	return out
+}
+
+func isJSON(mt string) bool {
+	return mt == "application/json" || strings.HasSuffix(mt, "+json")
+}
+
+func isText(mt string) bool {
+	return mt == "" || strings.HasPrefix(mt, "text/") || mt == "application/xml" ||
+		strings.HasSuffix(mt, "+xml") || mt == "application/javascript"
+}
+
+// Headers flattens headers into a map (multi-values comma-joined) so
+// field-name redaction rules apply per header. Authorization, Cookie,
+// Set-Cookie and similar credential headers are always masked.
+func Headers(h http.Header) map[string]any {
+	out := make(map[string]any, len(h))
+	for k, v := range h {
+		out[k] = strings.Join(v, ", ")
+	}
+	return credentials.Map(out)
+}
@@ -0,0 +1,229 @@
+// internal/capture/rules.go
+package capture // Time-boxed debug capture rules shared via Redis
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"example.com/api-gateway/internal/redis"
+	"go.uber.org/zap"
+)
+
+// redisKey is the hash holding rules (field = rule ID, value = JSON).
+const redisKey = "capture:rules"
+
+// Validation errors returned by Add.
+var (
+	ErrNoTarget    = errors.New("rule needs a route or a userId")
+	ErrBadDuration = errors.New("duration must be positive and within the configured maximum")
+)
+
+// Rule enables capture for matching requests until ExpiresAt.
+type Rule struct {
+	ID           string    `json:"id"`
+	Route        string    `json:"route,omitempty"`  // route template ("/users/:id") or path prefix ending in "*"
+	UserID       string    `json:"userId,omitempty"` // auth.sub
+	MaxBodyBytes int       `json:"maxBodyBytes"`     // per body (request and response)
+	CreatedBy    string    `json:"createdBy,omitempty"`
+	ExpiresAt    time.Time `json:"expiresAt"`
+}
+
+// matches reports whether a request on route/path by userID is covered.
+// When both Route and UserID are set, both must match.
+func (r Rule) matches(route, path, userID string) bool {
+	if r.Route != "" {
+		if p, ok := strings.CutSuffix(r.Route, "*"); ok {
+			if !strings.HasPrefix(path, p) {
+				return false
+			}
+		} else if r.Route != route {
+			return false
+		}
+	}
+	return r.UserID == "" || r.UserID == userID
+}
+
+// Rules caches the active rules locally and refreshes them from Redis, so
+// the request path never waits on Redis. Expired rules stop matching at
+// once and are deleted on the next refresh.
+type Rules struct {
+	c            redis.Client
+	refresh      time.Duration
+	maxDuration  time.Duration
+	maxBodyBytes int
+	log          *zap.Logger
+
+	mu    sync.RWMutex
+	rules []Rule
+
+	stop     chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+// NewRules builds the store; call Start to load it and keep it fresh.
+func NewRules(c redis.Client, refresh, maxDuration time.Duration, maxBodyBytes int, log *zap.Logger) *Rules {
+	if refresh <= 0 {
+		refresh = 5 * time.Second
+	}
+	if maxDuration <= 0 {
+		maxDuration = time.Hour
+	}
+	if maxBodyBytes <= 0 {
+		maxBodyBytes = 4096
+	}
+	return &Rules{
+		c:            c,
+		refresh:      refresh,
+		maxDuration:  maxDuration,
+		maxBodyBytes: maxBodyBytes,
+		log:          log,
+		stop:         make(chan struct{}),
+		done:         make(chan struct{}),
+	}
+}
+
+// Start loads rules and refreshes them periodically until Stop.
+func (r *Rules) Start() {
+	r.load(context.Background())
+	go func() {
+		defer close(r.done)
+		t := time.NewTicker(r.refresh)
+		defer t.Stop()
+		for {
+			select {
+			case <-r.stop:
+				return
+			case <-t.C:
+				r.load(context.Background())
+			}
+		}
+	}()
+}
+
+// Stop ends the refresh loop.
+func (r *Rules) Stop() {
+	r.stopOnce.Do(func() { close(r.stop) })
+	<-r.done
+}
+
+// MaxBodyBytes is the largest body a rule may capture.
+func (r *Rules) MaxBodyBytes() int { return r.maxBodyBytes }
+
+// Active reports whether any unexpired rule exists (cheap pre-check).
+func (r *Rules) Active() bool {
+	if r == nil {
+		return false
+	}
+	now := time.Now()
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, rule := range r.rules {
+		if now.Before(rule.ExpiresAt) {
+			return true
+		}
+	}
+	return false
+}
+
+// Match returns the first unexpired rule covering the request.
+func (r *Rules) Match(route, path, userID string) (Rule, bool) {
+	if r == nil {
+		return Rule{}, false
+	}
+	now := time.Now()
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, rule := range r.rules {
+		if now.Before(rule.ExpiresAt) && rule.matches(route, path, userID) {
+			return rule, true
+		}
+	}
+	return Rule{}, false
+}
+
+// List returns the unexpired rules.
+func (r *Rules) List() []Rule {
+	now := time.Now()
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	out := make([]Rule, 0, len(r.rules))
+	for _, rule := range r.rules {
+		if now.Before(rule.ExpiresAt) {
+			out = append(out, rule)
+		}
+	}
+	return out
+}
+
+// Add validates and stores a rule active for d, returning it with its ID.
+func (r *Rules) Add(ctx context.Context, rule Rule, d time.Duration) (Rule, error) {
+	if rule.Route == "" && rule.UserID == "" {
+		return Rule{}, ErrNoTarget
+	}
+	if d <= 0 || d > r.maxDuration {
+		return Rule{}, ErrBadDuration
+	}
+	if rule.MaxBodyBytes <= 0 || rule.MaxBodyBytes > r.maxBodyBytes {
+		rule.MaxBodyBytes = r.maxBodyBytes
+	}
+	rule.ID = newID()
+	rule.ExpiresAt = time.Now().Add(d).UTC()
+	b, _ := json.Marshal(rule)
+	if err := r.c.HSet(ctx, redisKey, rule.ID, b).Err(); err != nil {
+		return Rule{}, err
+	}
+	r.load(ctx)
+	return rule, nil
+}
+
+// Remove deletes a rule; it reports whether it existed.
+func (r *Rules) Remove(ctx context.Context, id string) (bool, error) {
+	n, err := r.c.HDel(ctx, redisKey, id).Result()
+	if err != nil {
+		return false, err
+	}
+	r.load(ctx)
+	return n > 0, nil
+}
+
+// load replaces the cache with the Redis contents, deleting expired rules.
+func (r *Rules) load(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, time.Second)
+	defer cancel()
+	raw, err := r.c.HGetAll(ctx, redisKey).Result()
+	if err != nil {
+		r.log.Debug("capture rules refresh failed, keeping cached rules", zap.Error(err))
+		return
+	}
+	now := time.Now()
+	fresh := make([]Rule, 0, len(raw))
+	var expired []string
+	for id, v := range raw {
+		var rule Rule
+		if json.Unmarshal([]byte(v), &rule) != nil || !now.Before(rule.ExpiresAt) {
+			expired = append(expired, id)
+			continue
+		}
+		fresh = append(fresh, rule)
+	}
+	if len(expired) > 0 {
+		_ = r.c.HDel(ctx, redisKey, expired...).Err()
+	}
+	r.mu.Lock()
+	r.rules = fresh
+	r.mu.Unlock()
+}
+
+// newID returns a short random rule ID.
+func newID() string {
+	b := make([]byte, 6)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -31,7 +31,7 @@ func (h *AuthHandler) Login(c *gin.Context) {
 	if err := h.v.Struct(req); err != nil {
 		c.JSON(422, gin.H{"error": err.Error()}); return
 	}
-	token, u, err := h.s.Login(req.Email, req.Password)
+	token, u, err := h.s.Login(c.Request.Context(), req.Email, req.Password)
 	if err != nil { c.JSON(401, gin.H{"error": err.Error()}); return }
 	c.JSON(200, dto.LoginResponse{Token: token})
 	_ = u // could return user profile too if desired
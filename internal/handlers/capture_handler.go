@@ -0,0 +1,78 @@
+// internal/handlers/capture_handler.go
+package handlers // Admin debug capture endpoints
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"example.com/api-gateway/internal/capture"
+)
+
+// CaptureHandler lets admins switch request/response capture on for a
+// route or a user, for a limited time.
+type CaptureHandler struct {
+	rules *capture.Rules
+}
+
+// NewCaptureHandler builds a new CaptureHandler.
+func NewCaptureHandler(rules *capture.Rules) *CaptureHandler {
+	return &CaptureHandler{rules: rules}
+}
+
+// captureRequest is the body for POST /api/capture.
+type captureRequest struct {
+	Route        string `json:"route"`                               // "/users/:id" or "/api/*"
+	UserID       string `json:"userId"`                              // auth.sub
+	DurationSec  int    `json:"durationSec" binding:"required,min=1"` // capture window
+	MaxBodyBytes int    `json:"maxBodyBytes"`                        // optional, capped by config
+}
+
+// List handles GET /api/capture (admin only).
+func (h *CaptureHandler) List(c *gin.Context) {
+	rules := h.rules.List()
+	c.JSON(http.StatusOK, gin.H{"count": len(rules), "items": rules})
+}
+
+// Create handles POST /api/capture (admin only).
+// 🔹 Captures show up in /api/logs as DEBUG "http.capture" entries
+// 🔹 Filter them with ?q=http.capture or fetch one request via /api/logs/:requestId
+func (h *CaptureHandler) Create(c *gin.Context) {
+	var req captureRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+		return
+	}
+	admin, _ := c.Get("auth.sub")
+	createdBy, _ := admin.(string)
+	rule, err := h.rules.Add(c.Request.Context(), capture.Rule{
+		Route:        req.Route,
+		UserID:       req.UserID,
+		MaxBodyBytes: req.MaxBodyBytes,
+		CreatedBy:    createdBy,
+	}, time.Duration(req.DurationSec)*time.Second)
+	if err != nil {
+		if errors.Is(err, capture.ErrNoTarget) || errors.Is(err, capture.ErrBadDuration) {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to store capture rule"})
+		return
+	}
+	c.JSON(http.StatusCreated, rule)
+}
+
+// Delete handles DELETE /api/capture/:id (admin only).
+func (h *CaptureHandler) Delete(c *gin.Context) {
+	found, err := h.rules.Remove(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete capture rule"})
+		return
+	}
+	if !found {
+		c.JSON(http.StatusNotFound, gin.H{"error": "capture rule not found"})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
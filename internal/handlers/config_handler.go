@@ -0,0 +1,32 @@
+// internal/handlers/config_handler.go
+package handlers // Admin view of config reloads
+
+import (
+	"net/http"
+
+	"example.com/api-gateway/internal/reload"
+	"github.com/gin-gonic/gin"
+)
+
+// ConfigHandler reports hot reload outcomes.
+type ConfigHandler struct {
+	reloader *reload.Reloader // nil when reload is not wired
+}
+
+// NewConfigHandler builds a new ConfigHandler.
+func NewConfigHandler(r *reload.Reloader) *ConfigHandler {
+	return &ConfigHandler{reloader: r}
+}
+
+// Status handles GET /api/config/status (admin only).
+// 🔹 version counts applied reloads; last is the latest attempt (applied,
+// unchanged or rejected with the error)
+// 🔹 last.restartRequired lists changed fields that only take effect after
+// a restart (listen address, DB driver, ...)
+func (h *ConfigHandler) Status(c *gin.Context) {
+	if h.reloader == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "config reload is disabled"})
+		return
+	}
+	c.JSON(http.StatusOK, h.reloader.Status())
+}
@@ -0,0 +1,50 @@
+// internal/handlers/health_handler.go
+package handlers // Liveness and readiness probes
+
+import (
+	"net/http"
+
+	"example.com/api-gateway/internal/health"
+	"github.com/gin-gonic/gin"
+)
+
+// HealthHandler serves the Kubernetes-style probes.
+type HealthHandler struct {
+	ready  *health.Readiness // nil = no checks, always ready
+	detail bool              // errors and latencies in /health/ready (admin listener only)
+}
+
+// NewHealthHandler builds a new HealthHandler; detail exposes each check's
+// error and latency, which only the admin listener should.
+func NewHealthHandler(ready *health.Readiness, detail bool) *HealthHandler {
+	return &HealthHandler{ready: ready, detail: detail}
+}
+
+// Live handles GET /health/live: the process serves requests. It never
+// looks at dependencies, so a Redis or DB outage does not get pods restarted.
+func (h *HealthHandler) Live(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// Ready handles GET /health/ready.
+// 🔹 200 with status "ready" when every critical check passes
+// 🔹 503 with "not_ready" (a critical check failed) or "draining" (shutting down)
+// 🔹 components lists each check with its status; latency and error only
+// with detail, so the public listener does not leak internals
+// 🔹 the checks run at most once a second, however many probes arrive
+func (h *HealthHandler) Ready(c *gin.Context) {
+	if h.ready == nil {
+		c.JSON(http.StatusOK, health.Report{Status: "ready", Components: []health.Component{}})
+		return
+	}
+	rep := h.ready.Check(c.Request.Context())
+	status := http.StatusOK
+	if !rep.Ready() {
+		status = http.StatusServiceUnavailable
+	}
+	if !h.detail {
+		c.JSON(status, rep.Brief())
+		return
+	}
+	c.JSON(status, rep)
+}
@@ -0,0 +1,88 @@
+// internal/handlers/log_archive_handler.go
+package handlers // Admin download of archived log days
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	rlog "example.com/api-gateway/internal/redis"
+	"github.com/gin-gonic/gin"
+)
+
+// LogArchiveHandler serves the files written by rlog.Archiver.
+type LogArchiveHandler struct {
+	archiver *rlog.Archiver // nil when archival is disabled
+}
+
+// NewLogArchiveHandler builds a new LogArchiveHandler.
+func NewLogArchiveHandler(archiver *rlog.Archiver) *LogArchiveHandler {
+	return &LogArchiveHandler{archiver: archiver}
+}
+
+// List handles GET /api/logs/archive (admin only).
+func (h *LogArchiveHandler) List(c *gin.Context) {
+	if h.archiver == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "log archival is disabled"})
+		return
+	}
+	files, err := h.archiver.Files("")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list archives"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"count": len(files), "items": files})
+}
+
+// Download handles GET /api/logs/archive/:day (admin only).
+// 🔹 Streams gzipped NDJSON; a day with several class files (default,
+// access, error, ...) is sent as one multi-member gzip stream
+// 🔹 ?class=access limits the download to one class ("default" = unclassified)
+func (h *LogArchiveHandler) Download(c *gin.Context) {
+	if h.archiver == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "log archival is disabled"})
+		return
+	}
+	day := c.Param("day")
+	files, err := h.archiver.Files(day)
+	if err != nil {
+		if errors.Is(err, rlog.ErrBadDay) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list archives"})
+		return
+	}
+	if class, ok := c.GetQuery("class"); ok {
+		if class == "default" {
+			class = ""
+		}
+		kept := files[:0]
+		for _, f := range files {
+			if f.Class == class {
+				kept = append(kept, f)
+			}
+		}
+		files = kept
+	}
+	if len(files) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "no archive for this day"})
+		return
+	}
+
+	c.Header("Content-Type", "application/gzip")
+	c.Header("Content-Disposition", `attachment; filename="logs-`+day+`.ndjson.gz"`)
+	c.Status(http.StatusOK)
+	for _, f := range files {
+		r, err := h.archiver.Open(f)
+		if err != nil {
+			_ = c.Error(err)
+			return // headers are sent; the truncated body fails gzip's checksum
+		}
+		_, err = io.Copy(c.Writer, r)
+		r.Close()
+		if err != nil {
+			return
+		}
+	}
+}
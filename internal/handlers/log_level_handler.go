@@ -0,0 +1,67 @@
+// internal/handlers/log_level_handler.go
+package handlers // Admin runtime log level endpoints
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"example.com/api-gateway/internal/logger"
+)
+
+// LogLevelHandler changes log levels without a restart.
+type LogLevelHandler struct {
+	levels *logger.Levels
+}
+
+// NewLogLevelHandler builds a new LogLevelHandler.
+func NewLogLevelHandler(levels *logger.Levels) *LogLevelHandler {
+	return &LogLevelHandler{levels: levels}
+}
+
+// logLevelRequest is the body for PUT /api/log-level.
+type logLevelRequest struct {
+	Logger string `json:"logger"` // logger name (e.g. "rate", "access"); empty = base level
+	Level  string `json:"level"`  // debug|info|warn|error; empty with logger = remove override
+}
+
+// Get handles GET /api/log-level (admin only).
+func (h *LogLevelHandler) Get(c *gin.Context) {
+	h.respond(c)
+}
+
+// Set handles PUT /api/log-level (admin only).
+// 🔹 {"level":"debug"} changes the base level
+// 🔹 {"logger":"rate","level":"debug"} overrides one logger and its children
+// 🔹 {"logger":"rate","level":""} removes that override
+func (h *LogLevelHandler) Set(c *gin.Context) {
+	var req logLevelRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+		return
+	}
+	if req.Logger != "" && req.Level == "" {
+		h.levels.ClearOverride(req.Logger)
+		h.respond(c)
+		return
+	}
+	if req.Level == "" {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "level is required"})
+		return
+	}
+	lvl, err := logger.ParseLevel(req.Level)
+	if err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Logger == "" {
+		h.levels.SetBase(lvl)
+	} else {
+		h.levels.SetOverride(req.Logger, lvl)
+	}
+	h.respond(c)
+}
+
+func (h *LogLevelHandler) respond(c *gin.Context) {
+	base, overrides := h.levels.Snapshot()
+	c.JSON(http.StatusOK, gin.H{"level": base, "overrides": overrides})
+}
@@ -2,39 +2,136 @@
 package handlers // Admin logs endpoint
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	rlog "example.com/api-gateway/internal/redis"
 )
 
+// maxLogLimit caps ?limit= on GET /api/logs; larger values are clamped.
+const maxLogLimit = 1000
+
 // LogsHandler exposes admin-only endpoints to inspect logs saved in Redis.
 type LogsHandler struct {
-	RClient rlog.Client // Redis client
+	Store *rlog.LogStore // Redis log stream
 }
 
 // NewLogsHandler builds a new LogsHandler.
-func NewLogsHandler(rc rlog.Client) *LogsHandler {
-	return &LogsHandler{RClient: rc}
+func NewLogsHandler(store *rlog.LogStore) *LogsHandler {
+	return &LogsHandler{Store: store}
 }
 
-// ListRecent handles GET /api/logs?limit=N (admin only).
-// It loads up to N recent logs from Redis and returns JSON.
+// ListRecent handles GET /api/logs?limit=N&cursor=C (admin only).
+// Optional filters: level, from, to (RFC3339), requestId, userId, path
+// (prefix), status (e.g. 4xx) and q (free text in the message).
+// 🔹 Without filters it pages through the stream (and legacy list) as before
+// 🔹 With filters it scans the stream, or the per-day lists for ranges older
+//    than the stream
+// 🔹 400 for a cursor the listing did not hand out, e.g. an unfiltered
+//    (legacy list) cursor sent with filters
+// 🔹 limit defaults to 100 and is clamped to maxLogLimit
+// Returns JSON with nextCursor to fetch the following page ("" when done).
 func (h *LogsHandler) ListRecent(c *gin.Context) {
 	limit := 100
 	if q := c.Query("limit"); q != "" {
 		if v, err := strconv.Atoi(q); err == nil && v > 0 {
-			limit = v
+			limit = min(v, maxLogLimit)
 		}
 	}
-	logs, err := rlog.LoadRecentLogs(c.Request.Context(), h.RClient, limit)
+	filter, err := ParseLogFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var (
+		logs []rlog.LogEntry
+		next string
+	)
+	cursor := c.Query("cursor")
+	if filter.IsZero() && !strings.HasPrefix(cursor, "d:") {
+		logs, next, err = h.Store.LoadRecent(c.Request.Context(), limit, cursor)
+	} else {
+		logs, next, err = h.Store.Query(c.Request.Context(), filter, limit, cursor)
+	}
+	if errors.Is(err, rlog.ErrBadCursor) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load logs"})
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{
-		"count": len(logs),
-		"items": logs,
+		"count":      len(logs),
+		"items":      logs,
+		"nextCursor": next,
 	})
 }
+
+// ByRequest handles GET /api/logs/:requestId (admin only).
+// It returns every entry correlated with one request, oldest first.
+// 🔹 truncated: true when the scan budget ran out first; entries may be
+//    missing (an empty result is then 200, not 404) and
+//    GET /api/logs?requestId=...&from=... narrows the search
+func (h *LogsHandler) ByRequest(c *gin.Context) {
+	logs, truncated, err := h.Store.ByRequestID(c.Request.Context(), c.Param("requestId"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load logs"})
+		return
+	}
+	if len(logs) == 0 && !truncated {
+		c.JSON(http.StatusNotFound, gin.H{"error": "no logs for request"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"requestId": c.Param("requestId"),
+		"count":     len(logs),
+		"items":     logs,
+		"truncated": truncated,
+	})
+}
+
+// ParseLogFilter reads log filters from the query string.
+func ParseLogFilter(c *gin.Context) (rlog.LogFilter, error) {
+	f := rlog.LogFilter{
+		Level:      strings.ToUpper(c.Query("level")),
+		RequestID:  c.Query("requestId"),
+		UserID:     c.Query("userId"),
+		PathPrefix: c.Query("path"),
+		Text:       c.Query("q"),
+	}
+	var err error
+	if f.From, err = parseTime(c.Query("from")); err != nil {
+		return f, fmt.Errorf("invalid from: %w", err)
+	}
+	if f.To, err = parseTime(c.Query("to")); err != nil {
+		return f, fmt.Errorf("invalid to: %w", err)
+	}
+	if !f.From.IsZero() && !f.To.IsZero() && f.To.Before(f.From) {
+		return f, fmt.Errorf("to must not be before from")
+	}
+	if s := c.Query("status"); s != "" {
+		// Accept "4xx", "4XX" or "4".
+		d := strings.TrimSuffix(strings.ToLower(s), "xx")
+		v, convErr := strconv.Atoi(d)
+		if convErr != nil || v < 1 || v > 5 {
+			return f, fmt.Errorf("invalid status %q (want 1xx..5xx)", s)
+		}
+		f.StatusClass = v
+	}
+	return f, nil
+}
+
+// parseTime parses an optional RFC3339 timestamp ("" = zero time).
+func parseTime(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.RFC3339, s)
+}
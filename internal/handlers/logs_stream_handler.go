@@ -0,0 +1,172 @@
+// internal/http/handlers/logs_stream_handler.go
+package handlers // Live log tail over SSE / WebSocket
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	rlog "example.com/api-gateway/internal/redis"
+)
+
+// Tail tuning.
+const (
+	tailBuffer     = 256              // per-client queue; overflow is dropped and reported
+	tailHeartbeat  = 15 * time.Second // keeps proxies from closing idle streams
+	tailWriteLimit = 10 * time.Second // a client that can't take a write in time is cut off
+)
+
+// LogStreamHandler pushes new log entries to admins as they are saved.
+type LogStreamHandler struct {
+	Tail      *rlog.Tailer
+	Heartbeat time.Duration
+	Buffer    int // per-client queue (0 = tailBuffer)
+	upgrader  websocket.Upgrader
+}
+
+// NewLogStreamHandler builds a handler fed by tail.
+func NewLogStreamHandler(tail *rlog.Tailer) *LogStreamHandler {
+	return &LogStreamHandler{Tail: tail, Heartbeat: tailHeartbeat}
+}
+
+// Stream handles GET /api/logs/stream (admin only).
+// It accepts the same filters as GET /api/logs.
+// 🔹 A WebSocket upgrade request gets a WebSocket: one JSON entry per text
+//    message, pings as heartbeats
+// 🔹 Anything else gets Server-Sent Events: "log" events carrying JSON entries,
+//    ": ping" comments as heartbeats
+// 🔹 When the client falls behind, entries are dropped and a "dropped" event
+//    (SSE) or {"dropped":N} message (WS) reports how many
+func (h *LogStreamHandler) Stream(c *gin.Context) {
+	filter, err := ParseLogFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if websocket.IsWebSocketUpgrade(c.Request) {
+		h.serveWS(c, filter)
+		return
+	}
+	h.serveSSE(c, filter)
+}
+
+// serveSSE writes an event stream until the client goes away.
+func (h *LogStreamHandler) serveSSE(c *gin.Context, filter rlog.LogFilter) {
+	rc := http.NewResponseController(c.Writer)
+	sub := h.Tail.Subscribe(filter, h.buffer())
+	defer h.Tail.Unsubscribe(sub)
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no") // disable nginx buffering
+	c.Status(http.StatusOK)
+
+	write := func(format string, args ...any) bool {
+		// Per-write deadline replaces the server-wide WriteTimeout.
+		_ = rc.SetWriteDeadline(time.Now().Add(tailWriteLimit))
+		if _, err := fmt.Fprintf(c.Writer, format, args...); err != nil {
+			return false
+		}
+		return rc.Flush() == nil
+	}
+	if !write(": connected\n\n") {
+		return
+	}
+
+	hb := time.NewTicker(h.heartbeat())
+	defer hb.Stop()
+	ctx := c.Request.Context()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-h.Tail.Closing():
+			return // shutting down: the client reconnects to another replica
+		case <-hb.C:
+			if !write(": ping\n\n") {
+				return
+			}
+		case e := <-sub.C:
+			if n := sub.Dropped(); n > 0 && !write("event: dropped\ndata: %d\n\n", n) {
+				return
+			}
+			b, _ := json.Marshal(e)
+			if !write("id: %s\nevent: log\ndata: %s\n\n", e.ID, b) {
+				return
+			}
+		}
+	}
+}
+
+// serveWS upgrades and writes entries until either side closes.
+func (h *LogStreamHandler) serveWS(c *gin.Context, filter rlog.LogFilter) {
+	conn, err := h.upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		return // Upgrade already replied with an error status
+	}
+	defer conn.Close()
+	sub := h.Tail.Subscribe(filter, h.buffer())
+	defer h.Tail.Unsubscribe(sub)
+
+	// Reader: the client sends nothing useful, but reading is needed to
+	// process pongs and notice the close.
+	hbEvery := h.heartbeat()
+	closed := make(chan struct{})
+	conn.SetReadLimit(512)
+	_ = conn.SetReadDeadline(time.Now().Add(2 * hbEvery))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(2 * hbEvery))
+	})
+	go func() {
+		defer close(closed)
+		for {
+			if _, _, err := conn.NextReader(); err != nil {
+				return
+			}
+		}
+	}()
+
+	hb := time.NewTicker(hbEvery)
+	defer hb.Stop()
+	for {
+		select {
+		case <-closed:
+			return
+		case <-h.Tail.Closing():
+			_ = conn.WriteControl(websocket.CloseMessage,
+				websocket.FormatCloseMessage(websocket.CloseGoingAway, "server shutting down"),
+				time.Now().Add(tailWriteLimit))
+			return
+		case <-hb.C:
+			if conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(tailWriteLimit)) != nil {
+				return
+			}
+		case e := <-sub.C:
+			_ = conn.SetWriteDeadline(time.Now().Add(tailWriteLimit))
+			if n := sub.Dropped(); n > 0 && conn.WriteJSON(gin.H{"dropped": n}) != nil {
+				return
+			}
+			if conn.WriteJSON(e) != nil {
+				return
+			}
+		}
+	}
+}
+
+func (h *LogStreamHandler) buffer() int {
+	if h.Buffer <= 0 {
+		return tailBuffer
+	}
+	return h.Buffer
+}
+
+func (h *LogStreamHandler) heartbeat() time.Duration {
+	if h.Heartbeat <= 0 {
+		return tailHeartbeat
+	}
+	return h.Heartbeat
+}
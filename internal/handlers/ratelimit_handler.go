@@ -0,0 +1,115 @@
+// internal/handlers/ratelimit_handler.go
+package handlers // Admin rate limit endpoints
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"example.com/api-gateway/internal/rate"
+)
+
+// RateLimitHandler exposes admin-only endpoints to inspect and reset limiter
+// state and to manage the runtime allow/deny lists.
+type RateLimitHandler struct {
+	limiter rate.Limiter
+	lists   *rate.AccessList
+}
+
+// NewRateLimitHandler builds a new RateLimitHandler. lists may be nil.
+func NewRateLimitHandler(l rate.Limiter, lists *rate.AccessList) *RateLimitHandler {
+	return &RateLimitHandler{limiter: l, lists: lists}
+}
+
+// accessListEntry is the body for POST /api/ratelimit-lists/:list.
+type accessListEntry struct {
+	Entry string `json:"entry" binding:"required"` // IP, CIDR or user ID
+}
+
+// Get handles GET /api/ratelimits/:key (admin only).
+// 🔹 Key is what the limiter uses: the user ID when authenticated, else the client IP.
+func (h *RateLimitHandler) Get(c *gin.Context) {
+	in, ok := h.limiter.(rate.Inspector)
+	if !ok {
+		c.JSON(http.StatusNotImplemented, gin.H{"error": "limiter does not support inspection"})
+		return
+	}
+	st, err := in.Inspect(c.Request.Context(), c.Param("key"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to read limiter state"})
+		return
+	}
+	c.JSON(http.StatusOK, st)
+}
+
+// Reset handles DELETE /api/ratelimits/:key (admin only).
+// 🔹 Clears the bucket/window so the key starts fresh.
+func (h *RateLimitHandler) Reset(c *gin.Context) {
+	in, ok := h.limiter.(rate.Inspector)
+	if !ok {
+		c.JSON(http.StatusNotImplemented, gin.H{"error": "limiter does not support reset"})
+		return
+	}
+	if err := in.Reset(c.Request.Context(), c.Param("key")); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to reset limiter state"})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// Lists handles GET /api/ratelimit-lists (admin only).
+func (h *RateLimitHandler) Lists(c *gin.Context) {
+	if h.lists == nil {
+		c.JSON(http.StatusNotImplemented, gin.H{"error": "access lists disabled"})
+		return
+	}
+	c.JSON(http.StatusOK, h.lists.Entries())
+}
+
+// AddEntry handles POST /api/ratelimit-lists/:list (admin only).
+// 🔹 Body: {"entry": "10.0.0.0/8"}; :list is allow or deny.
+func (h *RateLimitHandler) AddEntry(c *gin.Context) {
+	if h.lists == nil {
+		c.JSON(http.StatusNotImplemented, gin.H{"error": "access lists disabled"})
+		return
+	}
+	var req accessListEntry
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid json"})
+		return
+	}
+	if err := h.lists.Add(c.Request.Context(), c.Param("list"), req.Entry); err != nil {
+		h.listError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, h.lists.Entries())
+}
+
+// RemoveEntry handles DELETE /api/ratelimit-lists/:list?entry=... (admin only).
+// 🔹 The entry travels in the query string because CIDRs contain '/'.
+// 🔹 404 when the entry is not listed
+func (h *RateLimitHandler) RemoveEntry(c *gin.Context) {
+	if h.lists == nil {
+		c.JSON(http.StatusNotImplemented, gin.H{"error": "access lists disabled"})
+		return
+	}
+	if err := h.lists.Remove(c.Request.Context(), c.Param("list"), c.Query("entry")); err != nil {
+		h.listError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// listError maps validation failures to 422, a missing entry to 404 and
+// storage failures to 500.
+func (h *RateLimitHandler) listError(c *gin.Context, err error) {
+	if errors.Is(err, rate.ErrNoSuchEntry) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, rate.ErrUnknownList) || errors.Is(err, rate.ErrInvalidEntry) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update access list"})
+}
@@ -179,7 +179,7 @@ func NewUserHandler(s *service.UserService) *UserHandler {
 // List handles GET /users (admin only).
 // 🔹 Fetches a page of users and returns a safe DTO slice.
 func (h *UserHandler) List(c *gin.Context) {
-	users, err := h.s.List(0, 100)
+	users, err := h.s.List(c.Request.Context(), 0, 100)
 	if err != nil {
 		c.JSON(500, gin.H{"error": err.Error()})
 		return
@@ -226,7 +226,7 @@ func (h *UserHandler) Create(c *gin.Context) {
 		UpdatedAt:   time.Now(),
 	}
 
-	if err := h.s.Create(u); err != nil {
+	if err := h.s.Create(c.Request.Context(), u); err != nil {
 		c.JSON(500, gin.H{"error": err.Error()})
 		return
 	}
@@ -240,7 +240,7 @@ func (h *UserHandler) Create(c *gin.Context) {
 // 🔹 Step 2: Return safe DTO or 404
 func (h *UserHandler) Get(c *gin.Context) {
 	id := c.Param("id")
-	u, err := h.s.Get(id)
+	u, err := h.s.Get(c.Request.Context(), id)
 	if err != nil {
 		c.JSON(404, gin.H{"error": "not found"})
 		return
@@ -270,7 +270,7 @@ func (h *UserHandler) Patch(c *gin.Context) {
 		return
 	}
 
-	u, err := h.s.Get(id)
+	u, err := h.s.Get(c.Request.Context(), id)
 	if err != nil {
 		c.JSON(404, gin.H{"error": "not found"})
 		return
@@ -298,7 +298,7 @@ func (h *UserHandler) Patch(c *gin.Context) {
 		u.Active = *req.Active // may be false; repo must not ignore zero values
 	}
 
-	if err := h.s.Update(u); err != nil {
+	if err := h.s.Update(c.Request.Context(), u); err != nil {
 		c.JSON(500, gin.H{"error": err.Error()})
 		return
 	}
@@ -311,7 +311,7 @@ func (h *UserHandler) Patch(c *gin.Context) {
 // Delete handles DELETE /users/:id (admin only).
 func (h *UserHandler) Delete(c *gin.Context) {
 	id := c.Param("id")
-	if err := h.s.Delete(id); err != nil {
+	if err := h.s.Delete(c.Request.Context(), id); err != nil {
 		c.JSON(404, gin.H{"error": "not found"})
 		return
 	}
@@ -322,7 +322,7 @@ func (h *UserHandler) Delete(c *gin.Context) {
 // 🔹 Uses auth.sub injected by the Authenticated middleware.
 func (h *UserHandler) Me(c *gin.Context) {
 	if sub, ok := c.Get("auth.sub"); ok {
-		if u, err := h.s.Get(sub.(string)); err == nil {
+		if u, err := h.s.Get(c.Request.Context(), sub.(string)); err == nil {
 			c.JSON(200, dto.UserResponse{
 				ID: u.ID, Name: u.Name, Email: u.Email, Role: u.Role, Active: u.Active,
 			})
@@ -0,0 +1,266 @@
+// internal/health/health.go
+package health // Readiness checks of the gateway's dependencies
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"example.com/api-gateway/internal/tracing"
+)
+
+const (
+	defaultTimeout = 2 * time.Second
+	cacheFor       = time.Second // a report is reused this long
+)
+
+// Checker is one dependency probed by /health/ready. Check must honour
+// ctx: it carries the per-check timeout.
+type Checker interface {
+	Name() string
+	Check(ctx context.Context) error
+}
+
+// funcChecker adapts a function to Checker.
+type funcChecker struct {
+	name string
+	fn   func(context.Context) error
+}
+
+func (f funcChecker) Name() string                    { return f.name }
+func (f funcChecker) Check(ctx context.Context) error { return f.fn(ctx) }
+
+// Func returns a Checker named name that runs fn.
+func Func(name string, fn func(context.Context) error) Checker {
+	return funcChecker{name: name, fn: fn}
+}
+
+// Pinger is implemented by stores that can be pinged (the GORM repository).
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// Ping returns a Checker that pings p.
+func Ping(name string, p Pinger) Checker {
+	return Func(name, p.Ping)
+}
+
+// HTTP returns a Checker that GETs url with client (nil = a plain client)
+// and fails on transport errors and 5xx answers. The request carries the
+// probe's trace (tracing.Transport), so upstream spans join it.
+func HTTP(name, url string, client *http.Client) Checker {
+	traced := http.Client{}
+	if client != nil {
+		traced = *client
+	}
+	traced.Transport = tracing.Transport(traced.Transport)
+	client = &traced
+	return Func(name, func(ctx context.Context) error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return err
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		if resp.StatusCode >= 500 {
+			return fmt.Errorf("status %d", resp.StatusCode)
+		}
+		return nil
+	})
+}
+
+// Component is the result of one check.
+type Component struct {
+	Name      string  `json:"name"`
+	Status    string  `json:"status"` // up|down|degraded (a failing optional check)
+	Critical  bool    `json:"critical"`
+	LatencyMS float64 `json:"latencyMs"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// Report is the body of /health/ready.
+type Report struct {
+	Status     string      `json:"status"` // ready|not_ready|draining
+	Components []Component `json:"components"`
+}
+
+// Ready reports whether the report means "send traffic".
+func (r Report) Ready() bool { return r.Status == "ready" }
+
+// Brief is the report without errors, latencies or criticality, for
+// callers that must not learn about the internals (the public listener).
+type Brief struct {
+	Status     string           `json:"status"`
+	Components []BriefComponent `json:"components"`
+}
+
+// BriefComponent is one check in a Brief.
+type BriefComponent struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+}
+
+// Brief strips r down to the status and the check names.
+func (r Report) Brief() Brief {
+	b := Brief{Status: r.Status, Components: make([]BriefComponent, len(r.Components))}
+	for i, c := range r.Components {
+		b.Components[i] = BriefComponent{Name: c.Name, Status: c.Status}
+	}
+	return b
+}
+
+type entry struct {
+	c        Checker
+	critical bool
+}
+
+// Readiness runs the registered checks concurrently, each with its own
+// timeout. A failing critical check makes the gateway not ready; a failing
+// optional one (e.g. Redis when the gateway runs degraded without it) is
+// only reported. Once draining it answers not ready without checking.
+// A report is reused for a second and concurrent callers share one run,
+// so probes cannot multiply the load on the dependencies.
+type Readiness struct {
+	timeout  time.Duration
+	mu       sync.RWMutex
+	checks   []entry
+	draining atomic.Bool
+
+	runMu   sync.Mutex
+	running chan struct{} // closed when the run in flight ends; nil = none
+	last    Report
+	lastAt  time.Time
+	lastGen int // checks registered when last ran; a Register invalidates it
+	gen     int
+}
+
+// NewReadiness builds an empty Readiness; timeout <= 0 means 2s.
+func NewReadiness(timeout time.Duration) *Readiness {
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+	return &Readiness{timeout: timeout}
+}
+
+// Register adds a critical check.
+func (r *Readiness) Register(c Checker) { r.add(c, true) }
+
+// RegisterOptional adds a check whose failure does not fail readiness.
+func (r *Readiness) RegisterOptional(c Checker) { r.add(c, false) }
+
+func (r *Readiness) add(c Checker, critical bool) {
+	r.mu.Lock()
+	r.checks = append(r.checks, entry{c: c, critical: critical})
+	r.mu.Unlock()
+	r.runMu.Lock()
+	r.gen++
+	r.runMu.Unlock()
+}
+
+// SetDraining flips readiness off for good (graceful shutdown), so load
+// balancers stop routing here while in-flight requests finish.
+func (r *Readiness) SetDraining() { r.draining.Store(true) }
+
+// Draining reports whether SetDraining was called.
+func (r *Readiness) Draining() bool { return r.draining.Load() }
+
+// Check returns the per-component breakdown, sorted by name: the last
+// report when it is under a second old, else the result of a new run
+// (shared with the callers that arrive while it is in flight).
+func (r *Readiness) Check(ctx context.Context) Report {
+	if r.Draining() {
+		return Report{Status: "draining", Components: []Component{}}
+	}
+	for {
+		r.runMu.Lock()
+		if r.lastGen == r.gen && !r.lastAt.IsZero() && time.Since(r.lastAt) < cacheFor {
+			rep := r.last
+			r.runMu.Unlock()
+			return rep
+		}
+		if wait := r.running; wait != nil {
+			r.runMu.Unlock()
+			select {
+			case <-wait:
+				continue
+			case <-ctx.Done():
+				return Report{Status: "not_ready", Components: []Component{}}
+			}
+		}
+		done := make(chan struct{})
+		r.running = done
+		gen := r.gen
+		r.runMu.Unlock()
+
+		// not the caller's context: the others waiting must not see its cancellation
+		rep := r.runAll(context.WithoutCancel(ctx))
+		r.runMu.Lock()
+		r.last, r.lastAt, r.lastGen, r.running = rep, time.Now(), gen, nil
+		r.runMu.Unlock()
+		close(done)
+		return rep
+	}
+}
+
+// runAll runs every check concurrently.
+func (r *Readiness) runAll(ctx context.Context) Report {
+	r.mu.RLock()
+	checks := append([]entry(nil), r.checks...)
+	r.mu.RUnlock()
+
+	out := make([]Component, len(checks))
+	var wg sync.WaitGroup
+	for i, e := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			out[i] = r.run(ctx, e)
+		}()
+	}
+	wg.Wait()
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+
+	rep := Report{Status: "ready", Components: out}
+	for _, c := range out {
+		if c.Critical && c.Status != "up" {
+			rep.Status = "not_ready"
+		}
+	}
+	return rep
+}
+
+// run executes one check under the timeout; a check that ignores ctx is
+// abandoned (reported down) when the timeout passes.
+func (r *Readiness) run(ctx context.Context, e entry) Component {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+	start := time.Now()
+	res := make(chan error, 1)
+	go func() { res <- e.c.Check(ctx) }()
+	var err error
+	select {
+	case err = <-res:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+	comp := Component{
+		Name:      e.c.Name(),
+		Status:    "up",
+		Critical:  e.critical,
+		LatencyMS: float64(time.Since(start).Microseconds()) / 1000,
+	}
+	if err != nil {
+		comp.Status, comp.Error = "down", err.Error()
+		if !e.critical {
+			comp.Status = "degraded"
+		}
+	}
+	return comp
+}
@@ -0,0 +1,80 @@
+// internal/http/graceful.go
+package httpx // Serving with connection draining on shutdown
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"example.com/api-gateway/config"
+	"example.com/api-gateway/internal/health"
+	"go.uber.org/zap"
+)
+
+const defaultShutdownTimeout = 20 * time.Second
+
+// Graceful serves until its context ends, then drains:
+// 🔹 readiness flips to "draining", so load balancers and the kubelet stop
+// sending new requests
+// 🔹 after delay (time for endpoints to be removed) the listener closes and
+// srv.Shutdown waits up to timeout for in-flight requests
+// 🔹 connections still busy at the deadline are closed
+type Graceful struct {
+	ready   *health.Readiness // nil = nothing to flip
+	delay   time.Duration
+	timeout time.Duration
+	log     *zap.Logger
+}
+
+// NewGraceful builds a Graceful from cfg (timeout defaults to 20s).
+func NewGraceful(cfg config.Shutdown, ready *health.Readiness, log *zap.Logger) *Graceful {
+	timeout := time.Duration(cfg.TimeoutMS) * time.Millisecond
+	if timeout <= 0 {
+		timeout = defaultShutdownTimeout
+	}
+	return &Graceful{
+		ready:   ready,
+		delay:   time.Duration(max(cfg.DelayMS, 0)) * time.Millisecond,
+		timeout: timeout,
+		log:     log,
+	}
+}
+
+// Serve serves srv on ln until ctx is done and then drains it. It returns
+// nil after a clean drain, the serve error if serving fails first, or an
+// error wrapping context.DeadlineExceeded when requests outlived the timeout.
+func (g *Graceful) Serve(ctx context.Context, srv *http.Server, ln net.Listener) error {
+	served := make(chan error, 1)
+	go func() { served <- srv.Serve(ln) }()
+
+	select {
+	case err := <-served:
+		return err
+	case <-ctx.Done():
+	}
+
+	if g.ready != nil {
+		g.ready.SetDraining()
+	}
+	g.log.Info("shutdown: draining", zap.Duration("delay", g.delay), zap.Duration("timeout", g.timeout))
+	if g.delay > 0 {
+		time.Sleep(g.delay) // keep serving while endpoints are removed upstream
+	}
+
+	sctx, cancel := context.WithTimeout(context.Background(), g.timeout)
+	defer cancel()
+	start := time.Now()
+	if err := srv.Shutdown(sctx); err != nil {
+		_ = srv.Close()
+		<-served
+		return fmt.Errorf("drain: %w", err)
+	}
+	if err := <-served; err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	g.log.Info("shutdown: drained", zap.Duration("took", time.Since(start)))
+	return nil
+}
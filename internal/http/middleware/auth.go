@@ -3,26 +3,40 @@
 package middleware // JWT authentication: parse + attach identity
 
 import (
+	"errors"
 	"strings"
 
 	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
 	"example.com/api-gateway/config"
 	"example.com/api-gateway/internal/auth"
 )
 
 // Authenticated ensures valid JWT and stores sub/role in context.
 func Authenticated(jwtCfg config.JWT) gin.HandlerFunc {
+	return AuthenticatedKeys(auth.NewKeys(jwtCfg))
+}
+
+// AuthenticatedKeys is Authenticated verifying against a rotating key set
+// (tokens signed with a recently retired secret still pass).
+func AuthenticatedKeys(keys *auth.Keys) gin.HandlerFunc {
 	return func(c *gin.Context) {
 			// 🔹 Expect "Authorization: Bearer <token>"
 		h := c.GetHeader("Authorization")
 		if !strings.HasPrefix(h, "Bearer ") {
+			auth.CountFailure(auth.ReasonMissingToken)
 			c.AbortWithStatusJSON(401, gin.H{"error": "authentication required", "code": "unauthorized"})
 			return
 		}
 		token := strings.TrimPrefix(h, "Bearer ")
 		// 🔹 Parse & validate JWT (signature + claims)
-		claims, err := auth.Parse(jwtCfg, token)
+		claims, err := keys.Parse(token)
 		if err != nil {
+			if errors.Is(err, jwt.ErrTokenExpired) {
+				auth.CountFailure(auth.ReasonExpiredToken)
+			} else {
+				auth.CountFailure(auth.ReasonInvalidToken)
+			}
 			c.AbortWithStatusJSON(401, gin.H{"error": "invalid token", "code": "unauthorized"})
 			return
 		}
@@ -0,0 +1,94 @@
+// Debug capture of request/response headers and bodies.
+package middleware // Runtime-toggled capture into the Redis log store
+
+import (
+	"io"
+	"net/http"
+	"time"
+
+	"example.com/api-gateway/internal/capture"
+	"example.com/api-gateway/internal/redact"
+	rlog "example.com/api-gateway/internal/redis"
+	"github.com/gin-gonic/gin"
+)
+
+// captureWriter tees the response body into a bounded buffer.
+type captureWriter struct {
+	gin.ResponseWriter
+	buf *capture.Buffer
+}
+
+// Unwrap lets http.ResponseController reach the connection (SSE deadlines).
+func (w *captureWriter) Unwrap() http.ResponseWriter { return w.ResponseWriter }
+
+func (w *captureWriter) Write(b []byte) (int, error) {
+	w.buf.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *captureWriter) WriteString(s string) (int, error) {
+	w.buf.Write([]byte(s))
+	return w.ResponseWriter.WriteString(s)
+}
+
+// Capture records matching requests when an admin has enabled a capture
+// rule (see capture.Rules). With no active rule it only does one cheap check.
+// 🔹 Bodies are buffered up to the configured limit while the handler runs;
+// the rule is matched afterwards, when auth.sub is known
+// 🔹 Credentials are always masked (see capture.Headers and Describe);
+// headers and bodies then go through red before being enqueued as a
+// DEBUG "http.capture" entry carrying the request ID
+func Capture(rules *capture.Rules, async *rlog.AsyncLogger, red *redact.Redactor) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if async == nil || !rules.Active() {
+			c.Next()
+			return
+		}
+
+		reqBuf := capture.NewBuffer(rules.MaxBodyBytes())
+		if c.Request.Body != nil {
+			c.Request.Body = struct {
+				io.Reader
+				io.Closer
+			}{io.TeeReader(c.Request.Body, reqBuf), c.Request.Body}
+		}
+		respBuf := capture.NewBuffer(rules.MaxBodyBytes())
+		c.Writer = &captureWriter{ResponseWriter: c.Writer, buf: respBuf}
+		start := time.Now()
+
+		c.Next()
+
+		uid, _ := c.Get("auth.sub")
+		userID, _ := uid.(string)
+		rule, ok := rules.Match(c.FullPath(), c.Request.URL.Path, userID)
+		if !ok {
+			return
+		}
+		reqBuf.Limit(rule.MaxBodyBytes)
+		respBuf.Limit(rule.MaxBodyBytes)
+		async.Enqueue(rlog.LogEntry{
+			Timestamp: start.UTC().Format(time.RFC3339),
+			Level:     "DEBUG",
+			Message:   "http.capture",
+			Context: red.Map(map[string]any{
+				"requestId": c.GetString("req.id"),
+				"rule":      rule.ID,
+				"method":    c.Request.Method,
+				"path":      c.Request.URL.Path,
+				"query":     c.Request.URL.RawQuery,
+				"route":     c.FullPath(),
+				"status":    c.Writer.Status(),
+				"duration":  time.Since(start).String(),
+				"userID":    userID,
+				"request": map[string]any{
+					"headers": capture.Headers(c.Request.Header),
+					"body":    capture.Describe(c.ContentType(), reqBuf),
+				},
+				"response": map[string]any{
+					"headers": capture.Headers(c.Writer.Header()),
+					"body":    capture.Describe(c.Writer.Header().Get("Content-Type"), respBuf),
+				},
+			}),
+		})
+	}
+}
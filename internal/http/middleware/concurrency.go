@@ -0,0 +1,35 @@
+// Cap in-flight requests per route template; shed with 503 when saturated.
+
+package middleware // Concurrency limit middleware wiring
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"example.com/api-gateway/internal/rate"
+)
+
+// Concurrency creates a middleware using the provided ConcurrencyLimiter.
+// Key strategy: the matched route template (c.FullPath), so the per-key cap
+// bounds work per route regardless of path parameters.
+func Concurrency(limiter rate.ConcurrencyLimiter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if limiter == nil {
+			c.Next()
+			return
+		}
+		key := c.FullPath()
+		if key == "" {
+			key = "unmatched"
+		}
+		release, retry, ok := limiter.Acquire(c.Request.Context(), key)
+		if !ok {
+			c.Writer.Header().Set("Retry-After", strconv.Itoa(int((retry + time.Second - 1) / time.Second)))
+			c.AbortWithStatusJSON(503, gin.H{"error": "server overloaded, retry later", "code": "service_unavailable"})
+			return
+		}
+		defer release()
+		c.Next()
+	}
+}
@@ -0,0 +1,185 @@
+// Cross-origin resource sharing for the public listener.
+package middleware // CORS policy from server.cors, with per-route overrides
+
+import (
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"example.com/api-gateway/config"
+	"github.com/gin-gonic/gin"
+)
+
+// Defaults for the fields server.cors leaves empty.
+var (
+	corsMethods = []string{"GET", "POST", "PATCH", "DELETE"}
+	corsHeaders = []string{"Authorization", "Content-Type", "X-Request-Id"}
+	corsExposed = []string{"X-Request-Id", "X-RateLimit-Limit", "X-RateLimit-Remaining", "Retry-After"}
+)
+
+const corsMaxAgeS = 600
+
+// corsPolicy is one CORS config, compiled for lookups.
+type corsPolicy struct {
+	anyOrigin   bool
+	origins     map[string]bool
+	wildcards   []string // "https://" + ".example.com" for https://*.example.com
+	credentials bool
+	methods     map[string]bool
+	allowMethod string
+	allowHeader string // "" with anyHeader
+	anyHeader   bool
+	expose      string
+	maxAge      string
+}
+
+func newCORSPolicy(c config.CORS) *corsPolicy {
+	p := &corsPolicy{origins: map[string]bool{}, methods: map[string]bool{}, credentials: c.AllowCredentials}
+	for _, o := range c.AllowedOrigins {
+		o = strings.ToLower(strings.TrimSuffix(o, "/"))
+		switch {
+		case o == "*":
+			p.anyOrigin = true
+		case strings.Contains(o, "://*."):
+			scheme, host, _ := strings.Cut(o, "://*")
+			p.wildcards = append(p.wildcards, scheme+"://\x00"+host)
+		default:
+			p.origins[o] = true
+		}
+	}
+	methods := orDefault(c.AllowedMethods, corsMethods)
+	for _, m := range methods {
+		p.methods[m] = true
+	}
+	p.allowMethod = strings.Join(methods, ", ")
+	headers := orDefault(c.AllowedHeaders, corsHeaders)
+	p.anyHeader = slices.Contains(headers, "*")
+	p.allowHeader = strings.Join(headers, ", ")
+	p.expose = strings.Join(orDefault(c.ExposedHeaders, corsExposed), ", ")
+	maxAge := c.MaxAgeS
+	if maxAge == 0 {
+		maxAge = corsMaxAgeS
+	}
+	p.maxAge = strconv.Itoa(maxAge)
+	return p
+}
+
+func orDefault(v, def []string) []string {
+	if v == nil {
+		return def
+	}
+	return v
+}
+
+// allows reports whether origin may read responses.
+func (p *corsPolicy) allows(origin string) bool {
+	origin = strings.ToLower(origin)
+	if p.anyOrigin || p.origins[origin] {
+		return true
+	}
+	for _, w := range p.wildcards {
+		scheme, suffix, _ := strings.Cut(w, "\x00")
+		host, ok := strings.CutPrefix(origin, scheme)
+		if ok && len(host) > len(suffix) && strings.HasSuffix(host, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// CORS applies server.cors: it answers preflights (204, or 403 for an
+// origin, method or header that is not allowed) before auth and rate
+// limiting run, and adds the Access-Control-* headers to the responses of
+// allowed origins.
+// 🔹 "*" is sent only without credentials; otherwise the origin is echoed
+// with Vary: Origin, so caches keep one copy per origin
+// 🔹 routes override the policy for a path prefix (longest wins)
+func CORS(cfg config.CORS) gin.HandlerFunc {
+	base := newCORSPolicy(cfg)
+	type route struct {
+		prefix string
+		policy *corsPolicy
+	}
+	routes := make([]route, 0, len(cfg.Routes))
+	for _, rt := range cfg.Routes {
+		routes = append(routes, route{rt.Prefix, newCORSPolicy(cfg.For(rt))})
+	}
+	sort.SliceStable(routes, func(i, j int) bool { return len(routes[i].prefix) > len(routes[j].prefix) })
+
+	return func(c *gin.Context) {
+		h := c.Writer.Header()
+		h.Add("Vary", "Origin")
+		origin := c.GetHeader("Origin")
+		if origin == "" {
+			c.Next()
+			return
+		}
+		p := base
+		for _, rt := range routes {
+			if strings.HasPrefix(c.Request.URL.Path, rt.prefix) {
+				p = rt.policy
+				break
+			}
+		}
+		preflight := c.Request.Method == http.MethodOptions && c.GetHeader("Access-Control-Request-Method") != ""
+		if !p.allows(origin) {
+			if preflight {
+				c.AbortWithStatus(http.StatusForbidden)
+				return
+			}
+			c.Next() // served without CORS headers: the browser hides it
+			return
+		}
+
+		if p.anyOrigin && !p.credentials {
+			h.Set("Access-Control-Allow-Origin", "*")
+		} else {
+			h.Set("Access-Control-Allow-Origin", origin)
+		}
+		if p.credentials {
+			h.Set("Access-Control-Allow-Credentials", "true")
+		}
+		if !preflight {
+			if p.expose != "" {
+				h.Set("Access-Control-Expose-Headers", p.expose)
+			}
+			c.Next()
+			return
+		}
+
+		h.Add("Vary", "Access-Control-Request-Method")
+		h.Add("Vary", "Access-Control-Request-Headers")
+		if !p.methods[c.GetHeader("Access-Control-Request-Method")] {
+			c.AbortWithStatus(http.StatusForbidden)
+			return
+		}
+		requested := c.GetHeader("Access-Control-Request-Headers")
+		allowHeader := p.allowHeader
+		if p.anyHeader {
+			allowHeader = requested
+		} else if !headersAllowed(requested, p.allowHeader) {
+			c.AbortWithStatus(http.StatusForbidden)
+			return
+		}
+		h.Set("Access-Control-Allow-Methods", p.allowMethod)
+		if allowHeader != "" {
+			h.Set("Access-Control-Allow-Headers", allowHeader)
+		}
+		h.Set("Access-Control-Max-Age", p.maxAge)
+		c.AbortWithStatus(http.StatusNoContent)
+	}
+}
+
+// headersAllowed reports whether every header in the comma-separated
+// requested list is in allowed (case-insensitive).
+func headersAllowed(requested, allowed string) bool {
+	list := strings.Split(strings.ToLower(allowed), ", ")
+	for _, h := range strings.Split(requested, ",") {
+		if h = strings.ToLower(strings.TrimSpace(h)); h != "" && !slices.Contains(list, h) {
+			return false
+		}
+	}
+	return true
+}
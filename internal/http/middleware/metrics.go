@@ -0,0 +1,77 @@
+// Request count, latency and in-flight metrics per route template.
+package middleware // Prometheus HTTP metrics with bounded labels
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Labels stay bounded: route is the matched template (c.FullPath) or
+// "unmatched", method is a known verb or "OTHER", status is a class (2xx).
+var (
+	httpRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "gateway_http_requests_total",
+		Help: "HTTP requests handled, by route template, method and status class.",
+	}, []string{"route", "method", "status"})
+	httpDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "gateway_http_request_duration_seconds",
+		Help:    "HTTP request latency, by route template, method and status class.",
+		Buckets: []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10},
+	}, []string{"route", "method", "status"})
+	httpInFlight = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "gateway_http_in_flight_requests",
+		Help: "HTTP requests currently being served.",
+	})
+)
+
+// Metrics records every request. Place it before anything that may abort,
+// so rejections (401, 429, 503) are counted too. A panicking handler is
+// recorded as 5xx before the panic continues to gin.Recovery.
+func Metrics() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		httpInFlight.Inc()
+		defer func() {
+			httpInFlight.Dec()
+			status := statusClass(c.Writer.Status())
+			p := recover()
+			if p != nil {
+				status = "5xx"
+			}
+			route := c.FullPath()
+			if route == "" {
+				route = "unmatched" // 404s and probes for random paths share one series
+			}
+			labels := prometheus.Labels{"route": route, "method": methodLabel(c.Request.Method), "status": status}
+			httpRequests.With(labels).Inc()
+			httpDuration.With(labels).Observe(time.Since(start).Seconds())
+			if p != nil {
+				panic(p)
+			}
+		}()
+		c.Next()
+	}
+}
+
+// statusClass maps 404 to "4xx".
+func statusClass(code int) string {
+	if code < 100 || code > 599 {
+		return "other"
+	}
+	return strconv.Itoa(code/100) + "xx"
+}
+
+// methodLabel keeps standard verbs; anything else becomes "OTHER".
+func methodLabel(m string) string {
+	switch m {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+		http.MethodDelete, http.MethodOptions, http.MethodConnect, http.MethodTrace:
+		return m
+	}
+	return "OTHER"
+}
@@ -5,20 +5,54 @@ package middleware // Rate limit middleware wiring
 import (
 	"net"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"example.com/api-gateway/internal/auth"
 	"example.com/api-gateway/internal/rate"
 )
 
+// RateLimitIP is the stage that runs before auth: deny-listed IPs get 403
+// and requests without a valid bearer token are limited per client IP, so
+// missing or guessed tokens are throttled before auth rejects them with
+// 401. Requests whose token verifies against keys are left to RateLimit,
+// so each request costs one token and users behind one NAT keep separate
+// buckets. Allow-listed IPs skip the limiter.
+func RateLimitIP(limiter rate.Limiter, lists *rate.AccessList, rpm int, keys *auth.Keys) gin.HandlerFunc {
+	return rateLimit(limiter, lists, rpm, keys, false)
+}
+
 // RateLimit creates a middleware using provided Limiter.
-// Key strategy: if auth.sub exists -> per-user; else per-IP.
-func RateLimit(limiter rate.Limiter, rpm int) gin.HandlerFunc {
+// Key strategy: per-user (auth.sub); requests without one pass through.
+// It runs after auth (behind RateLimitIP): the AccessList is checked
+// again with the user ID, deny-listed users get 403 and allow-listed ones
+// skip the limiter. Decisions are counted per policy
+// (gateway_ratelimit_decisions_total).
+func RateLimit(limiter rate.Limiter, lists *rate.AccessList, rpm int) gin.HandlerFunc {
+	return rateLimit(limiter, lists, rpm, nil, true)
+}
+
+func rateLimit(limiter rate.Limiter, lists *rate.AccessList, rpm int, keys *auth.Keys, bySubject bool) gin.HandlerFunc {
+	policy := rate.Policy(limiter)
 	return func(c *gin.Context) {
-		if limiter == nil { c.Next(); return }
-		key := clientIP(c)
-		if sub, ok := c.Get("auth.sub"); ok { key = sub.(string) }
+		ip := clientIP(c)
+		sub := ""
+		if bySubject { sub = c.GetString("auth.sub") }
+		allowListed, denied := lists.Check(ip, sub)
+		if denied {
+			rate.CountDecision(rate.PolicyAccessList, false)
+			c.AbortWithStatusJSON(403, gin.H{"error": "access denied", "code": "forbidden"})
+			return
+		}
+		if allowListed { rate.CountDecision(rate.PolicyAccessList, true) }
+		if limiter == nil || allowListed { c.Next(); return }
+		if bySubject && sub == "" { c.Next(); return } // anonymous: RateLimitIP counted it
+		if !bySubject && validBearer(c, keys) { c.Next(); return } // RateLimit counts it per user
+		key := ip
+		if sub != "" { key = sub }
 		allowed, retry := limiter.Allow(key)
+		rate.CountDecision(policy, allowed)
 		remaining := "unknown" // memory limiter doesn't track, but we expose standard headers
 		c.Writer.Header().Set("X-RateLimit-Limit", strconv.Itoa(rpm))
 		c.Writer.Header().Set("X-RateLimit-Remaining", remaining)
@@ -31,6 +65,16 @@ func RateLimit(limiter rate.Limiter, rpm int) gin.HandlerFunc {
 	}
 }
 
+// validBearer reports whether the request carries a token that verifies
+// against keys. A nil key set treats every request as anonymous.
+func validBearer(c *gin.Context, keys *auth.Keys) bool {
+	if keys == nil { return false }
+	h := c.GetHeader("Authorization")
+	if !strings.HasPrefix(h, "Bearer ") { return false }
+	_, err := keys.Parse(strings.TrimPrefix(h, "Bearer "))
+	return err == nil
+}
+
 // clientIP extracts best-effort client IP.
 func clientIP(c *gin.Context) string {
 	ip := c.ClientIP()
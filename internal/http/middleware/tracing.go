@@ -0,0 +1,57 @@
+// Server span per request, continuing the caller's W3C trace context.
+package middleware // OpenTelemetry server spans
+
+import (
+	"strconv"
+
+	"example.com/api-gateway/internal/tracing"
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var serverTracer = tracing.Tracer("http.server")
+
+// Tracing starts a server span named "<METHOD> <route template>" as a child
+// of the incoming traceparent (if any) and puts it on the request context,
+// so handlers, services, repositories and Redis calls become its children.
+// It also stores the trace ID as "trace.id" for logs and error bodies.
+func Tracing() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := otel.GetTextMapPropagator().Extract(c.Request.Context(), propagation.HeaderCarrier(c.Request.Header))
+		route := c.FullPath()
+		name := c.Request.Method + " " + route
+		if route == "" {
+			name = c.Request.Method // unmatched: keep span names bounded
+		}
+		ctx, span := serverTracer.Start(ctx, name,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				attribute.String("http.request.method", c.Request.Method),
+				attribute.String("http.route", route),
+				attribute.String("url.path", c.Request.URL.Path),
+				attribute.String("client.address", c.ClientIP()),
+				attribute.String("request.id", c.GetString("req.id")),
+			))
+		defer span.End()
+
+		c.Request = c.Request.WithContext(ctx)
+		if id := tracing.TraceID(ctx); id != "" {
+			c.Set("trace.id", id)
+		}
+
+		c.Next()
+
+		status := c.Writer.Status()
+		span.SetAttributes(attribute.Int("http.response.status_code", status))
+		if sub := c.GetString("auth.sub"); sub != "" {
+			span.SetAttributes(attribute.String("enduser.id", sub))
+		}
+		if status >= 500 {
+			span.SetStatus(codes.Error, "status "+strconv.Itoa(status))
+		}
+	}
+}
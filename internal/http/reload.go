@@ -0,0 +1,132 @@
+// internal/http/reload.go
+package httpx // Reload stages that rebuild and swap the running engines
+
+import (
+	"net/http"
+	"sync"
+
+	"example.com/api-gateway/config"
+	"example.com/api-gateway/internal/logger"
+	"example.com/api-gateway/internal/rate"
+	"example.com/api-gateway/internal/reload"
+)
+
+// routerPaths are the fields the "router" stage applies by rebuilding the
+// engines: CORS and rate-limit policies.
+var routerPaths = []string{"server.cors", "rate_limit.enabled", "rate_limit.strategy", "rate_limit.failure_mode",
+	"rate_limit.requests_per_minute", "rate_limit.burst", "rate_limit.shards", "rate_limit.max_keys",
+	"rate_limit.idle_ttl_ms", "rate_limit.janitor_interval_ms", "rate_limit.sync_interval_ms",
+	"rate_limit.max_local_pending", "rate_limit.concurrency"}
+
+// Live is what serves traffic: the public (and optional admin) engines and
+// the Deps they were built from. Its reload stages rebuild the engines from
+// a new config and swap them in.
+type Live struct {
+	public, admin  *Swappable // admin nil without server.admin.address
+	newLimiter     func(config.RateLimit) rate.Limiter
+	newConcurrency func(config.Concurrency) rate.ConcurrencyLimiter
+
+	mu   sync.Mutex
+	deps Deps // current; replaced by each router commit
+}
+
+// NewLive tracks the engines public and admin (nil = no admin listener)
+// built from d; newLimiter and newConcurrency build the limiters of a new
+// rate_limit policy.
+func NewLive(d Deps, public, admin *Swappable, newLimiter func(config.RateLimit) rate.Limiter,
+	newConcurrency func(config.Concurrency) rate.ConcurrencyLimiter) *Live {
+	return &Live{public: public, admin: admin, newLimiter: newLimiter, newConcurrency: newConcurrency, deps: d}
+}
+
+// Deps returns the dependencies of the engines now serving.
+func (l *Live) Deps() Deps {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.deps
+}
+
+// Stop stops the current limiter's background goroutines (at exit, after
+// the reloader has stopped).
+func (l *Live) Stop() { stopLimiter(l.Deps().Limiter) }
+
+// Stages returns the reload stages: "log levels" (with Deps.LogLevels),
+// "jwt keys" (with Deps.Keys) and "router". Anything else that changes in
+// the file is reported at /api/config/status as requiring a restart.
+func (l *Live) Stages() []reload.Stage {
+	d := l.Deps()
+	var stages []reload.Stage
+	if d.LogLevels != nil {
+		stages = append(stages, reload.Stage{
+			Name:  "log levels",
+			Paths: []string{"logging.level", "logging.levels"},
+			Prepare: func(_, next config.Root) (func(), error) {
+				if _, err := logger.NewLevels(next.Logging.Level, next.Logging.Levels); err != nil {
+					return nil, err
+				}
+				return func() { _ = d.LogLevels.Apply(next.Logging.Level, next.Logging.Levels) }, nil
+			},
+		})
+	}
+	if d.Keys != nil {
+		stages = append(stages, reload.Stage{
+			Name:  "jwt keys",
+			Paths: []string{"security.jwt"},
+			Prepare: func(_, next config.Root) (func(), error) {
+				return func() {
+					if d.Keys.Rotate(next.Security.JWT) {
+						d.Log.Info("jwt secret rotated; tokens signed with the old one stay valid until they expire")
+					}
+				}, nil
+			},
+		})
+	}
+	return append(stages, reload.Stage{Name: "router", Paths: routerPaths, Prepare: l.prepareRouter})
+}
+
+// prepareRouter rebuilds the engines from next; new limiters only when
+// their policy changed (fresh limiters start with empty state). Nothing is
+// swapped until commit, and a failure stops what it built.
+func (l *Live) prepareRouter(prev, next config.Root) (func(), error) {
+	cur := l.Deps()
+	nd := cur
+	nd.Config = next
+	pr, nr := prev.RateLimit, next.RateLimit
+	pr.Concurrency, nr.Concurrency = config.Concurrency{}, config.Concurrency{}
+	pr.AccessListRefreshMS, nr.AccessListRefreshMS = 0, 0 // not reloaded; the access list keeps its interval
+	if pr != nr {
+		nd.Limiter = l.newLimiter(next.RateLimit)
+	}
+	if prev.RateLimit.Concurrency != next.RateLimit.Concurrency {
+		nd.Concurrency = l.newConcurrency(next.RateLimit.Concurrency)
+	}
+	engine := NewRouter(nd)
+	var adminEngine http.Handler
+	if l.admin != nil {
+		var err error
+		if adminEngine, err = NewAdminRouter(nd); err != nil {
+			if nd.Limiter != cur.Limiter {
+				stopLimiter(nd.Limiter)
+			}
+			return nil, err
+		}
+	}
+	return func() {
+		l.public.Swap(engine)
+		if l.admin != nil {
+			l.admin.Swap(adminEngine)
+		}
+		if nd.Limiter != cur.Limiter {
+			stopLimiter(cur.Limiter) // requests still in the old engine keep working: Allow does not need the janitor
+		}
+		l.mu.Lock()
+		l.deps = nd
+		l.mu.Unlock()
+	}, nil
+}
+
+// stopLimiter ends a limiter's background goroutines, if it has any.
+func stopLimiter(l rate.Limiter) {
+	if s, ok := l.(rate.Stopper); ok {
+		s.Stop()
+	}
+}
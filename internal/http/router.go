@@ -116,7 +116,7 @@
 package httpx // Router wiring (Gin)
 
 import (
-	"net/http"
+	"net/http/pprof"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -124,58 +124,88 @@ import (
 	"go.uber.org/zap"
 
 	"example.com/api-gateway/config"
+	"example.com/api-gateway/internal/auth"
+	"example.com/api-gateway/internal/capture"
 	"example.com/api-gateway/internal/handlers"
+	"example.com/api-gateway/internal/health"
 	"example.com/api-gateway/internal/http/middleware"
+	"example.com/api-gateway/internal/logger"
 	"example.com/api-gateway/internal/rate"
+	"example.com/api-gateway/internal/redact"
 	rlog "example.com/api-gateway/internal/redis"
+	"example.com/api-gateway/internal/reload"
 	"example.com/api-gateway/internal/service"
+	"example.com/api-gateway/internal/tracing"
 )
 
+// Deps bundles everything NewRouter wires into routes and middleware.
+// Optional pieces (Limiter, Concurrency, RedisAsync) may be nil.
+type Deps struct {
+	Config      config.Root
+	Log         *zap.Logger
+	AuthSvc     *service.AuthService
+	UserSvc     *service.UserService
+	Limiter     rate.Limiter            // token bucket (rate)
+	Concurrency rate.ConcurrencyLimiter // in-flight caps (load shedding)
+	AccessList  *rate.AccessList        // runtime allow/deny lists
+	RedisAsync  *rlog.AsyncLogger       // async Redis access log
+	LogStore    *rlog.LogStore          // Redis log stream for admin reads
+	LogTail     *rlog.Tailer            // live fan-out for /api/logs/stream
+	LogLevels   *logger.Levels          // runtime log levels (PUT /api/log-level)
+	Redactor    *redact.Redactor        // log redaction rules (nil = off)
+	Capture     *capture.Rules          // admin-toggled body capture (nil = off)
+	LogArchive  *rlog.Archiver          // archived log days (nil = archival off)
+	Health      *health.Readiness       // readiness checks (nil = always ready)
+	Keys        *auth.Keys              // JWT verification keys (nil = Config.Security.JWT)
+	Reloader    *reload.Reloader        // config hot reload (nil = off)
+}
+
 // NewRouter builds the full HTTP router with routes and middleware.
-// It now accepts an Async Redis Logger to persist enriched HTTP access logs.
-func NewRouter(
-	cfg config.Root,
-	log *zap.Logger,
-	authSvc *service.AuthService,
-	userSvc *service.UserService,
-	limiter rate.Limiter,
-	redisAsync *rlog.AsyncLogger,
-	rclient rlog.Client,
-) *gin.Engine {
+// It accepts an Async Redis Logger to persist enriched HTTP access logs.
+func NewRouter(d Deps) *gin.Engine {
+	cfg := d.Config
 	r := gin.New()
 	r.Use(gin.Recovery())
 	r.Use(middleware.RequestID())
-	r.Use(requestLogger(log, redisAsync)) // file/console + async Redis
-	// CORS (allow-all example; adapt for prod)
-	r.Use(func(c *gin.Context) {
-		c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Authorization, Content-Type, X-Request-Id")
-		c.Writer.Header().Set("Access-Control-Allow-Methods", "GET, POST, PATCH, DELETE, OPTIONS")
-		if c.Request.Method == http.MethodOptions {
-			c.AbortWithStatus(204)
-			return
-		}
-		c.Next()
-	})
-
-	// Health & metrics
-	r.GET("/health", func(c *gin.Context) { c.JSON(200, gin.H{"status": "ok"}) })
-	r.GET("/metrics", gin.WrapH(promhttp.Handler()))
+	r.Use(middleware.Metrics()) // before anything that aborts, so rejections are counted
+	r.Use(middleware.Tracing()) // server span; its context reaches services, repos and Redis
+	r.Use(requestLogger(d.Log.Named(logger.AccessLogger), d.RedisAsync, d.Redactor)) // file/console + async Redis
+	r.Use(middleware.Capture(d.Capture, d.RedisAsync, d.Redactor))                 // headers/bodies while a capture rule matches
+	r.Use(middleware.CORS(cfg.Server.CORS)) // preflights end here, before auth and rate limits
+
+	// Health & metrics. With a separate admin listener, metrics move there
+	// and the probes stay for load balancers and the kubelet.
+	separateAdmin := cfg.Server.Admin.Address != ""
+	probes(r, d, false)
+	if !separateAdmin {
+		r.GET("/metrics", gin.WrapH(promhttp.Handler()))
+	}
 
 	// Middlewares that depend on config
-	authRequired := middleware.Authenticated(cfg.Security.JWT)
-	rlmw := middleware.RateLimit(limiter, cfg.RateLimit.RequestsPerMinute)
+	keys := d.Keys
+	if keys == nil {
+		keys = auth.NewKeys(cfg.Security.JWT)
+	}
+	authRequired := middleware.AuthenticatedKeys(keys)
+	// Deny lists and the per-IP limit run before auth, so bad tokens are
+	// throttled too; requests with a valid token skip the IP limit and are
+	// counted once, by the per-user limit that runs after auth.
+	iplmw := middleware.RateLimitIP(d.Limiter, d.AccessList, cfg.RateLimit.RequestsPerMinute, keys)
+	rlmw := middleware.RateLimit(d.Limiter, d.AccessList, cfg.RateLimit.RequestsPerMinute)
+	ccmw := middleware.Concurrency(d.Concurrency) // after rlmw: rate rejections are cheaper
 
 	// Handlers
-	pair := handlersFrom(authSvc, userSvc)
-	logsHandler := handlers.NewLogsHandler(rclient)
+	pair := handlersFrom(d.AuthSvc, d.UserSvc)
 
 	// Auth routes
-	r.POST("/auth/login", rlmw, pair.Auth.Login)
+	// Login has no per-user stage, so a bearer token must not exempt it
+	// from the IP limit.
+	r.POST("/auth/login", middleware.RateLimitIP(d.Limiter, d.AccessList, cfg.RateLimit.RequestsPerMinute, nil), ccmw, pair.Auth.Login)
 
-	// Protected routes
+	// Protected routes: IP deny list and limit, then auth, then the per-user
+	// limit and access lists keyed by auth.sub.
 	grp := r.Group("/")
-	grp.Use(rlmw, authRequired)
+	grp.Use(iplmw, authRequired, rlmw, ccmw)
 
 	// Users
 	grp.GET("/users", middleware.RequireAdmin(), pair.Users.List)
@@ -186,12 +216,101 @@ func NewRouter(
 	grp.GET("/users/me", pair.Users.Me)
 	grp.PATCH("/users/me", pair.Users.PatchMe)
 
-	// Admin logs endpoint
-	grp.GET("/api/logs", middleware.RequireAdmin(), logsHandler.ListRecent)
+	// Admin APIs: here behind a JWT with role admin, unless they live on
+	// the admin listener. The live tail is not behind ccmw: a stream stays
+	// open for minutes and would pin an in-flight slot and skew the
+	// adaptive limit.
+	if !separateAdmin {
+		adminAPI(grp.Group("/", middleware.RequireAdmin()),
+			r.Group("/", iplmw, authRequired, rlmw, middleware.RequireAdmin()), d)
+	}
 
 	return r
 }
 
+// NewAdminRouter builds the engine of the separate admin listener
+// (server.admin): metrics, health, optional pprof and the admin APIs, all
+// behind AdminAuth. It fails when no auth method is configured.
+func NewAdminRouter(d Deps) (*gin.Engine, error) {
+	cfg := d.Config.Server.Admin
+	guard, err := middleware.AdminAuth(cfg.Auth)
+	if err != nil {
+		return nil, err
+	}
+	r := gin.New()
+	r.Use(gin.Recovery())
+	r.Use(middleware.RequestID())
+	// Audit trail of admin calls. Named like the public access log so the
+	// Redis core skips it: requestLogger enqueues the Redis copy itself.
+	r.Use(requestLogger(d.Log.Named(logger.AccessLogger).With(zap.String("listener", "admin")), d.RedisAsync, d.Redactor))
+	r.Use(guard)
+
+	probes(r, d, true)
+	r.GET("/metrics", gin.WrapH(promhttp.Handler()))
+	if cfg.Pprof {
+		dbg := r.Group("/debug/pprof")
+		dbg.GET("/", gin.WrapF(pprof.Index))
+		dbg.GET("/cmdline", gin.WrapF(pprof.Cmdline))
+		dbg.GET("/profile", gin.WrapF(pprof.Profile))
+		dbg.GET("/symbol", gin.WrapF(pprof.Symbol))
+		dbg.POST("/symbol", gin.WrapF(pprof.Symbol))
+		dbg.GET("/trace", gin.WrapF(pprof.Trace))
+		dbg.GET("/:name", gin.WrapF(pprof.Index)) // heap, goroutine, allocs, block, mutex, threadcreate
+	}
+	api := r.Group("/")
+	adminAPI(api, api, d)
+	return r, nil
+}
+
+// probes registers /health (plain ok, kept for existing checks) and the
+// liveness/readiness probes; detail adds check errors and latencies.
+func probes(r *gin.Engine, d Deps, detail bool) {
+	h := handlers.NewHealthHandler(d.Health, detail)
+	r.GET("/health", h.Live)
+	r.GET("/health/live", h.Live)
+	r.GET("/health/ready", h.Ready)
+}
+
+// adminAPI registers the admin endpoints on api (stream: the live tail,
+// which may need a different middleware chain).
+func adminAPI(api, stream *gin.RouterGroup, d Deps) {
+	logsHandler := handlers.NewLogsHandler(d.LogStore)
+	tailHandler := handlers.NewLogStreamHandler(d.LogTail)
+	levelHandler := handlers.NewLogLevelHandler(d.LogLevels)
+	captureHandler := handlers.NewCaptureHandler(d.Capture)
+	archiveHandler := handlers.NewLogArchiveHandler(d.LogArchive)
+	rlHandler := handlers.NewRateLimitHandler(d.Limiter, d.AccessList)
+	configHandler := handlers.NewConfigHandler(d.Reloader)
+
+	// Admin logs endpoint
+	api.GET("/api/logs", logsHandler.ListRecent)
+	api.GET("/api/logs/:requestId", logsHandler.ByRequest)
+	api.GET("/api/logs/archive", archiveHandler.List)
+	api.GET("/api/logs/archive/:day", archiveHandler.Download)
+
+	// Live tail (SSE or WebSocket)
+	stream.GET("/api/logs/stream", tailHandler.Stream)
+
+	// Admin log level endpoints
+	api.GET("/api/log-level", levelHandler.Get)
+	api.PUT("/api/log-level", levelHandler.Set)
+
+	// Admin debug capture endpoints
+	api.GET("/api/capture", captureHandler.List)
+	api.POST("/api/capture", captureHandler.Create)
+	api.DELETE("/api/capture/:id", captureHandler.Delete)
+
+	// Admin rate limit endpoints
+	api.GET("/api/ratelimits/:key", rlHandler.Get)
+	api.DELETE("/api/ratelimits/:key", rlHandler.Reset)
+	api.GET("/api/ratelimit-lists", rlHandler.Lists)
+	api.POST("/api/ratelimit-lists/:list", rlHandler.AddEntry)
+	api.DELETE("/api/ratelimit-lists/:list", rlHandler.RemoveEntry)
+
+	// Admin config reload status
+	api.GET("/api/config/status", configHandler.Status)
+}
+
 // pair holds both handlers for convenience.
 type pair struct{ Auth *handlers.AuthHandler; Users *handlers.UserHandler }
 
@@ -202,7 +321,7 @@ func handlersFrom(a *service.AuthService, u *service.UserService) pair {
 
 // requestLogger writes a structured access log (zap) and also enqueues a Redis LogEntry.
 // It runs AFTER the handler (c.Next) to capture status + duration.
-func requestLogger(log *zap.Logger, async *rlog.AsyncLogger) gin.HandlerFunc {
+func requestLogger(log *zap.Logger, async *rlog.AsyncLogger, red *redact.Redactor) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		start := time.Now()
 		c.Next()
@@ -213,8 +332,8 @@ func requestLogger(log *zap.Logger, async *rlog.AsyncLogger) gin.HandlerFunc {
 		uid, _ := c.Get("auth.sub")
 		role, _ := c.Get("auth.role")
 
-		// 1) Primary log to file/console
-		log.Info("http",
+		// 1) Primary log to file/console (trace_id/span_id when traced)
+		tracing.Logger(c.Request.Context(), log).Info("http",
 			zap.String("id", reqID),
 			zap.String("method", c.Request.Method),
 			zap.String("path", c.Request.URL.Path),
@@ -225,13 +344,13 @@ func requestLogger(log *zap.Logger, async *rlog.AsyncLogger) gin.HandlerFunc {
 			zap.Stringer("role", toStringer(role)),
 		)
 
-		// 2) Async to Redis
+		// 2) Async to Redis (bypasses zap, so redaction is applied here)
 		if async != nil {
 			async.Enqueue(rlog.LogEntry{
 				Timestamp: time.Now().UTC().Format(time.RFC3339),
 				Level:     "INFO",
-				Message:   "http",
-				Context: map[string]any{
+				Message:   rlog.AccessMessage, // own retention class (access_days)
+				Context: red.Map(map[string]any{
 					"requestId": reqID,
 					"method":    c.Request.Method,
 					"path":      c.Request.URL.Path,
@@ -240,7 +359,8 @@ func requestLogger(log *zap.Logger, async *rlog.AsyncLogger) gin.HandlerFunc {
 					"ip":        ip,
 					"userID":    uid,
 					"role":      role,
-				},
+					"traceId":   c.GetString("trace.id"),
+				}),
 			})
 		}
 	}
@@ -0,0 +1,29 @@
+// internal/http/swap.go
+package httpx // Atomically replaceable handler for config reloads
+
+import (
+	"net/http"
+	"sync/atomic"
+)
+
+// Swappable serves through the current handler. A config reload swaps in
+// an engine rebuilt from the new config; requests already inside the old
+// engine finish there.
+type Swappable struct {
+	cur atomic.Pointer[http.Handler]
+}
+
+// NewSwappable starts with h.
+func NewSwappable(h http.Handler) *Swappable {
+	s := &Swappable{}
+	s.Swap(h)
+	return s
+}
+
+// Swap makes h the handler for new requests.
+func (s *Swappable) Swap(h http.Handler) { s.cur.Store(&h) }
+
+// ServeHTTP implements http.Handler.
+func (s *Swappable) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	(*s.cur.Load()).ServeHTTP(w, r)
+}
@@ -0,0 +1,164 @@
+// Runtime-adjustable log levels with per-logger overrides.
+
+package logger // zap.AtomicLevel + name-based overrides
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Levels holds the base level (a zap.AtomicLevel) and overrides keyed by
+// logger name. An override for "rate" also applies to "rate.memory"; the
+// longest matching name wins. Changes take effect immediately.
+type Levels struct {
+	base zap.AtomicLevel
+
+	mu        sync.RWMutex
+	overrides map[string]zapcore.Level
+	floor     zapcore.Level // lowest of base and overrides, for fast Enabled
+}
+
+// NewLevels builds a controller from config values (level names as in
+// logging.level). Unknown names are rejected.
+func NewLevels(base string, overrides map[string]string) (*Levels, error) {
+	lvl, err := ParseLevel(base)
+	if err != nil {
+		return nil, err
+	}
+	l := &Levels{base: zap.NewAtomicLevelAt(lvl), overrides: map[string]zapcore.Level{}}
+	for name, s := range overrides {
+		ov, err := ParseLevel(s)
+		if err != nil {
+			return nil, fmt.Errorf("logging.levels.%s: %w", name, err)
+		}
+		l.overrides[name] = ov
+	}
+	l.recompute()
+	return l, nil
+}
+
+// ParseLevel accepts debug|info|warn|error (case-insensitive; "" = info).
+func ParseLevel(s string) (zapcore.Level, error) {
+	switch strings.ToLower(s) {
+	case "debug":
+		return zapcore.DebugLevel, nil
+	case "", "info":
+		return zapcore.InfoLevel, nil
+	case "warn":
+		return zapcore.WarnLevel, nil
+	case "error":
+		return zapcore.ErrorLevel, nil
+	default:
+		return zapcore.InfoLevel, fmt.Errorf("unknown log level %q (want debug|info|warn|error)", s)
+	}
+}
+
+// Base returns the AtomicLevel used for loggers without an override.
+func (l *Levels) Base() zap.AtomicLevel { return l.base }
+
+// SetBase changes the base level.
+func (l *Levels) SetBase(lvl zapcore.Level) {
+	l.base.SetLevel(lvl)
+	l.mu.Lock()
+	l.recompute()
+	l.mu.Unlock()
+}
+
+// SetOverride sets the level for logger name and its children.
+func (l *Levels) SetOverride(name string, lvl zapcore.Level) {
+	l.mu.Lock()
+	l.overrides[name] = lvl
+	l.recompute()
+	l.mu.Unlock()
+}
+
+// ClearOverride removes the override for name.
+func (l *Levels) ClearOverride(name string) {
+	l.mu.Lock()
+	delete(l.overrides, name)
+	l.recompute()
+	l.mu.Unlock()
+}
+
+// Apply replaces the base level and every override with config values
+// (config reload). Nothing changes when a level name is unknown.
+func (l *Levels) Apply(base string, overrides map[string]string) error {
+	next, err := NewLevels(base, overrides)
+	if err != nil {
+		return err
+	}
+	l.mu.Lock()
+	l.base.SetLevel(next.base.Level())
+	l.overrides = next.overrides
+	l.recompute()
+	l.mu.Unlock()
+	return nil
+}
+
+// Snapshot returns the base level and overrides as names.
+func (l *Levels) Snapshot() (string, map[string]string) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	out := make(map[string]string, len(l.overrides))
+	for name, lvl := range l.overrides {
+		out[name] = lvl.String()
+	}
+	return l.base.Level().String(), out
+}
+
+// Enabled reports whether lvl passes for any logger (used by zap as a fast
+// pre-check before the per-name decision in For).
+func (l *Levels) Enabled(lvl zapcore.Level) bool {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return lvl >= l.floor
+}
+
+// For returns the effective level for logger name.
+func (l *Levels) For(name string) zapcore.Level {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	best, bestLen := l.base.Level(), -1
+	for key, lvl := range l.overrides {
+		if (name == key || strings.HasPrefix(name, key+".")) && len(key) > bestLen {
+			best, bestLen = lvl, len(key)
+		}
+	}
+	return best
+}
+
+// recompute refreshes floor; callers hold mu.
+func (l *Levels) recompute() {
+	l.floor = l.base.Level()
+	for _, lvl := range l.overrides {
+		if lvl < l.floor {
+			l.floor = lvl
+		}
+	}
+}
+
+// levelCore gates a core by the effective level of each entry's logger name.
+type levelCore struct {
+	zapcore.Core
+	levels *Levels
+}
+
+// Enabled is the loosest level across base and overrides.
+func (c levelCore) Enabled(lvl zapcore.Level) bool { return c.levels.Enabled(lvl) }
+
+// With keeps the gate on child cores.
+func (c levelCore) With(fields []zapcore.Field) zapcore.Core {
+	return levelCore{Core: c.Core.With(fields), levels: c.levels}
+}
+
+// Check applies the per-name level, then the inner cores' own thresholds.
+func (c levelCore) Check(e zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if e.Level < c.levels.For(e.LoggerName) {
+		return ce
+	}
+	return c.Core.Check(e, ce)
+}
@@ -3,8 +3,7 @@
 package logger // Zap logger initialization  + console/file + Redis hook
 
 import (
-	// "strings" 
-		"os"
+	"os"
 	"path/filepath"
 	"time"    // Normalize level
 	"go.uber.org/zap" // Zap logging
@@ -12,32 +11,47 @@ import (
 	"example.com/api-gateway/config" // Logging config type
 "gopkg.in/natefinch/lumberjack.v2"
 rlog "example.com/api-gateway/internal/redis"
+"example.com/api-gateway/internal/redact"
 )
 
 
-// ensureLogsDir makes sure the /logs directory exists (project root).
-func ensureLogsDir() (string, error) {
-	dir := filepath.Clean("./logs")
-	if err := os.MkdirAll(dir, 0o755); err != nil {
-		return "", err
+// Controls exposes the runtime knobs of a logger built by New.
+type Controls struct {
+	Levels   *Levels           // base level + per-logger overrides (PUT /api/log-level)
+	Redactor *redact.Redactor  // rules applied to every output (nil = disabled)
+	sinks    []*SinkCore
+	file     *lumberjack.Logger // nil when logging.file.path is "-"
+}
+
+// Close flushes and closes the sinks and the log file; call it after the
+// last log line.
+func (c *Controls) Close() {
+	for _, sc := range c.sinks {
+		_ = sc.Close()
+	}
+	if c.file != nil {
+		_ = c.file.Close()
 	}
-	return dir, nil
 }
 
 // fileSyncer creates a rotating file sink using lumberjack.
-func fileSyncer() (zapcore.WriteSyncer, error) {
-	dir, err := ensureLogsDir()
-	if err != nil {
+// Zero rotation settings keep the previous defaults (25 MB, 7 backups, 30 days).
+func fileSyncer(cfg config.LogFile) (*lumberjack.Logger, error) {
+	path := cfg.Path
+	if path == "" {
+		path = "logs/app.log"
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
 		return nil, err
 	}
 	lj := &lumberjack.Logger{
-		Filename:   filepath.Join(dir, "app.log"),
-		MaxSize:    25,  // megabytes before rotation
-		MaxBackups: 7,   // keep 7 backup files
-		MaxAge:     30,  // days
-		Compress:   true,
+		Filename:   path,
+		MaxSize:    orDefault(cfg.MaxSizeMB, 25),  // megabytes before rotation
+		MaxBackups: orDefault(cfg.MaxBackups, 7),  // backup files kept
+		MaxAge:     orDefault(cfg.MaxAgeDays, 30), // days
+		Compress:   cfg.Compress,
 	}
-	return zapcore.AddSync(lj), nil
+	return lj, nil
 }
 
 
@@ -51,6 +65,15 @@ func consoleEncoder() zapcore.Encoder {
 	return zapcore.NewConsoleEncoder(cfg)
 }
 
+// plainEncoder is the text encoder without colors (for files).
+func plainEncoder() zapcore.Encoder {
+	cfg := zap.NewDevelopmentEncoderConfig()
+	cfg.EncodeTime = func(t time.Time, enc zapcore.PrimitiveArrayEncoder) {
+		enc.AppendString(t.Format(time.RFC3339))
+	}
+	return zapcore.NewConsoleEncoder(cfg)
+}
+
 // jsonEncoder returns a structured JSON encoder (good for log aggregation).
 func jsonEncoder() zapcore.Encoder {
 	cfg := zap.NewProductionEncoderConfig()
@@ -60,59 +83,82 @@ func jsonEncoder() zapcore.Encoder {
 	return zapcore.NewJSONEncoder(cfg)
 }
 
-// levelFromConfig parses the configured logging level.
-func levelFromConfig(cfg config.Logging) zapcore.Level {
-	switch cfg.Level {
-	case "debug":
-		return zapcore.DebugLevel
-	case "info":
-		return zapcore.InfoLevel
-	case "warn":
-		return zapcore.WarnLevel
-	case "error":
-		return zapcore.ErrorLevel
-	default:
-		return zapcore.InfoLevel
+// threshold parses an optional per-output level ("" = fallback).
+func threshold(s string, fallback zapcore.LevelEnabler) (zapcore.LevelEnabler, error) {
+	if s == "" {
+		return fallback, nil
 	}
+	return ParseLevel(s)
 }
 
 
 
 // New constructs a zap.Logger that writes to both console and a rotating file.
-// If a redis AsyncLogger is provided, we attach a zap hook so that every log
-// entry (after being written to console/file) is also enqueued for Redis saving.
-func New(cfg config.Logging, redisAsync *rlog.AsyncLogger) (*zap.Logger, error) {
-	level := levelFromConfig(cfg)
-
-	// Build console core
-	consoleCore := zapcore.NewCore(consoleEncoder(), zapcore.AddSync(os.Stdout), level)
-
-	// Build file core
-	fileWS, err := fileSyncer()
+// If a redis AsyncLogger is provided, a third core encodes every entry with
+// all of its fields and enqueues it for Redis saving (see redisCore).
+// Each configured sink (logging.sinks) adds a batching core.
+//
+// Every entry first passes the runtime level (Controls.Levels: logging.level
+// plus logging.levels overrides per logger name); outputs with a level of
+// their own (redis_level, sink level) apply it on top. Redaction rules
+// (logging.redaction) run before any output encodes the entry.
+func New(cfg config.Logging, redisAsync *rlog.AsyncLogger) (*zap.Logger, *Controls, error) {
+	levels, err := NewLevels(cfg.Level, cfg.Levels)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
+	}
+	redactor, err := redact.New(cfg.Redaction)
+	if err != nil {
+		return nil, nil, err
+	}
+	all := zapcore.DebugLevel // console/file follow the runtime level alone
+	redisLevel, err := threshold(cfg.RedisLevel, all)
+	if err != nil {
+		return nil, nil, err
 	}
-	fileCore := zapcore.NewCore(jsonEncoder(), fileWS, level)
 
-	// Tee both
-	baseCore := zapcore.NewTee(consoleCore, fileCore)
+	// Build console core (logging.json selects JSON over colored text)
+	enc := consoleEncoder()
+	if cfg.JSON {
+		enc = jsonEncoder()
+	}
+	cores := []zapcore.Core{zapcore.NewCore(enc, zapcore.AddSync(os.Stdout), all)}
+
+	// Build file core ("-" disables it)
+	var file *lumberjack.Logger
+	if cfg.File.Path != "-" {
+		if file, err = fileSyncer(cfg.File); err != nil {
+			return nil, nil, err
+		}
+		fileEnc := jsonEncoder()
+		if cfg.File.Encoding == "console" {
+			fileEnc = plainEncoder()
+		}
+		cores = append(cores, zapcore.NewCore(fileEnc, zapcore.AddSync(file), all))
+	}
 
-	// Optionally attach a hook that forwards entries to Redis asynchronously.
+	// Optionally forward entries (fields included) to Redis asynchronously,
+	// with a threshold of its own (logging.redis_level).
 	if redisAsync != nil {
-		baseCore = zapcore.RegisterHooks(baseCore, func(entry zapcore.Entry) error {
-			le := rlog.LogEntry{
-				Timestamp: entry.Time.UTC().Format(time.RFC3339),
-				Level:     entry.Level.CapitalString(),
-				Message:   entry.Message,
-				Context:   map[string]any{}, // fields are added below via WithOptions hook
-			}
-			redisAsync.Enqueue(le)
-			return nil
-		})
+		cores = append(cores, newRedisCore(redisAsync, redisLevel))
 	}
 
+	// Extra sinks (syslog, http, per-category files); openSinks closes the
+	// ones it opened when a later one fails, the log file is ours to close.
+	sinks, err := openSinks(cfg.Sinks, all)
+	if err != nil {
+		if file != nil {
+			_ = file.Close()
+		}
+		return nil, nil, err
+	}
+	for _, sc := range sinks {
+		cores = append(cores, sc)
+	}
+
+	baseCore := levelCore{Core: withRedaction(zapcore.NewTee(cores...), redactor), levels: levels}
+
 	// Build logger with or without sampling depending on config.
-	// (We still honor cfg.JSON for console vs JSON output through encoder selection above.)
 	opts := []zap.Option{
 		zap.AddCaller(),
 		zap.AddStacktrace(zapcore.ErrorLevel),
@@ -124,5 +170,5 @@ func New(cfg config.Logging, redisAsync *rlog.AsyncLogger) (*zap.Logger, error)
 	}
 
 	logger := zap.New(baseCore, opts...)
-	return logger, nil
-}
\ No newline at end of file
+	return logger, &Controls{Levels: levels, Redactor: redactor, sinks: sinks, file: file}, nil
+}
@@ -0,0 +1,154 @@
+// Zap core wrapper applying redaction rules.
+
+package logger // Redacts messages and fields before an output core writes them
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+
+	"example.com/api-gateway/internal/redact"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// redactCore wraps the tee of every output, so each entry is redacted once
+// however many outputs write it. It rewrites the entry message and fields
+// on Write, then hands them to the outputs that accept the entry.
+//
+// Fields are redacted by key and value: strings, errors, Stringers, maps,
+// and zap.Object, zap.Inline and array fields (encoded to maps and slices
+// first). Structs passed to zap.Any are written as they are; log them as
+// maps or ObjectMarshalers when they may hold sensitive values.
+type redactCore struct {
+	zapcore.Core
+	r *redact.Redactor
+}
+
+// withRedaction wraps core (nil r leaves it untouched).
+func withRedaction(core zapcore.Core, r *redact.Redactor) zapcore.Core {
+	if r == nil {
+		return core
+	}
+	return redactCore{Core: core, r: r}
+}
+
+// With redacts fields as they are attached.
+func (c redactCore) With(fields []zapcore.Field) zapcore.Core {
+	return redactCore{Core: c.Core.With(c.fields(fields)), r: c.r}
+}
+
+// Check routes the write through this wrapper when any output is enabled
+// at the entry's level; the outputs' own filters apply in Write.
+func (c redactCore) Check(e zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(e.Level) {
+		return ce.AddCore(e, c)
+	}
+	return ce
+}
+
+// Write redacts, then writes to the outputs whose level and logger-name
+// filters accept the entry (a plain Write would reach every output).
+func (c redactCore) Write(e zapcore.Entry, fields []zapcore.Field) error {
+	inner := c.Core.Check(e, nil)
+	if inner == nil {
+		return nil
+	}
+	inner.Message = c.r.String(e.Message)
+	inner.ErrorOutput = writeErrors
+	inner.Write(c.fields(fields)...)
+	return nil
+}
+
+// writeErrors receives output failures, like zap.Logger's default.
+var writeErrors = zapcore.Lock(os.Stderr)
+
+// fields returns redacted copies; untouched fields are kept as-is.
+func (c redactCore) fields(in []zapcore.Field) []zapcore.Field {
+	out := make([]zapcore.Field, len(in))
+	for i, f := range in {
+		out[i] = c.field(f)
+	}
+	return out
+}
+
+func (c redactCore) field(f zapcore.Field) zapcore.Field {
+	if mode, ok := c.r.FieldMode(f.Key); ok {
+		return zap.String(f.Key, c.r.Apply(mode, fieldString(f)))
+	}
+	switch f.Type {
+	case zapcore.StringType:
+		f.String = c.r.String(f.String)
+	case zapcore.ErrorType, zapcore.StringerType:
+		if s := fieldString(f); s != "" {
+			if red := c.r.String(s); red != s {
+				return zap.String(f.Key, red)
+			}
+		}
+	case zapcore.ReflectType:
+		if m, ok := f.Interface.(map[string]any); ok {
+			return zap.Any(f.Key, c.r.Map(m))
+		}
+		if s, ok := f.Interface.(string); ok {
+			return zap.String(f.Key, c.r.String(s))
+		}
+	case zapcore.ObjectMarshalerType, zapcore.InlineMarshalerType:
+		enc := zapcore.NewMapObjectEncoder()
+		if err := f.Interface.(zapcore.ObjectMarshaler).MarshalLogObject(enc); err != nil {
+			return f // the encoder reports the error again
+		}
+		if f.Type == zapcore.InlineMarshalerType {
+			return zap.Inline(object(c.r.Map(enc.Fields)))
+		}
+		return zap.Object(f.Key, object(c.r.Map(enc.Fields)))
+	case zapcore.ArrayMarshalerType:
+		enc := zapcore.NewMapObjectEncoder()
+		if err := enc.AddArray(f.Key, f.Interface.(zapcore.ArrayMarshaler)); err != nil {
+			return f
+		}
+		return zap.Any(f.Key, c.r.Value(f.Key, enc.Fields[f.Key]))
+	}
+	return f
+}
+
+// object writes a redacted map back as an ObjectMarshaler, keys sorted.
+type object map[string]any
+
+func (o object) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	keys := make([]string, 0, len(o))
+	for k := range o {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if err := enc.AddReflected(k, o[k]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fieldString renders a field value for hashing or pattern matching.
+func fieldString(f zapcore.Field) string {
+	switch f.Type {
+	case zapcore.StringType:
+		return f.String
+	case zapcore.Int64Type, zapcore.Int32Type, zapcore.Int16Type, zapcore.Int8Type:
+		return strconv.FormatInt(f.Integer, 10)
+	case zapcore.Uint64Type, zapcore.Uint32Type, zapcore.Uint16Type, zapcore.Uint8Type:
+		return strconv.FormatUint(uint64(f.Integer), 10)
+	case zapcore.ErrorType:
+		if err, ok := f.Interface.(error); ok && err != nil {
+			return err.Error()
+		}
+	case zapcore.StringerType:
+		if s, ok := f.Interface.(fmt.Stringer); ok && s != nil {
+			return s.String()
+		}
+	}
+	if f.Interface != nil {
+		return fmt.Sprint(f.Interface)
+	}
+	return ""
+}
@@ -0,0 +1,80 @@
+// Zap core that forwards structured entries to Redis.
+
+package logger // zapcore.Core implementation backed by the async Redis logger
+
+import (
+	"time"
+
+	"go.uber.org/zap/zapcore"
+	rlog "example.com/api-gateway/internal/redis"
+)
+
+// AccessLogger is the logger name used for HTTP access logs. The router
+// enqueues those to Redis itself (with request metadata), so redisCore skips
+// them to avoid recording every request twice.
+const AccessLogger = "access"
+
+// redisCore encodes every field (including those added via With) into
+// rlog.LogEntry.Context and hands the entry to the AsyncLogger.
+type redisCore struct {
+	zapcore.LevelEnabler
+	async  *rlog.AsyncLogger
+	fields []zapcore.Field // accumulated With fields
+}
+
+// newRedisCore builds a core with its own level threshold.
+func newRedisCore(async *rlog.AsyncLogger, level zapcore.LevelEnabler) zapcore.Core {
+	return &redisCore{LevelEnabler: level, async: async}
+}
+
+// With returns a child core carrying extra fields.
+func (c *redisCore) With(fields []zapcore.Field) zapcore.Core {
+	clone := *c
+	clone.fields = append(c.fields[:len(c.fields):len(c.fields)], fields...)
+	return &clone
+}
+
+// Check adds this core when the level passes and the entry is not an access log.
+func (c *redisCore) Check(e zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(e.Level) && e.LoggerName != AccessLogger {
+		return ce.AddCore(e, c)
+	}
+	return ce
+}
+
+// Write converts the entry into a LogEntry and enqueues it (non-blocking).
+func (c *redisCore) Write(e zapcore.Entry, fields []zapcore.Field) error {
+	c.async.Enqueue(toLogEntry(e, c.fields, fields))
+	return nil
+}
+
+// toLogEntry encodes e with its accumulated and call-site fields.
+// Shared by redisCore and SinkCore.
+func toLogEntry(e zapcore.Entry, with, fields []zapcore.Field) rlog.LogEntry {
+	enc := zapcore.NewMapObjectEncoder()
+	for _, f := range with {
+		f.AddTo(enc)
+	}
+	for _, f := range fields {
+		f.AddTo(enc)
+	}
+	ctx := enc.Fields
+	if e.LoggerName != "" {
+		ctx["logger"] = e.LoggerName
+	}
+	if e.Caller.Defined {
+		ctx["caller"] = e.Caller.TrimmedPath()
+	}
+	if e.Stack != "" {
+		ctx["stack"] = e.Stack
+	}
+	return rlog.LogEntry{
+		Timestamp: e.Time.UTC().Format(time.RFC3339),
+		Level:     e.Level.CapitalString(),
+		Message:   e.Message,
+		Context:   ctx,
+	}
+}
+
+// Sync is a no-op; the AsyncLogger flushes on Stop.
+func (c *redisCore) Sync() error { return nil }
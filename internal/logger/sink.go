@@ -0,0 +1,261 @@
+// Pluggable log outputs with per-sink level, batching and retry.
+
+package logger // Sink interface + batching zap core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"example.com/api-gateway/config"
+	rlog "example.com/api-gateway/internal/redis"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.uber.org/zap/zapcore"
+)
+
+// Sink delivers batches of structured entries to one output. Send is only
+// called from a single goroutine per sink; a returned error makes SinkCore
+// retry the batch (unless it is Permanent).
+type Sink interface {
+	Send(ctx context.Context, batch []rlog.LogEntry) error
+	Close() error
+}
+
+// permanentError marks failures that retrying cannot fix (e.g. HTTP 400).
+type permanentError struct{ err error }
+
+func (e permanentError) Error() string { return e.err.Error() }
+func (e permanentError) Unwrap() error { return e.err }
+
+// Permanent wraps err so SinkCore drops the batch without retrying.
+func Permanent(err error) error { return permanentError{err} }
+
+// sinkEntries counts entries per sink and outcome (sent|dropped|failed).
+var sinkEntries = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "gateway_log_sink_entries_total",
+	Help: "Log entries handled by each sink, by outcome.",
+}, []string{"sink", "result"})
+
+// OpenSink builds the sink described by cfg.
+func OpenSink(cfg config.LogSink) (Sink, error) {
+	switch cfg.Type {
+	case "syslog":
+		return NewSyslogSink(cfg.Syslog)
+	case "http":
+		return NewHTTPSink(cfg.HTTP)
+	case "file":
+		return NewFileSink(cfg.File)
+	default:
+		return nil, fmt.Errorf("unknown log sink type %q (want syslog|http|file)", cfg.Type)
+	}
+}
+
+// openSinks builds a core per configured sink; fallback is the level used
+// when a sink has none.
+func openSinks(cfgs []config.LogSink, fallback zapcore.LevelEnabler) ([]*SinkCore, error) {
+	cores := make([]*SinkCore, 0, len(cfgs))
+	fail := func(sc config.LogSink, err error) ([]*SinkCore, error) {
+		for _, c := range cores {
+			_ = c.Close()
+		}
+		return nil, fmt.Errorf("log sink %q: %w", sinkName(sc), err)
+	}
+	for _, sc := range cfgs {
+		level, err := threshold(sc.Level, fallback)
+		if err != nil {
+			return fail(sc, err)
+		}
+		s, err := OpenSink(sc)
+		if err != nil {
+			return fail(sc, err)
+		}
+		cores = append(cores, NewSinkCore(s, sc, level))
+	}
+	return cores, nil
+}
+
+func sinkName(cfg config.LogSink) string {
+	if cfg.Name != "" {
+		return cfg.Name
+	}
+	return cfg.Type
+}
+
+// SinkCore is a zapcore.Core feeding a Sink through a bounded queue. A
+// background worker groups entries into batches (by size or interval) and
+// retries failed sends with exponential backoff. Logging never blocks: when
+// the queue is full the entry is dropped and counted.
+type SinkCore struct {
+	zapcore.LevelEnabler
+	fields []zapcore.Field // accumulated With fields
+	*sinkWorker
+}
+
+// sinkWorker is shared by a SinkCore and all its With children.
+type sinkWorker struct {
+	sink     Sink
+	name     string
+	ch       chan rlog.LogEntry
+	batch    int
+	interval time.Duration
+	retries  int
+	backoff  time.Duration
+
+	flushReq chan chan struct{}
+	done     chan struct{}
+
+	mu       sync.RWMutex // guards closed against Write racing Close
+	closed   bool
+	closeErr error
+}
+
+// NewSinkCore starts the worker for s; Close flushes and stops it.
+func NewSinkCore(s Sink, cfg config.LogSink, level zapcore.LevelEnabler) *SinkCore {
+	w := &sinkWorker{
+		sink:     s,
+		name:     sinkName(cfg),
+		ch:       make(chan rlog.LogEntry, orDefault(cfg.BufferSize, 1024)),
+		batch:    orDefault(cfg.BatchSize, 100),
+		interval: time.Duration(orDefault(cfg.FlushIntervalMS, 1000)) * time.Millisecond,
+		retries:  orDefault(cfg.MaxRetries, 3),
+		backoff:  time.Duration(orDefault(cfg.RetryBackoffMS, 200)) * time.Millisecond,
+		flushReq: make(chan chan struct{}),
+		done:     make(chan struct{}),
+	}
+	if cfg.MaxRetries < 0 {
+		w.retries = 0
+	}
+	go w.run()
+	return &SinkCore{LevelEnabler: level, sinkWorker: w}
+}
+
+func orDefault(v, def int) int {
+	if v <= 0 {
+		return def
+	}
+	return v
+}
+
+// With returns a child core carrying extra fields.
+func (c *SinkCore) With(fields []zapcore.Field) zapcore.Core {
+	clone := *c
+	clone.fields = append(c.fields[:len(c.fields):len(c.fields)], fields...)
+	return &clone
+}
+
+// Check adds this core when the level passes.
+func (c *SinkCore) Check(e zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(e.Level) {
+		return ce.AddCore(e, c)
+	}
+	return ce
+}
+
+// Write enqueues the entry (non-blocking).
+func (c *SinkCore) Write(e zapcore.Entry, fields []zapcore.Field) error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.closed {
+		return nil
+	}
+	select {
+	case c.ch <- toLogEntry(e, c.fields, fields):
+	default:
+		sinkEntries.WithLabelValues(c.name, "dropped").Inc()
+	}
+	return nil
+}
+
+// Sync sends everything queued so far and waits for it.
+func (c *SinkCore) Sync() error {
+	ack := make(chan struct{})
+	select {
+	case c.flushReq <- ack:
+		<-ack
+	case <-c.done:
+	}
+	return nil
+}
+
+// Close flushes the queue, stops the worker and closes the sink.
+func (w *sinkWorker) Close() error {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return w.closeErr
+	}
+	w.closed = true
+	close(w.ch)
+	w.mu.Unlock()
+	<-w.done
+	w.closeErr = w.sink.Close()
+	return w.closeErr
+}
+
+// run batches queued entries until the queue is closed.
+func (w *sinkWorker) run() {
+	defer close(w.done)
+	buf := make([]rlog.LogEntry, 0, w.batch)
+	t := time.NewTicker(w.interval)
+	defer t.Stop()
+	flush := func() {
+		if len(buf) > 0 {
+			w.send(buf)
+			buf = make([]rlog.LogEntry, 0, w.batch) // the sink may keep the slice
+		}
+	}
+	for {
+		select {
+		case e, ok := <-w.ch:
+			if !ok {
+				flush()
+				return
+			}
+			buf = append(buf, e)
+			if len(buf) >= w.batch {
+				flush()
+			}
+		case <-t.C:
+			flush()
+		case ack := <-w.flushReq:
+			for n := len(w.ch); n > 0; n-- { // take what is queued right now
+				buf = append(buf, <-w.ch)
+				if len(buf) >= w.batch {
+					flush()
+				}
+			}
+			flush()
+			close(ack)
+		}
+	}
+}
+
+// send delivers one batch, retrying with doubling backoff.
+func (w *sinkWorker) send(batch []rlog.LogEntry) {
+	delay := w.backoff
+	var err error
+	for attempt := 0; attempt <= w.retries; attempt++ {
+		if attempt > 0 {
+			time.Sleep(delay)
+			delay *= 2
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		err = w.sink.Send(ctx, batch)
+		cancel()
+		if err == nil {
+			sinkEntries.WithLabelValues(w.name, "sent").Add(float64(len(batch)))
+			return
+		}
+		var perm permanentError
+		if errors.As(err, &perm) {
+			break
+		}
+	}
+	sinkEntries.WithLabelValues(w.name, "failed").Add(float64(len(batch)))
+	// Can't log through zap here (this is the logger); stderr is the last resort.
+	fmt.Fprintf(os.Stderr, "log sink %s: dropped batch of %d: %v\n", w.name, len(batch), err)
+}
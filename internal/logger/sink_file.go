@@ -0,0 +1,87 @@
+// Rotating NDJSON files, one per category.
+
+package logger // Per-category file sink backed by lumberjack
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	"example.com/api-gateway/config"
+	rlog "example.com/api-gateway/internal/redis"
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+// fileSink appends entries to <dir>/<category>.ndjson, where the category is
+// the zap logger name ("access", "log-tail", ...) or "app" when unnamed.
+type fileSink struct {
+	cfg   config.FileSink
+	files map[string]*lumberjack.Logger
+}
+
+// NewFileSink creates the directory; files are opened on first use.
+func NewFileSink(cfg config.FileSink) (Sink, error) {
+	if cfg.Dir == "" {
+		cfg.Dir = "logs"
+	}
+	if err := os.MkdirAll(cfg.Dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &fileSink{cfg: cfg, files: make(map[string]*lumberjack.Logger)}, nil
+}
+
+// Send appends each entry as one JSON line to its category file.
+func (s *fileSink) Send(_ context.Context, batch []rlog.LogEntry) error {
+	for _, e := range batch {
+		b, err := json.Marshal(e)
+		if err != nil {
+			continue // unencodable field; skip rather than retry forever
+		}
+		if _, err := s.file(category(e)).Write(append(b, '\n')); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close closes every open file.
+func (s *fileSink) Close() error {
+	var first error
+	for _, f := range s.files {
+		if err := f.Close(); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+func (s *fileSink) file(cat string) *lumberjack.Logger {
+	f, ok := s.files[cat]
+	if !ok {
+		f = &lumberjack.Logger{
+			Filename:   filepath.Join(s.cfg.Dir, cat+".ndjson"),
+			MaxSize:    orDefault(s.cfg.MaxSizeMB, 25),
+			MaxBackups: orDefault(s.cfg.MaxBackups, 7),
+			MaxAge:     s.cfg.MaxAgeDays,
+			Compress:   s.cfg.Compress,
+		}
+		s.files[cat] = f
+	}
+	return f
+}
+
+// category derives a safe file name from the logger name.
+func category(e rlog.LogEntry) string {
+	name, _ := e.Context["logger"].(string)
+	if name == "" {
+		return "app"
+	}
+	out := []byte(name)
+	for i, c := range out {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '_') {
+			out[i] = '_' // also flattens "a.b" child loggers and path separators
+		}
+	}
+	return string(out)
+}
@@ -0,0 +1,90 @@
+// HTTP batch sink (Loki/Elasticsearch-style ingest endpoints).
+
+package logger // POST batches as NDJSON or a JSON array
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"example.com/api-gateway/config"
+	rlog "example.com/api-gateway/internal/redis"
+	"example.com/api-gateway/internal/tracing"
+)
+
+// httpSink POSTs each batch in one request. 5xx, 429 and transport errors
+// are retried by SinkCore; other non-2xx answers are permanent.
+type httpSink struct {
+	url     string
+	ndjson  bool
+	headers map[string]string
+	client  *http.Client
+}
+
+// NewHTTPSink validates cfg.
+func NewHTTPSink(cfg config.HTTPSink) (Sink, error) {
+	if cfg.URL == "" {
+		return nil, fmt.Errorf("http sink url is required")
+	}
+	if cfg.Format != "" && cfg.Format != "ndjson" && cfg.Format != "json" {
+		return nil, fmt.Errorf("http sink format %q (want ndjson|json)", cfg.Format)
+	}
+	timeout := time.Duration(orDefault(cfg.TimeoutMS, 5000)) * time.Millisecond
+	return &httpSink{
+		url:     cfg.URL,
+		ndjson:  cfg.Format != "json",
+		headers: cfg.Headers,
+		client:  &http.Client{Timeout: timeout, Transport: tracing.Transport(nil)},
+	}, nil
+}
+
+// Send encodes and posts the batch.
+func (s *httpSink) Send(ctx context.Context, batch []rlog.LogEntry) error {
+	var body bytes.Buffer
+	contentType := "application/json"
+	if s.ndjson {
+		contentType = "application/x-ndjson"
+		enc := json.NewEncoder(&body)
+		for _, e := range batch {
+			if err := enc.Encode(e); err != nil {
+				return Permanent(err)
+			}
+		}
+	} else if err := json.NewEncoder(&body).Encode(batch); err != nil {
+		return Permanent(err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, &body)
+	if err != nil {
+		return Permanent(err)
+	}
+	req.Header.Set("Content-Type", contentType)
+	for k, v := range s.headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10)) // allow connection reuse
+
+	switch {
+	case resp.StatusCode < 300:
+		return nil
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return fmt.Errorf("http sink: %s", resp.Status)
+	default:
+		return Permanent(fmt.Errorf("http sink: %s", resp.Status))
+	}
+}
+
+// Close releases idle connections.
+func (s *httpSink) Close() error {
+	s.client.CloseIdleConnections()
+	return nil
+}
@@ -0,0 +1,178 @@
+// Syslog sink (RFC 5424 over udp/tcp/unix).
+
+package logger // RFC 5424 formatting + connection handling
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"example.com/api-gateway/config"
+	rlog "example.com/api-gateway/internal/redis"
+)
+
+// sdID is the structured-data ID carrying entry context
+// (32473 is the private enterprise number reserved for examples, RFC 5612).
+const sdID = "ctx@32473"
+
+// syslogSink writes one RFC 5424 message per entry. Stream transports
+// (tcp, unix) use octet-counting framing (RFC 6587); datagram transports
+// send one message per packet. The connection is re-dialed after an error.
+type syslogSink struct {
+	network  string
+	address  string
+	facility int
+	app      string
+	host     string
+	pid      string
+	conn     net.Conn
+}
+
+// NewSyslogSink validates cfg; the connection is dialed on first send.
+func NewSyslogSink(cfg config.SyslogSink) (Sink, error) {
+	switch cfg.Network {
+	case "udp", "tcp", "unix", "unixgram":
+	default:
+		return nil, fmt.Errorf("syslog network %q (want udp|tcp|unix|unixgram)", cfg.Network)
+	}
+	if cfg.Address == "" {
+		return nil, fmt.Errorf("syslog address is required")
+	}
+	if cfg.Facility < 0 || cfg.Facility > 23 {
+		return nil, fmt.Errorf("syslog facility %d out of range 0-23", cfg.Facility)
+	}
+	s := &syslogSink{
+		network:  cfg.Network,
+		address:  cfg.Address,
+		facility: cfg.Facility,
+		app:      cfg.AppName,
+		pid:      strconv.Itoa(os.Getpid()),
+	}
+	if s.facility == 0 {
+		s.facility = 16 // local0; kern (0) is not for applications
+	}
+	if s.app == "" {
+		s.app = "api-gateway"
+	}
+	s.host, _ = os.Hostname()
+	if s.host == "" {
+		s.host = "-"
+	}
+	return s, nil
+}
+
+// Send writes the batch, dialing if needed.
+func (s *syslogSink) Send(ctx context.Context, batch []rlog.LogEntry) error {
+	if s.conn == nil {
+		var d net.Dialer
+		conn, err := d.DialContext(ctx, s.network, s.address)
+		if err != nil {
+			return err
+		}
+		s.conn = conn
+	}
+	if dl, ok := ctx.Deadline(); ok {
+		_ = s.conn.SetWriteDeadline(dl)
+	}
+	stream := s.network == "tcp" || s.network == "unix"
+	for _, e := range batch {
+		msg := s.format(e)
+		if stream {
+			msg = strconv.Itoa(len(msg)) + " " + msg
+		}
+		if _, err := s.conn.Write([]byte(msg)); err != nil {
+			s.conn.Close()
+			s.conn = nil // redial on retry; part of the batch may be repeated
+			return err
+		}
+	}
+	return nil
+}
+
+// Close closes the connection.
+func (s *syslogSink) Close() error {
+	if s.conn == nil {
+		return nil
+	}
+	return s.conn.Close()
+}
+
+// format renders:
+// <PRI>1 TIMESTAMP HOSTNAME APP-NAME PROCID MSGID [ctx@32473 k="v" ...] MSG
+func (s *syslogSink) format(e rlog.LogEntry) string {
+	pri := s.facility*8 + severity(e.Level)
+	ts := e.Timestamp
+	if ts == "" {
+		ts = time.Now().UTC().Format(time.RFC3339)
+	}
+	msgID := "-"
+	if name, _ := e.Context["logger"].(string); name != "" {
+		msgID = sdName(name)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "<%d>1 %s %s %s %s %s ", pri, ts, s.host, s.app, s.pid, msgID)
+	if len(e.Context) == 0 {
+		b.WriteString("-")
+	} else {
+		keys := make([]string, 0, len(e.Context))
+		for k := range e.Context {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		b.WriteString("[" + sdID)
+		for _, k := range keys {
+			fmt.Fprintf(&b, ` %s="%s"`, sdName(k), sdEscape(fmt.Sprint(e.Context[k])))
+		}
+		b.WriteString("]")
+	}
+	if e.Message != "" {
+		b.WriteString(" " + e.Message)
+	}
+	return b.String()
+}
+
+// severity maps zap level names to syslog severities.
+func severity(level string) int {
+	switch level {
+	case "DEBUG":
+		return 7
+	case "INFO":
+		return 6
+	case "WARN":
+		return 4
+	case "ERROR":
+		return 3
+	case "DPANIC", "PANIC", "FATAL":
+		return 2
+	default:
+		return 5 // notice
+	}
+}
+
+// sdName keeps printable ASCII except '=', ' ', ']' and '"', max 32 chars.
+func sdName(s string) string {
+	out := make([]byte, 0, len(s))
+	for i := 0; i < len(s) && len(out) < 32; i++ {
+		c := s[i]
+		if c > 32 && c < 127 && c != '=' && c != ']' && c != '"' {
+			out = append(out, c)
+		} else {
+			out = append(out, '_')
+		}
+	}
+	if len(out) == 0 {
+		return "_"
+	}
+	return string(out)
+}
+
+// sdEscape escapes '"', '\' and ']' in PARAM-VALUE.
+var sdEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, `]`, `\]`)
+
+func sdEscape(s string) string { return sdEscaper.Replace(s) }
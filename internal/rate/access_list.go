@@ -0,0 +1,240 @@
+package rate // Runtime allow/deny lists (IPs, CIDRs, user IDs) stored in Redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"example.com/api-gateway/internal/redis"
+	"go.uber.org/zap"
+)
+
+// List names accepted by AccessList.
+const (
+	ListAllow = "allow" // bypass rate limiting
+	ListDeny  = "deny"  // reject outright
+)
+
+// Validation errors returned by Add/Remove.
+var (
+	ErrUnknownList  = errors.New("unknown list (want allow|deny)")
+	ErrInvalidEntry = errors.New("invalid access list entry")
+	ErrNoSuchEntry  = errors.New("entry is not in the list")
+)
+
+// AccessList holds allow/deny entries shared across replicas through Redis
+// sets and cached locally, so checks never hit Redis on the request path.
+// Entries are exact keys (an IP or a user ID) or CIDR ranges.
+type AccessList struct {
+	c       redis.Client
+	refresh time.Duration
+	log     *zap.Logger
+
+	mu    sync.RWMutex
+	lists map[string]*matcher
+
+	stop     chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+// matcher is one list compiled for lookups.
+type matcher struct {
+	exact map[string]struct{}
+	nets  []*net.IPNet
+}
+
+// NewAccessList builds the list; call Start to load it and keep it fresh.
+func NewAccessList(c redis.Client, refresh time.Duration, log *zap.Logger) *AccessList {
+	if refresh <= 0 {
+		refresh = 10 * time.Second
+	}
+	return &AccessList{
+		c:       c,
+		refresh: refresh,
+		log:     NewLoggerTagged(log, "access-list"),
+		lists:   map[string]*matcher{ListAllow: compile(nil), ListDeny: compile(nil)},
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+}
+
+// redisListKey is the Redis set backing list.
+func redisListKey(list string) string { return "rl:list:" + list }
+
+// Start loads both lists and refreshes them periodically until Stop.
+// While Redis is unreachable the last loaded entries stay in effect.
+func (a *AccessList) Start() {
+	a.load(context.Background())
+	go func() {
+		defer close(a.done)
+		t := time.NewTicker(a.refresh)
+		defer t.Stop()
+		for {
+			select {
+			case <-a.stop:
+				return
+			case <-t.C:
+				a.load(context.Background())
+			}
+		}
+	}()
+}
+
+// Stop ends the refresh loop.
+func (a *AccessList) Stop() {
+	a.stopOnce.Do(func() { close(a.stop) })
+	<-a.done
+}
+
+// Check reports whether the request identified by ip and (optional) userID
+// is allow-listed or deny-listed. Deny wins when both match.
+func (a *AccessList) Check(ip, userID string) (allowed, denied bool) {
+	if a == nil {
+		return false, false
+	}
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	if a.lists[ListDeny].match(ip, userID) {
+		return false, true
+	}
+	return a.lists[ListAllow].match(ip, userID), false
+}
+
+// Entries returns the cached entries of both lists, sorted.
+func (a *AccessList) Entries() map[string][]string {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	out := make(map[string][]string, len(a.lists))
+	for name, m := range a.lists {
+		out[name] = m.entries()
+	}
+	return out
+}
+
+// Add validates entry, stores it in Redis and applies it locally right away.
+func (a *AccessList) Add(ctx context.Context, list, entry string) error {
+	entry, err := normalizeEntry(list, entry)
+	if err != nil {
+		return err
+	}
+	if err := a.c.SAdd(ctx, redisListKey(list), entry).Err(); err != nil {
+		return err
+	}
+	a.load(ctx)
+	return nil
+}
+
+// Remove deletes entry from Redis and from the local cache.
+// ErrNoSuchEntry reports an entry that was not listed.
+func (a *AccessList) Remove(ctx context.Context, list, entry string) error {
+	entry, err := normalizeEntry(list, entry)
+	if err != nil {
+		return err
+	}
+	n, err := a.c.SRem(ctx, redisListKey(list), entry).Result()
+	if err != nil {
+		return err
+	}
+	a.load(ctx)
+	if n == 0 {
+		return ErrNoSuchEntry
+	}
+	return nil
+}
+
+// load replaces the cache with the current Redis contents.
+func (a *AccessList) load(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, time.Second)
+	defer cancel()
+	fresh := make(map[string]*matcher, 2)
+	for _, name := range []string{ListAllow, ListDeny} {
+		members, err := a.c.SMembers(ctx, redisListKey(name)).Result()
+		if err != nil {
+			a.log.Debug("access list refresh failed, keeping cached entries", zap.Error(err))
+			return
+		}
+		fresh[name] = compile(members)
+	}
+	a.mu.Lock()
+	a.lists = fresh
+	a.mu.Unlock()
+}
+
+// normalizeEntry checks the list name and canonicalizes CIDRs and IPs.
+func normalizeEntry(list, entry string) (string, error) {
+	if list != ListAllow && list != ListDeny {
+		return "", ErrUnknownList
+	}
+	entry = strings.TrimSpace(entry)
+	if entry == "" {
+		return "", fmt.Errorf("%w: empty", ErrInvalidEntry)
+	}
+	if strings.Contains(entry, "/") {
+		_, n, err := net.ParseCIDR(entry)
+		if err != nil {
+			return "", fmt.Errorf("%w: %v", ErrInvalidEntry, err)
+		}
+		return n.String(), nil
+	}
+	if ip := net.ParseIP(entry); ip != nil {
+		if v4 := ip.To4(); v4 != nil {
+			return v4.String(), nil
+		}
+		return ip.String(), nil
+	}
+	return entry, nil // user ID
+}
+
+// compile splits entries into exact keys and networks.
+func compile(entries []string) *matcher {
+	m := &matcher{exact: make(map[string]struct{}, len(entries))}
+	for _, e := range entries {
+		if _, n, err := net.ParseCIDR(e); err == nil {
+			m.nets = append(m.nets, n)
+			continue
+		}
+		m.exact[e] = struct{}{}
+	}
+	return m
+}
+
+// match tests ip (exact or by network) and userID (exact).
+func (m *matcher) match(ip, userID string) bool {
+	if _, ok := m.exact[ip]; ok {
+		return true
+	}
+	if userID != "" {
+		if _, ok := m.exact[userID]; ok {
+			return true
+		}
+	}
+	if len(m.nets) > 0 {
+		if parsed := net.ParseIP(ip); parsed != nil {
+			for _, n := range m.nets {
+				if n.Contains(parsed) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// entries lists the matcher contents in sorted order.
+func (m *matcher) entries() []string {
+	out := make([]string, 0, len(m.exact)+len(m.nets))
+	for e := range m.exact {
+		out = append(out, e)
+	}
+	for _, n := range m.nets {
+		out = append(out, n.String())
+	}
+	sort.Strings(out)
+	return out
+}
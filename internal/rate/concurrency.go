@@ -0,0 +1,260 @@
+package rate // In-flight request limiting with bounded queues + AIMD shedding
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+
+	"example.com/api-gateway/config"
+	"go.uber.org/zap"
+)
+
+// ConcurrencyLimiter caps how many requests run at once, globally and per key.
+// It complements Limiter: the token bucket bounds request rate, this bounds
+// work in progress (and therefore DB pool pressure).
+type ConcurrencyLimiter interface {
+	// Acquire waits (bounded by the queue timeout) for a slot for key.
+	// On success release must be called exactly once when the work is done.
+	// On rejection retryAfter hints when the client should try again.
+	Acquire(ctx context.Context, key string) (release func(), retryAfter time.Duration, ok bool)
+}
+
+// CCfg is an alias to avoid importing config everywhere.
+type CCfg = config.Concurrency
+
+// Defaults used when Concurrency fields are left at zero.
+const (
+	defaultMaxInFlight   = 256
+	defaultQueueTimeout  = time.Second
+	defaultTargetLatency = 500 * time.Millisecond
+)
+
+// concurrencyLimiter combines a global gate with lazily created per-key gates.
+type concurrencyLimiter struct {
+	cfg     CCfg
+	timeout time.Duration
+	global  *gate
+	aimd    *aimd // nil unless adaptive
+
+	mu   sync.Mutex
+	keys map[string]*keyGate
+	log  *zap.Logger
+}
+
+// keyGate is a per-key gate plus a reference count so idle keys are dropped.
+type keyGate struct {
+	*gate
+	refs int
+}
+
+// NewConcurrencyLimiter constructs the limiter from config.
+func NewConcurrencyLimiter(cfg CCfg, log *zap.Logger) ConcurrencyLimiter {
+	maxInFlight := cfg.MaxInFlight
+	if maxInFlight <= 0 {
+		maxInFlight = defaultMaxInFlight
+	}
+	timeout := time.Duration(cfg.QueueTimeoutMS) * time.Millisecond
+	if timeout <= 0 {
+		timeout = defaultQueueTimeout
+	}
+	l := &concurrencyLimiter{
+		cfg:     cfg,
+		timeout: timeout,
+		global:  newGate(maxInFlight, cfg.QueueSize),
+		keys:    make(map[string]*keyGate),
+		log:     NewLoggerTagged(log, "concurrency"),
+	}
+	if cfg.Adaptive {
+		target := time.Duration(cfg.TargetLatencyMS) * time.Millisecond
+		if target <= 0 {
+			target = defaultTargetLatency
+		}
+		floor := max(1, cfg.MinInFlight)
+		if floor > maxInFlight {
+			floor = maxInFlight
+		}
+		l.aimd = &aimd{
+			limit:  float64(maxInFlight),
+			min:    float64(floor),
+			max:    float64(maxInFlight),
+			target: target,
+		}
+	}
+	return l
+}
+
+// Acquire takes the per-key slot first (cheap rejection for a hot route),
+// then the global one. Both waits share the same queue deadline.
+func (l *concurrencyLimiter) Acquire(ctx context.Context, key string) (func(), time.Duration, bool) {
+	ctx, cancel := context.WithTimeout(ctx, l.timeout)
+	defer cancel()
+
+	var kg *keyGate
+	if l.cfg.PerKeyInFlight > 0 {
+		kg = l.ref(key)
+		if !kg.acquire(ctx) {
+			l.unref(key, kg)
+			return nil, l.retryAfter(), false
+		}
+	}
+	if !l.global.acquire(ctx) {
+		if kg != nil {
+			kg.release()
+			l.unref(key, kg)
+		}
+		return nil, l.retryAfter(), false
+	}
+
+	start := time.Now()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			l.global.release()
+			if kg != nil {
+				kg.release()
+				l.unref(key, kg)
+			}
+			if l.aimd != nil {
+				if n, changed := l.aimd.observe(time.Since(start)); changed {
+					l.global.setLimit(n)
+					l.log.Debug("adaptive limit changed", zap.Int("limit", n))
+				}
+			}
+		})
+	}, 0, true
+}
+
+// retryAfter suggests waiting one queue timeout (at least a second).
+func (l *concurrencyLimiter) retryAfter() time.Duration {
+	if l.timeout < time.Second {
+		return time.Second
+	}
+	return l.timeout
+}
+
+// ref returns the gate for key, creating it on first use.
+func (l *concurrencyLimiter) ref(key string) *keyGate {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	kg, ok := l.keys[key]
+	if !ok {
+		kg = &keyGate{gate: newGate(l.cfg.PerKeyInFlight, l.cfg.QueueSize)}
+		l.keys[key] = kg
+	}
+	kg.refs++
+	return kg
+}
+
+// unref drops the gate for key once nobody holds or waits on it.
+func (l *concurrencyLimiter) unref(key string, kg *keyGate) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	kg.refs--
+	if kg.refs == 0 {
+		delete(l.keys, key)
+	}
+}
+
+// gate is a counting semaphore with a bounded FIFO wait queue.
+type gate struct {
+	mu       sync.Mutex
+	limit    int
+	inFlight int
+	queue    *list.List // of chan struct{}; closed when the waiter is granted a slot
+	maxQueue int
+}
+
+func newGate(limit, maxQueue int) *gate {
+	return &gate{limit: limit, maxQueue: maxQueue, queue: list.New()}
+}
+
+// acquire takes a slot, queueing until ctx is done if none is free.
+// It returns false straight away when the queue is already full.
+func (g *gate) acquire(ctx context.Context) bool {
+	g.mu.Lock()
+	if g.inFlight < g.limit && g.queue.Len() == 0 {
+		g.inFlight++
+		g.mu.Unlock()
+		return true
+	}
+	if g.queue.Len() >= g.maxQueue {
+		g.mu.Unlock()
+		return false
+	}
+	ready := make(chan struct{})
+	el := g.queue.PushBack(ready)
+	g.mu.Unlock()
+
+	select {
+	case <-ready:
+		return true
+	case <-ctx.Done():
+		g.mu.Lock()
+		select {
+		case <-ready: // granted while we were timing out: hand the slot back
+			g.inFlight--
+			g.dispatch()
+		default:
+			g.queue.Remove(el)
+		}
+		g.mu.Unlock()
+		return false
+	}
+}
+
+// release frees a slot and wakes the next waiter.
+func (g *gate) release() {
+	g.mu.Lock()
+	g.inFlight--
+	g.dispatch()
+	g.mu.Unlock()
+}
+
+// setLimit changes the cap; raising it admits queued waiters immediately.
+func (g *gate) setLimit(n int) {
+	g.mu.Lock()
+	g.limit = n
+	g.dispatch()
+	g.mu.Unlock()
+}
+
+// dispatch grants free slots to waiters in FIFO order; the caller holds g.mu.
+func (g *gate) dispatch() {
+	for g.inFlight < g.limit && g.queue.Len() > 0 {
+		g.inFlight++
+		close(g.queue.Remove(g.queue.Front()).(chan struct{}))
+	}
+}
+
+// aimd adjusts a limit additively up while latency is healthy and
+// multiplicatively down when it exceeds the target.
+type aimd struct {
+	mu     sync.Mutex
+	limit  float64
+	min    float64
+	max    float64
+	target time.Duration
+}
+
+// observe feeds one request latency and returns the new integer limit and
+// whether it differs from the previous one.
+func (a *aimd) observe(latency time.Duration) (int, bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	before := int(a.limit)
+	if latency > a.target {
+		a.limit = max64(a.min, a.limit*0.9) // back off fast
+	} else {
+		a.limit = min(a.max, a.limit+1/a.limit) // ~+1 per window of `limit` requests
+	}
+	after := int(a.limit)
+	return after, after != before
+}
+
+func max64(a, b float64) float64 {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,308 @@
+package rate // Local counting with batched Redis sync (global limit across replicas)
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"example.com/api-gateway/internal/redis"
+	"go.uber.org/zap"
+)
+
+// Defaults used when the hybrid tuning fields are left at zero.
+const defaultSyncInterval = 250 * time.Millisecond
+
+// evictScan bounds how many entries one eviction looks at for an idle victim.
+const evictScan = 8
+
+// hybridLimiter enforces the same per-minute window as redisLimiter, but
+// decides locally: each replica counts hits in memory and a sync loop pushes
+// the deltas to Redis with INCRBY (one pipeline per tick), reading back the
+// cluster-wide total. Only keys with new hits are pushed; the others keep
+// the total from their last push, except keys at their limit, which are
+// re-read with GET so a Reset elsewhere lets them through again. Every
+// INCRBY is paired with a PEXPIRE, so a counter recreated after Reset still
+// expires. A key that collects MaxLocalPending unsynced hits is flushed
+// inline, so the global overshoot stays around replicas x MaxLocalPending
+// per window. At most MaxKeys keys are tracked (see evictLocked).
+type hybridLimiter struct {
+	c          redis.Client
+	cfg        Cfg
+	mon        *redis.Monitor // nil = assume healthy
+	mode       string
+	limit      int64
+	maxPending int64
+	maxKeys    int
+	log        *zap.Logger
+
+	mu      sync.Mutex
+	entries map[string]*hybridEntry
+
+	stop     chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+// hybridEntry is the local view of one key in the current window.
+type hybridEntry struct {
+	window   int64  // unix minute the counts belong to
+	global   int64  // last cluster-wide total read from Redis (includes our synced hits)
+	pending  int64  // local hits not pushed yet
+	inflight int64  // local hits being pushed right now
+	seq      uint64 // pushes sent for this entry
+	applied  uint64 // push whose total global holds; older replies are ignored
+}
+
+// hybridSync is one key's delta in a sync batch.
+type hybridSync struct {
+	key    string
+	window int64
+	delta  int64
+	read   bool         // GET the total instead of pushing a delta
+	entry  *hybridEntry // the entry the delta came from
+	seq    uint64       // entry.seq when sent
+}
+
+// hybridTTL keeps a window's counter a minute past its end.
+const hybridTTL = 2 * time.Minute
+
+// NewHybridLimiter constructs the limiter and starts its sync loop.
+// mon may be nil. Call Stop to flush pending counts and end the loop.
+func NewHybridLimiter(c redis.Client, cfg Cfg, mon *redis.Monitor, log *zap.Logger) Limiter {
+	limit := int64(cfg.RequestsPerMinute + cfg.Burst)
+	maxPending := int64(cfg.MaxLocalPending)
+	if maxPending <= 0 {
+		maxPending = max64i(1, limit/10)
+	}
+	maxKeys := cfg.MaxKeys
+	if maxKeys <= 0 {
+		maxKeys = defaultMaxKeys
+	}
+	h := &hybridLimiter{
+		c:          c,
+		cfg:        cfg,
+		mon:        mon,
+		mode:       failureMode(cfg),
+		limit:      limit,
+		maxPending: maxPending,
+		maxKeys:    maxKeys,
+		log:        NewLoggerTagged(log, "hybrid"),
+		entries:    make(map[string]*hybridEntry),
+		stop:       make(chan struct{}),
+		done:       make(chan struct{}),
+	}
+	interval := time.Duration(cfg.SyncIntervalMS) * time.Millisecond
+	if interval <= 0 {
+		interval = defaultSyncInterval
+	}
+	go h.loop(interval)
+	return h
+}
+
+// Allow decides from local state only; Redis is touched inline solely when
+// this key's unsynced count reaches the pending cap.
+func (h *hybridLimiter) Allow(key string) (bool, time.Duration) {
+	healthy := h.mon.Healthy()
+	if !healthy {
+		switch h.mode {
+		case FailOpen:
+			degradedDecisions.WithLabelValues(h.mode, "unavailable", "true").Inc()
+			return true, 0
+		case FailClosed:
+			degradedDecisions.WithLabelValues(h.mode, "unavailable", "false").Inc()
+			return false, time.Second
+		} // FailLocal: keep counting locally, which is exactly the degraded behavior
+	}
+
+	now := time.Now()
+	win := now.Unix() / 60
+	var batch []hybridSync
+	h.mu.Lock()
+	e, ok := h.entries[key]
+	if !ok || e.window != win {
+		if !ok && len(h.entries) >= h.maxKeys {
+			batch = h.evictLocked(win, healthy)
+		}
+		e = &hybridEntry{window: win}
+		h.entries[key] = e
+	}
+	if e.global+e.pending+e.inflight >= h.limit {
+		h.mu.Unlock()
+		if batch != nil {
+			h.push(batch)
+		}
+		if !healthy {
+			degradedDecisions.WithLabelValues(h.mode, "unavailable", "false").Inc()
+		}
+		return false, time.Duration(60-now.Unix()%60) * time.Second
+	}
+	e.pending++
+	if healthy && e.pending >= h.maxPending {
+		e.seq++
+		batch = append(batch, hybridSync{key: key, window: win, delta: e.pending, entry: e, seq: e.seq})
+		e.inflight += e.pending
+		e.pending = 0
+	}
+	h.mu.Unlock()
+
+	if batch != nil {
+		h.push(batch)
+	}
+	if !healthy {
+		degradedDecisions.WithLabelValues(h.mode, "unavailable", "true").Inc()
+	}
+	return true, 0
+}
+
+// loop syncs every interval until Stop, then flushes once more.
+func (h *hybridLimiter) loop(interval time.Duration) {
+	defer close(h.done)
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-h.stop:
+			h.sync()
+			return
+		case <-t.C:
+			h.sync()
+		}
+	}
+}
+
+// evictLocked drops one entry to make room for a new key: a past window or
+// an idle key (nothing pending or in flight) among the first evictScan
+// entries, else the first one seen. Hits the victim had not pushed yet are
+// returned as a batch (when healthy), so they still count in Redis. An
+// evicted key that comes back starts from zero until its first push reads
+// the cluster-wide total again. Callers hold h.mu.
+func (h *hybridLimiter) evictLocked(win int64, healthy bool) []hybridSync {
+	victim, n := "", 0
+	for key, e := range h.entries {
+		if e.window != win || e.pending+e.inflight == 0 {
+			victim = key
+			break
+		}
+		if n == 0 {
+			victim = key
+		}
+		if n++; n == evictScan {
+			break
+		}
+	}
+	e := h.entries[victim]
+	delete(h.entries, victim)
+	if !healthy || e.window != win || e.pending == 0 {
+		return nil
+	}
+	return []hybridSync{{key: victim, window: e.window, delta: e.pending, entry: e, seq: e.seq + 1}}
+}
+
+// sync drops entries from past windows and pushes keys with pending hits.
+// Keys at their limit with nothing in flight are re-read (GET), so a Reset
+// by another replica takes effect; other idle keys cost nothing.
+func (h *hybridLimiter) sync() {
+	if !h.mon.Healthy() {
+		return
+	}
+	win := time.Now().Unix() / 60
+	h.mu.Lock()
+	var batch []hybridSync
+	for key, e := range h.entries {
+		switch {
+		case e.window != win:
+			delete(h.entries, key)
+		case e.pending > 0:
+			e.seq++
+			batch = append(batch, hybridSync{key: key, window: e.window, delta: e.pending, entry: e, seq: e.seq})
+			e.inflight += e.pending
+			e.pending = 0
+		case e.inflight == 0 && e.global >= h.limit:
+			e.seq++
+			batch = append(batch, hybridSync{key: key, window: e.window, read: true, entry: e, seq: e.seq})
+		}
+	}
+	h.mu.Unlock()
+	if len(batch) > 0 {
+		h.push(batch)
+	}
+}
+
+// push sends a batch in one pipeline and folds the totals back in.
+// On failure the deltas return to pending so they are retried next tick.
+// Pushes for one key may overlap (inline flush vs. tick); a reply older
+// than the total already applied is ignored so it cannot roll it back.
+func (h *hybridLimiter) push(batch []hybridSync) {
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+
+	pipe := h.c.Pipeline()
+	incrs := make([]interface {
+		Val() int64
+		Err() error
+	}, len(batch))
+	reads := make([]interface{ Int64() (int64, error) }, len(batch))
+	for i, s := range batch {
+		k := hybridKey(s.key, s.window)
+		if s.read {
+			reads[i] = pipe.Get(ctx, k)
+			continue
+		}
+		incrs[i] = pipe.IncrBy(ctx, k, s.delta)
+		pipe.PExpire(ctx, k, hybridTTL)
+	}
+	_, err := pipe.Exec(ctx)
+	if errors.Is(err, redis.Nil) { // a GET of a reset (deleted) counter; did the pushes land?
+		err = nil
+		for _, c := range incrs {
+			if c != nil && c.Err() != nil {
+				err = c.Err()
+				break
+			}
+		}
+	}
+	if err != nil {
+		h.log.Warn("redis sync fail", zap.Error(err), zap.Int("keys", len(batch)))
+		h.mon.MarkDown(err)
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for i, s := range batch {
+		e := s.entry
+		if cur, ok := h.entries[s.key]; !ok || cur != e {
+			continue // window rolled over or key reset meanwhile; counts are moot
+		}
+		e.inflight -= s.delta
+		if err != nil {
+			e.pending += s.delta
+			continue
+		}
+		if s.seq <= e.applied {
+			continue
+		}
+		if s.read {
+			total, getErr := reads[i].Int64()
+			if getErr != nil && !errors.Is(getErr, redis.Nil) {
+				continue
+			}
+			e.global, e.applied = total, s.seq
+			continue
+		}
+		e.global, e.applied = incrs[i].Val(), s.seq
+	}
+}
+
+// Stop flushes pending counts and ends the sync loop. Safe to call twice.
+func (h *hybridLimiter) Stop() {
+	h.stopOnce.Do(func() { close(h.stop) })
+	<-h.done
+}
+
+func max64i(a, b int64) int64 {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,136 @@
+package rate // Read/reset limiter state for the admin API
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"example.com/api-gateway/internal/redis"
+)
+
+// State is a point-in-time view of one key's limiter state.
+type State struct {
+	Key       string  `json:"key"`
+	Strategy  string  `json:"strategy"`
+	Tracked   bool    `json:"tracked"`             // false: no state, the next request starts fresh
+	Limit     int     `json:"limit"`               // bucket size or hits per window
+	Remaining float64 `json:"remaining"`           // tokens (memory) or hits left in the window
+	Used      int64   `json:"used,omitempty"`      // hits counted in the current window (redis/hybrid)
+	Window    string  `json:"window,omitempty"`    // window identifier (redis/hybrid)
+	ResetInMS int64   `json:"resetInMs,omitempty"` // until the bucket is full / the window ends
+}
+
+// Inspector is implemented by limiters whose per-key state can be viewed
+// and cleared at runtime (memory, redis, hybrid).
+type Inspector interface {
+	Inspect(ctx context.Context, key string) (State, error)
+	Reset(ctx context.Context, key string) error
+}
+
+// Inspect returns the bucket for key as Allow would see it now, without consuming.
+func (m *memoryLimiter) Inspect(_ context.Context, key string) (State, error) {
+	st := State{Key: key, Strategy: "memory", Limit: m.cfg.Burst, Remaining: float64(m.cfg.Burst)}
+	s := m.shardFor(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	el, ok := s.buckets[key]
+	if !ok {
+		return st, nil
+	}
+	b := el.Value.(*bucket)
+	refill := time.Since(b.lastRefill).Minutes() * float64(m.cfg.RequestsPerMinute)
+	st.Tracked = true
+	st.Remaining = min(float64(m.cfg.Burst), b.tokens+refill)
+	missing := float64(m.cfg.Burst) - st.Remaining
+	st.ResetInMS = int64(missing * float64(time.Minute/time.Millisecond) / float64(max(1, m.cfg.RequestsPerMinute)))
+	return st, nil
+}
+
+// Reset drops the bucket so key starts over with a full burst.
+func (m *memoryLimiter) Reset(_ context.Context, key string) error {
+	s := m.shardFor(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if el, ok := s.buckets[key]; ok {
+		s.remove(el)
+	}
+	return nil
+}
+
+// windowKey is the Redis key redisLimiter uses for key in the current minute.
+func windowKey(key string, now time.Time) (string, string) {
+	w := now.Format("200601021504") // YYYYMMDDHHmm
+	return "rl:" + key + ":" + w, w
+}
+
+// Inspect reads the current window counter from Redis.
+func (r *redisLimiter) Inspect(ctx context.Context, key string) (State, error) {
+	limit := r.cfg.RequestsPerMinute + r.cfg.Burst
+	bucketKey, w := windowKey(key, time.Now())
+	st := State{Key: key, Strategy: "redis", Limit: limit, Remaining: float64(limit), Window: w}
+	count, err := r.c.Get(ctx, bucketKey).Int64()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return st, nil
+		}
+		return st, err
+	}
+	ttl, _ := r.c.TTL(ctx, bucketKey).Result()
+	st.Tracked = true
+	st.Used = count
+	st.Remaining = float64(max(0, limit-int(count)))
+	st.ResetInMS = ttl.Milliseconds()
+	return st, nil
+}
+
+// Reset deletes the current window counter (and the local fallback bucket).
+func (r *redisLimiter) Reset(ctx context.Context, key string) error {
+	if in, ok := r.fallback.(Inspector); ok {
+		_ = in.Reset(ctx, key)
+	}
+	bucketKey, _ := windowKey(key, time.Now())
+	return r.c.Del(ctx, bucketKey).Err()
+}
+
+// hybridKey is the Redis key hybridLimiter uses for key in window win.
+func hybridKey(key string, win int64) string {
+	return "rl:h:" + key + ":" + strconv.FormatInt(win, 10)
+}
+
+// Inspect combines the cluster-wide count in Redis with this replica's unsynced hits.
+func (h *hybridLimiter) Inspect(ctx context.Context, key string) (State, error) {
+	now := time.Now()
+	win := now.Unix() / 60
+	st := State{
+		Key: key, Strategy: "hybrid", Limit: int(h.limit), Remaining: float64(h.limit),
+		Window: strconv.FormatInt(win, 10), ResetInMS: (60 - now.Unix()%60) * 1000,
+	}
+	global, err := h.c.Get(ctx, hybridKey(key, win)).Int64()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return st, err
+	}
+	h.mu.Lock()
+	if e, ok := h.entries[key]; ok && e.window == win {
+		global += e.pending + e.inflight // unsynced local hits
+		st.Tracked = true
+	}
+	h.mu.Unlock()
+	if global > 0 {
+		st.Tracked = true
+	}
+	st.Used = global
+	st.Remaining = float64(max64i(0, h.limit-global))
+	return st, nil
+}
+
+// Reset clears the local entry and the shared counter. Other replicas pick
+// up the reset with their next push, or on their next sync while the key
+// is at its limit there.
+func (h *hybridLimiter) Reset(ctx context.Context, key string) error {
+	win := time.Now().Unix() / 60
+	h.mu.Lock()
+	delete(h.entries, key)
+	h.mu.Unlock()
+	return h.c.Del(ctx, hybridKey(key, win)).Err()
+}
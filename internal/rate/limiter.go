@@ -13,6 +13,12 @@ type Limiter interface {
 	Allow(key string) (allowed bool, retryAfter time.Duration)
 }
 
+// Stopper is implemented by limiters that own background goroutines
+// (janitors, sync loops). httpx.Live stops them on replacement and at exit.
+type Stopper interface {
+	Stop()
+}
+
 // Cfg is an alias to avoid importing config everywhere.
 type Cfg = config.RateLimit
 
@@ -1,44 +1,130 @@
 package rate // In-memory token bucket limiter
 
 import (
+	"container/list"
+	"hash/maphash"
 	"sync"
 	"time"
 
 	"go.uber.org/zap"
 )
 
-// memoryLimiter holds buckets per key.
+// Defaults used when the memory tuning fields are left at zero.
+const (
+	defaultShards          = 32
+	defaultMaxKeys         = 100_000
+	defaultIdleTTL         = 10 * time.Minute
+	defaultJanitorInterval = time.Minute
+)
+
+// memoryLimiter holds buckets per key, spread over independently locked shards.
+// Each shard keeps its buckets in LRU order so the max-keys cap and the idle
+// sweep only ever touch the cold end of the list.
 type memoryLimiter struct {
-	mu     sync.Mutex
-	cfg    Cfg
-	buckets map[string]*bucket
-	log    *zap.Logger
+	cfg         Cfg
+	seed        maphash.Seed
+	shards      []*shard
+	maxPerShard int
+	idleTTL     time.Duration
+	log         *zap.Logger
+
+	stop     chan struct{} // closed by Stop
+	done     chan struct{} // closed when the janitor exits
+	stopOnce sync.Once
+}
+
+// shard is one lock domain of the bucket map.
+type shard struct {
+	mu      sync.Mutex
+	buckets map[string]*list.Element // key -> element holding *bucket
+	lru     *list.List               // front = most recently used
 }
 
 // bucket stores token state.
 type bucket struct {
+	key        string
 	tokens     float64
-	lastRefill time.Time
+	lastRefill time.Time // also "last seen": every Allow refills
 }
 
-// NewMemoryLimiter constructs the limiter.
+// NewMemoryLimiter constructs the limiter and starts its janitor goroutine.
+// Call Stop (see Stopper) to terminate the janitor on shutdown.
 func NewMemoryLimiter(cfg Cfg, log *zap.Logger) Limiter {
-	return &memoryLimiter{cfg: cfg, buckets: make(map[string]*bucket), log: NewLoggerTagged(log, "memory")}
+	n := cfg.Shards
+	if n <= 0 {
+		n = defaultShards
+	}
+	maxKeys := cfg.MaxKeys
+	if maxKeys <= 0 {
+		maxKeys = defaultMaxKeys
+	}
+	m := &memoryLimiter{
+		cfg:         cfg,
+		seed:        maphash.MakeSeed(),
+		shards:      make([]*shard, n),
+		maxPerShard: max(1, maxKeys/n),
+		idleTTL:     idleTTL(cfg),
+		log:         NewLoggerTagged(log, "memory"),
+		stop:        make(chan struct{}),
+		done:        make(chan struct{}),
+	}
+	for i := range m.shards {
+		m.shards[i] = &shard{buckets: make(map[string]*list.Element), lru: list.New()}
+	}
+
+	interval := time.Duration(cfg.JanitorIntervalMS) * time.Millisecond
+	if interval <= 0 {
+		interval = defaultJanitorInterval
+	}
+	go m.janitor(interval)
+	return m
+}
+
+// idleTTL returns how long a bucket may sit unused before it is dropped.
+// An evicted bucket comes back full, so the TTL is never shorter than the
+// time an empty bucket needs to refill to burst; idle eviction can't grant
+// extra tokens. The max_keys cap can: see victim.
+func idleTTL(cfg Cfg) time.Duration {
+	ttl := time.Duration(cfg.IdleTTLMS) * time.Millisecond
+	if ttl <= 0 {
+		ttl = defaultIdleTTL
+	}
+	refill := time.Duration(float64(time.Minute) * float64(cfg.Burst) / float64(max(1, cfg.RequestsPerMinute)))
+	if ttl < refill {
+		ttl = refill
+	}
+	return ttl
+}
+
+// shardFor picks the shard owning key.
+func (m *memoryLimiter) shardFor(key string) *shard {
+	return m.shards[maphash.String(m.seed, key)%uint64(len(m.shards))]
 }
 
 // Allow checks/updates tokens.
 func (m *memoryLimiter) Allow(key string) (bool, time.Duration) {
-	m.mu.Lock(); defer m.mu.Unlock()
-	b, ok := m.buckets[key]
-	if !ok { // create new bucket
-		b = &bucket{tokens: float64(m.cfg.Burst), lastRefill: time.Now()}
-		m.buckets[key] = b
+	now := time.Now()
+	s := m.shardFor(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var b *bucket
+	if el, ok := s.buckets[key]; ok {
+		s.lru.MoveToFront(el)
+		b = el.Value.(*bucket)
+	} else { // create new bucket, evicting the coldest one if the shard is full
+		if s.lru.Len() >= m.maxPerShard {
+			s.remove(m.victim(s, now))
+		}
+		b = &bucket{key: key, tokens: float64(m.cfg.Burst), lastRefill: now}
+		s.buckets[key] = s.lru.PushFront(b)
 	}
+
 	// Refill proportional to elapsed time
-	elapsed := time.Since(b.lastRefill).Minutes()
+	elapsed := now.Sub(b.lastRefill).Minutes()
 	refill := elapsed * float64(m.cfg.RequestsPerMinute) // tokens per minute
 	b.tokens = min(float64(m.cfg.Burst), b.tokens+refill)
-	b.lastRefill = time.Now()
+	b.lastRefill = now
 	if b.tokens >= 1 { // consume a token
 		b.tokens -= 1
 		return true, 0
@@ -46,8 +132,83 @@ func (m *memoryLimiter) Allow(key string) (bool, time.Duration) {
 	// Not enough tokens -> compute retry-after until next token
 	need := 1 - b.tokens
 	perToken := time.Minute / time.Duration(max(1, m.cfg.RequestsPerMinute))
-	return false, time.Duration(need*float64(perToken))
+	return false, time.Duration(need * float64(perToken))
+}
+
+// victim picks the bucket to evict from a full shard: the coldest one that
+// has refilled to burst by now (dropping it loses nothing), looking at most
+// evictScan buckets from the cold end, else the coldest. Evicting a
+// depleted bucket hands its key a full one when it returns, so a client
+// rotating keys past the cap can gain tokens; preferring full buckets keeps
+// that to shards whose cold end is all recently drained.
+func (m *memoryLimiter) victim(s *shard, now time.Time) *list.Element {
+	burst := float64(m.cfg.Burst)
+	el := s.lru.Back()
+	for i := 0; el != nil && i < evictScan; i, el = i+1, el.Prev() {
+		b := el.Value.(*bucket)
+		if b.tokens+now.Sub(b.lastRefill).Minutes()*float64(m.cfg.RequestsPerMinute) >= burst {
+			return el
+		}
+	}
+	return s.lru.Back()
+}
+
+// Stop terminates the janitor and waits for it to exit. Safe to call twice.
+func (m *memoryLimiter) Stop() {
+	m.stopOnce.Do(func() { close(m.stop) })
+	<-m.done
+}
+
+// janitor periodically drops idle buckets until Stop is called.
+func (m *memoryLimiter) janitor(interval time.Duration) {
+	defer close(m.done)
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-m.stop:
+			return
+		case now := <-t.C:
+			if n := m.sweep(now); n > 0 {
+				m.log.Debug("evicted idle buckets", zap.Int("count", n))
+			}
+		}
+	}
 }
 
-func min(a, b float64) float64 { if a < b { return a }; return b }
-func max(a, b int) int { if a > b { return a }; return b }
\ No newline at end of file
+// sweep removes buckets idle longer than idleTTL and returns how many went.
+// Shards are locked one at a time so Allow on other shards is never blocked.
+func (m *memoryLimiter) sweep(now time.Time) int {
+	evicted := 0
+	for _, s := range m.shards {
+		s.mu.Lock()
+		for el := s.lru.Back(); el != nil; el = s.lru.Back() {
+			if now.Sub(el.Value.(*bucket).lastRefill) < m.idleTTL {
+				break // the rest of the list is warmer
+			}
+			s.remove(el)
+			evicted++
+		}
+		s.mu.Unlock()
+	}
+	return evicted
+}
+
+// remove unlinks el from the shard; the caller holds s.mu.
+func (s *shard) remove(el *list.Element) {
+	s.lru.Remove(el)
+	delete(s.buckets, el.Value.(*bucket).key)
+}
+
+func min(a, b float64) float64 {
+	if a < b {
+		return a
+	}
+	return b
+}
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,46 @@
+package rate // Prometheus metrics for limiter decisions
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// degradedDecisions counts decisions made without the shared store
+// (Redis down or erroring), labelled by failure mode and cause.
+var degradedDecisions = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "gateway_ratelimit_degraded_decisions_total",
+	Help: "Rate limit decisions taken in degraded mode because Redis was unavailable.",
+}, []string{"mode", "reason", "allowed"})
+
+// decisions counts rate limit outcomes per policy: the limiter strategy
+// (memory|redis|hybrid|none) or access_list for allow/deny list hits.
+var decisions = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "gateway_ratelimit_decisions_total",
+	Help: "Rate limit decisions, by policy and result (allowed|denied).",
+}, []string{"policy", "result"})
+
+// PolicyAccessList labels decisions taken by the AccessList.
+const PolicyAccessList = "access_list"
+
+// CountDecision records one decision for policy.
+func CountDecision(policy string, allowed bool) {
+	result := "denied"
+	if allowed {
+		result = "allowed"
+	}
+	decisions.WithLabelValues(policy, result).Inc()
+}
+
+// Policy returns the metrics label of a limiter's strategy.
+func Policy(l Limiter) string {
+	switch l.(type) {
+	case *memoryLimiter:
+		return "memory"
+	case *redisLimiter:
+		return "redis"
+	case *hybridLimiter:
+		return "hybrid"
+	default:
+		return "none"
+	}
+}
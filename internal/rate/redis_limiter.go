@@ -2,34 +2,64 @@ package rate // Redis-backed fixed-window-ish token bucket
 
 import (
 	"context"
+	"strconv"
 	"time"
 
 	"example.com/api-gateway/internal/redis"
 	"go.uber.org/zap"
 )
 
+// Failure modes for limiters backed by Redis (rate_limit.failure_mode).
+const (
+	FailOpen   = "open"   // allow everything while Redis is down
+	FailClosed = "closed" // deny everything while Redis is down
+	FailLocal  = "local"  // degrade to a per-instance in-memory limiter
+)
+
 // redisLimiter implements Limiter using Redis INCR + TTL.
 type redisLimiter struct {
-	c   redis.Client
-	cfg Cfg
-	log *zap.Logger
+	c        redis.Client
+	cfg      Cfg
+	mon      *redis.Monitor // nil = assume healthy
+	mode     string
+	fallback Limiter // set only in FailLocal mode
+	log      *zap.Logger
 }
 
-// NewRedisLimiter constructs the limiter.
-func NewRedisLimiter(c redis.Client, cfg Cfg, log *zap.Logger) Limiter {
-	return &redisLimiter{c: c, cfg: cfg, log: NewLoggerTagged(log, "redis")}
+// NewRedisLimiter constructs the limiter. mon may be nil; when set, the limiter
+// skips Redis while it is reported down and applies cfg.FailureMode instead.
+func NewRedisLimiter(c redis.Client, cfg Cfg, mon *redis.Monitor, log *zap.Logger) Limiter {
+	r := &redisLimiter{c: c, cfg: cfg, mon: mon, mode: failureMode(cfg), log: NewLoggerTagged(log, "redis")}
+	if r.mode == FailLocal {
+		r.fallback = NewMemoryLimiter(cfg, log)
+	}
+	return r
+}
+
+// failureMode normalizes cfg.FailureMode; unknown values keep the historical fail-open.
+func failureMode(cfg Cfg) string {
+	switch cfg.FailureMode {
+	case FailClosed, FailLocal:
+		return cfg.FailureMode
+	default:
+		return FailOpen
+	}
 }
 
 // Allow uses a per-minute key with burst windowing.
 func (r *redisLimiter) Allow(key string) (bool, time.Duration) {
+	if !r.mon.Healthy() {
+		return r.degraded(key, "unavailable")
+	}
 	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
 	defer cancel()
 
-	bucketKey := "rl:" + key + ":" + time.Now().Format("200601021504") // YYYYMMDDHHmm
+	bucketKey, _ := windowKey(key, time.Now())
 	count, err := r.c.Incr(ctx, bucketKey).Result()
 	if err != nil {
-		r.log.Warn("redis incr fail", zap.Error(err))
-		return true, 0 // fail-open
+		r.log.Warn("redis incr fail", zap.Error(err), zap.String("mode", r.mode))
+		r.mon.MarkDown(err)
+		return r.degraded(key, "error")
 	}
 	if count == 1 {
 		_ = r.c.Expire(ctx, bucketKey, time.Minute).Err()
@@ -44,3 +74,28 @@ func (r *redisLimiter) Allow(key string) (bool, time.Duration) {
 	}
 	return false, ttl
 }
+
+// degraded decides without Redis according to the configured failure mode.
+func (r *redisLimiter) degraded(key, reason string) (bool, time.Duration) {
+	var (
+		ok    bool
+		retry time.Duration
+	)
+	switch r.mode {
+	case FailClosed:
+		ok, retry = false, time.Second
+	case FailLocal:
+		ok, retry = r.fallback.Allow(key)
+	default:
+		ok = true
+	}
+	degradedDecisions.WithLabelValues(r.mode, reason, strconv.FormatBool(ok)).Inc()
+	return ok, retry
+}
+
+// Stop releases the fallback limiter's janitor, if any.
+func (r *redisLimiter) Stop() {
+	if s, ok := r.fallback.(Stopper); ok {
+		s.Stop()
+	}
+}
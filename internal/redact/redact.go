@@ -0,0 +1,224 @@
+// internal/redact/redact.go
+package redact // Masking/hashing of sensitive values before logs are written
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"example.com/api-gateway/config"
+)
+
+// Modes.
+const (
+	ModeMask = "mask" // replace with [REDACTED]
+	ModeHash = "hash" // replace with a stable keyed hash, so equal values still correlate
+)
+
+// Masked is what ModeMask writes.
+const Masked = "[REDACTED]"
+
+// Builtin patterns, enabled by name in redaction.builtin. A pattern with a
+// group named "v" redacts only that group (e.g. keep "password=").
+var Builtin = map[string]string{
+	"email":    `[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`,
+	"bearer":   `(?i)\bbearer\s+(?P<v>[A-Za-z0-9\-._~+/]+=*)`,
+	"jwt":      `\beyJ[A-Za-z0-9_\-]+\.[A-Za-z0-9_\-]+\.[A-Za-z0-9_\-]+`,
+	"password": `(?i)"?\b(?:password|passwd|pwd|secret)"?\s*[:=]\s*(?P<v>"[^"]*"?|[^\s&,;"]+)`, // closing quote optional: truncated bodies
+	"card":     `\b(?:\d[ \-]?){12,18}\d\b`, // confirmed with a Luhn check
+}
+
+// Redactor applies field-name rules and value patterns. A nil *Redactor
+// leaves everything unchanged.
+type Redactor struct {
+	mode     string
+	key      []byte
+	fields   map[string]string // lower-case field name -> mode
+	patterns []pattern
+}
+
+type pattern struct {
+	name string
+	re   *regexp.Regexp
+	mode string
+	luhn bool // only redact digit runs that pass a Luhn check
+}
+
+// New compiles cfg. It returns nil (no redaction) when cfg is disabled.
+func New(cfg config.Redaction) (*Redactor, error) {
+	if !cfg.Enabled {
+		return nil, nil
+	}
+	r := &Redactor{mode: cfg.Mode, key: []byte(cfg.HashKey), fields: map[string]string{}}
+	if r.mode == "" {
+		r.mode = ModeMask
+	}
+	if err := checkMode(r.mode); err != nil {
+		return nil, fmt.Errorf("redaction.mode: %w", err)
+	}
+	for i, f := range cfg.Fields {
+		mode := orMode(f.Mode, r.mode)
+		if err := checkMode(mode); err != nil {
+			return nil, fmt.Errorf("redaction.fields[%d]: %w", i, err)
+		}
+		r.fields[strings.ToLower(f.Name)] = mode
+	}
+	hashing := r.mode == ModeHash
+	for _, f := range r.fields {
+		hashing = hashing || f == ModeHash
+	}
+	for _, p := range cfg.Patterns {
+		hashing = hashing || p.Mode == ModeHash
+	}
+	if hashing && len(r.key) == 0 {
+		return nil, fmt.Errorf("redaction.hash_key: must be set when a rule hashes")
+	}
+	for _, name := range cfg.Builtin {
+		expr, ok := Builtin[name]
+		if !ok {
+			return nil, fmt.Errorf("redaction.builtin: unknown pattern %q", name)
+		}
+		r.patterns = append(r.patterns, pattern{name: name, re: regexp.MustCompile(expr), mode: r.mode, luhn: name == "card"})
+	}
+	for i, p := range cfg.Patterns {
+		re, err := regexp.Compile(p.Regex)
+		if err != nil {
+			return nil, fmt.Errorf("redaction.patterns[%d] (%s): %w", i, p.Name, err)
+		}
+		mode := orMode(p.Mode, r.mode)
+		if err := checkMode(mode); err != nil {
+			return nil, fmt.Errorf("redaction.patterns[%d] (%s): %w", i, p.Name, err)
+		}
+		r.patterns = append(r.patterns, pattern{name: p.Name, re: re, mode: mode})
+	}
+	return r, nil
+}
+
+func checkMode(m string) error {
+	if m != ModeMask && m != ModeHash {
+		return fmt.Errorf("unknown mode %q (want mask|hash)", m)
+	}
+	return nil
+}
+
+func orMode(m, def string) string {
+	if m == "" {
+		return def
+	}
+	return m
+}
+
+// FieldMode reports whether values of field name are redacted entirely.
+func (r *Redactor) FieldMode(name string) (string, bool) {
+	if r == nil {
+		return "", false
+	}
+	m, ok := r.fields[strings.ToLower(name)]
+	return m, ok
+}
+
+// Apply replaces a value with its redacted form.
+func (r *Redactor) Apply(mode, value string) string {
+	if mode == ModeHash {
+		h := hmac.New(sha256.New, r.key)
+		h.Write([]byte(value))
+		return "hash:" + hex.EncodeToString(h.Sum(nil))[:16]
+	}
+	return Masked
+}
+
+// String redacts pattern matches inside s.
+func (r *Redactor) String(s string) string {
+	if r == nil || s == "" {
+		return s
+	}
+	for _, p := range r.patterns {
+		s = r.replace(p, s)
+	}
+	return s
+}
+
+// replace redacts each match of p (or its "v" group) in s.
+func (r *Redactor) replace(p pattern, s string) string {
+	matches := p.re.FindAllStringSubmatchIndex(s, -1)
+	if matches == nil {
+		return s
+	}
+	group := p.re.SubexpIndex("v")
+	var b strings.Builder
+	last := 0
+	for _, m := range matches {
+		start, end := m[0], m[1]
+		if group > 0 && m[2*group] >= 0 {
+			start, end = m[2*group], m[2*group+1]
+		}
+		if p.luhn && !luhn(s[start:end]) {
+			continue
+		}
+		b.WriteString(s[last:start])
+		b.WriteString(r.Apply(p.mode, s[start:end]))
+		last = end
+	}
+	b.WriteString(s[last:])
+	return b.String()
+}
+
+// Value redacts one named value: the whole value when the name is listed,
+// otherwise pattern matches in strings, recursing into maps and slices.
+func (r *Redactor) Value(name string, v any) any {
+	if r == nil || v == nil {
+		return v
+	}
+	if mode, ok := r.FieldMode(name); ok {
+		return r.Apply(mode, fmt.Sprint(v))
+	}
+	switch x := v.(type) {
+	case string:
+		return r.String(x)
+	case map[string]any:
+		return r.Map(x)
+	case []any:
+		out := make([]any, len(x))
+		for i, e := range x {
+			out[i] = r.Value(name, e)
+		}
+		return out
+	default:
+		return v
+	}
+}
+
+// Map returns a redacted copy of m.
+func (r *Redactor) Map(m map[string]any) map[string]any {
+	if r == nil || m == nil {
+		return m
+	}
+	out := make(map[string]any, len(m))
+	for k, v := range m {
+		out[k] = r.Value(k, v)
+	}
+	return out
+}
+
+// luhn validates card-like digit runs (spaces and dashes ignored).
+func luhn(s string) bool {
+	sum, n := 0, 0
+	for i := len(s) - 1; i >= 0; i-- {
+		c := s[i]
+		if c == ' ' || c == '-' {
+			continue
+		}
+		d := int(c - '0')
+		if n%2 == 1 {
+			if d *= 2; d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		n++
+	}
+	return n >= 13 && sum%10 == 0
+}
@@ -0,0 +1,276 @@
+// internal/redis/archive.go
+package redis // Export of expiring per-day log lists to gzipped NDJSON
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"example.com/api-gateway/config"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	rds "github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+// archiveLists counts day lists exported by the Archiver; result is archived|failed.
+var archiveLists = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "gateway_log_archive_lists_total",
+	Help: "Per-day Redis log lists exported to archive files, by outcome.",
+}, []string{"result"})
+
+const (
+	archiveSuffix = ".ndjson.gz"
+	archiveChunk  = 1000
+)
+
+// ErrBadDay is returned for a day that is not YYYY-MM-DD.
+var ErrBadDay = errors.New("day must be YYYY-MM-DD")
+
+// ArchiveFile describes one archived day list.
+type ArchiveFile struct {
+	Day   string `json:"day"`             // YYYY-MM-DD
+	Class string `json:"class,omitempty"` // retention class ("" = default)
+	Name  string `json:"name"`
+	Bytes int64  `json:"bytes"`
+}
+
+// Archiver periodically exports the per-day lists that are about to expire
+// (TTL within the configured window) to <dir>/<day>[.<class>].ndjson.gz,
+// oldest entry first. A list is exported once; existing files are kept.
+type Archiver struct {
+	store    *LogStore
+	dir      string
+	interval time.Duration
+	ahead    time.Duration
+	keepDays int
+	log      *zap.Logger
+
+	stop     chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+// NewArchiver builds an archiver from cfg (zero values get defaults);
+// it returns nil when cfg.Dir is empty.
+func NewArchiver(store *LogStore, cfg config.LogArchive, log *zap.Logger) *Archiver {
+	if cfg.Dir == "" {
+		return nil
+	}
+	return &Archiver{
+		store:    store,
+		dir:      cfg.Dir,
+		interval: time.Duration(positive(cfg.IntervalMin, 60)) * time.Minute,
+		ahead:    time.Duration(positive(cfg.AheadHours, 24)) * time.Hour,
+		keepDays: cfg.KeepDays,
+		log:      log,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+}
+
+// Start runs an archival pass now and then every interval until Stop.
+func (a *Archiver) Start() {
+	go func() {
+		defer close(a.done)
+		t := time.NewTicker(a.interval)
+		defer t.Stop()
+		for {
+			if _, err := a.RunOnce(context.Background()); err != nil {
+				a.log.Warn("log archival pass failed", zap.Error(err))
+			}
+			select {
+			case <-a.stop:
+				return
+			case <-t.C:
+			}
+		}
+	}()
+}
+
+// Stop ends the loop, waiting for a running pass to finish.
+func (a *Archiver) Stop() {
+	a.stopOnce.Do(func() { close(a.stop) })
+	<-a.done
+}
+
+// RunOnce exports every past day list expiring within the window and not
+// archived yet, then prunes old archive files. It returns how many lists
+// were exported.
+func (a *Archiver) RunOnce(ctx context.Context) (int, error) {
+	if err := os.MkdirAll(a.dir, 0o755); err != nil {
+		return 0, err
+	}
+	ret := a.store.ret
+	classes := ret.classes()
+	today := truncateDay(time.Now())
+
+	// One pipelined TTL per (day, class) over the longest lifetime.
+	type target struct {
+		key, path string
+	}
+	var targets []target
+	pipe := a.store.c.Pipeline()
+	var ttls []*rds.DurationCmd
+	for d := 1; d <= ret.maxDays()+1; d++ {
+		day := today.AddDate(0, 0, -d)
+		for _, class := range classes {
+			key := keyDailyClass(day, class)
+			targets = append(targets, target{key: key, path: a.path(day.Format("2006-01-02"), class)})
+			ttls = append(ttls, pipe.TTL(ctx, key))
+		}
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, err
+	}
+
+	n := 0
+	var errs []error
+	for i, t := range targets {
+		ttl, err := ttls[i].Result()
+		if err != nil || ttl <= 0 || ttl > a.ahead { // missing (-2), no expiry (-1) or not due
+			continue
+		}
+		if _, err := os.Stat(t.path); err == nil {
+			continue // already archived
+		}
+		if err := a.export(ctx, t.key, t.path); err != nil {
+			archiveLists.WithLabelValues("failed").Inc()
+			errs = append(errs, fmt.Errorf("%s: %w", t.key, err))
+			continue
+		}
+		archiveLists.WithLabelValues("archived").Inc()
+		a.log.Info("log list archived", zap.String("key", t.key), zap.String("file", t.path))
+		n++
+	}
+	if err := a.prune(today); err != nil {
+		errs = append(errs, err)
+	}
+	return n, errors.Join(errs...)
+}
+
+// path returns the archive file of a day and class.
+func (a *Archiver) path(day, class string) string {
+	name := day
+	if class != "" {
+		name += "." + class
+	}
+	return filepath.Join(a.dir, name+archiveSuffix)
+}
+
+// export writes key oldest first into path (via a temporary file, so a
+// crash never leaves a partial archive that would be skipped later).
+func (a *Archiver) export(ctx context.Context, key, path string) error {
+	total, err := a.store.c.LLen(ctx, key).Result()
+	if err != nil {
+		return err
+	}
+	tmp := path + ".part"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp) // no-op after the rename
+	bw := bufio.NewWriter(f)
+	zw := gzip.NewWriter(bw)
+
+	// Lists are newest first: walk chunks from the tail.
+	for end := total - 1; end >= 0; end -= archiveChunk {
+		start := max(0, end-archiveChunk+1)
+		raws, err := a.store.c.LRange(ctx, key, start, end).Result()
+		if err != nil {
+			f.Close()
+			return err
+		}
+		for i := len(raws) - 1; i >= 0; i-- {
+			if _, err := zw.Write([]byte(raws[i] + "\n")); err != nil {
+				f.Close()
+				return err
+			}
+		}
+	}
+	if err := zw.Close(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := bw.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// prune deletes archive files older than keepDays (0 = keep forever).
+func (a *Archiver) prune(today time.Time) error {
+	if a.keepDays <= 0 {
+		return nil
+	}
+	files, err := a.Files("")
+	if err != nil {
+		return err
+	}
+	cutoff := today.AddDate(0, 0, -a.keepDays).Format("2006-01-02")
+	for _, f := range files {
+		if f.Day < cutoff {
+			if err := os.Remove(filepath.Join(a.dir, f.Name)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Files lists the archive files of day ("" = all days), oldest day first.
+func (a *Archiver) Files(day string) ([]ArchiveFile, error) {
+	if day != "" {
+		if _, err := time.Parse("2006-01-02", day); err != nil {
+			return nil, ErrBadDay
+		}
+	}
+	entries, err := os.ReadDir(a.dir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var out []ArchiveFile
+	for _, e := range entries {
+		base, ok := strings.CutSuffix(e.Name(), archiveSuffix)
+		if !ok || e.IsDir() {
+			continue
+		}
+		d, class, _ := strings.Cut(base, ".")
+		if _, err := time.Parse("2006-01-02", d); err != nil || (day != "" && d != day) {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		out = append(out, ArchiveFile{Day: d, Class: class, Name: e.Name(), Bytes: info.Size()})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Day != out[j].Day {
+			return out[i].Day < out[j].Day
+		}
+		return out[i].Class < out[j].Class
+	})
+	return out, nil
+}
+
+// Open opens an archive file listed by Files.
+func (a *Archiver) Open(f ArchiveFile) (*os.File, error) {
+	return os.Open(filepath.Join(a.dir, filepath.Base(f.Name)))
+}
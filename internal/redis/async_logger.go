@@ -0,0 +1,282 @@
+// internal/redis/async_logger.go
+package redis // Background, batched log writer with metrics and disk spillover
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"example.com/api-gateway/config"
+	rds "github.com/redis/go-redis/v9"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Metrics for the async writer; result is saved|dropped|failed|spilled|replayed.
+var (
+	asyncEntries = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "gateway_log_async_entries_total",
+		Help: "Log entries handled by the async Redis writer, by outcome.",
+	}, []string{"result"})
+	asyncQueueDepth = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "gateway_log_async_queue_depth",
+		Help: "Entries waiting in the async Redis writer queue.",
+	})
+)
+
+// Writer backoff while Redis keeps failing: batches go straight to the
+// spill file (or are counted failed) instead of waiting on timeouts.
+const (
+	asyncSaveTimeout = 2 * time.Second
+	asyncMinBackoff  = time.Second
+	asyncMaxBackoff  = 30 * time.Second
+)
+
+// AsyncStats is a snapshot of AsyncLogger counters.
+type AsyncStats struct {
+	Queued   int   `json:"queued"`
+	Saved    int64 `json:"saved"`
+	Dropped  int64 `json:"dropped"`  // queue full, spill full, or Stop deadline hit
+	Failed   int64 `json:"failed"`   // Redis write failed and nothing to spill to
+	Spilled  int64 `json:"spilled"`  // written to the spill file
+	Replayed int64 `json:"replayed"` // moved from the spill file to Redis
+}
+
+// AsyncLogger provides a non-blocking queue that flushes logs to Redis in background.
+// Entries are written in pipelined batches. When Redis is unavailable they
+// are appended to an optional spill file and replayed once it recovers.
+type AsyncLogger struct {
+	store    *LogStore
+	ch       chan LogEntry
+	batch    int
+	interval time.Duration
+	spill    *spillFile // nil when spill_path is empty
+
+	mu      sync.RWMutex // guards stopped against Enqueue racing Stop
+	stopped bool
+	abort   chan struct{} // closed when Stop's deadline passes
+	closed  chan struct{}
+
+	saved, dropped, failed, spilled, replayed atomic.Int64
+
+	// worker-only state
+	downUntil time.Time
+	backoff   time.Duration
+}
+
+// NewAsyncLogger creates an async logger from cfg (zero values get defaults).
+// It fails only when the spill file cannot be opened.
+func NewAsyncLogger(store *LogStore, cfg config.AsyncLog) (*AsyncLogger, error) {
+	a := &AsyncLogger{
+		store:    store,
+		ch:       make(chan LogEntry, positive(cfg.Buffer, 2048)),
+		batch:    positive(cfg.BatchSize, 100),
+		interval: time.Duration(positive(cfg.FlushIntervalMS, 200)) * time.Millisecond,
+		abort:    make(chan struct{}),
+		closed:   make(chan struct{}),
+	}
+	if cfg.SpillPath != "" {
+		sp, err := openSpill(cfg.SpillPath, int64(positive(cfg.SpillMaxMB, 64))<<20)
+		if err != nil {
+			return nil, err
+		}
+		a.spill = sp
+	}
+	return a, nil
+}
+
+func positive(v, def int) int {
+	if v <= 0 {
+		return def
+	}
+	return v
+}
+
+// Start launches the background worker.
+func (a *AsyncLogger) Start() {
+	go func() {
+		defer close(a.closed)
+		a.run()
+	}()
+}
+
+// Stop closes the queue and waits for the worker to save what is queued.
+// If ctx ends first, the remainder goes to the spill file (or is counted as
+// dropped) without further Redis calls. Stop returns ctx.Err() in that case.
+func (a *AsyncLogger) Stop(ctx context.Context) error {
+	a.mu.Lock()
+	if a.stopped {
+		a.mu.Unlock()
+		<-a.closed
+		return nil
+	}
+	a.stopped = true
+	close(a.ch)
+	a.mu.Unlock()
+
+	select {
+	case <-a.closed:
+		return nil
+	case <-ctx.Done():
+		close(a.abort)
+		<-a.closed
+		return ctx.Err()
+	}
+}
+
+// Enqueue adds a log entry to the background queue (non-blocking).
+// When the queue is full the entry is dropped and counted.
+func (a *AsyncLogger) Enqueue(e LogEntry) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	if a.stopped {
+		a.drop(1)
+		return
+	}
+	select {
+	case a.ch <- e:
+	default:
+		a.drop(1) // queue full, drop to remain non-blocking
+	}
+}
+
+// Stats returns current counters.
+func (a *AsyncLogger) Stats() AsyncStats {
+	return AsyncStats{
+		Queued:   len(a.ch),
+		Saved:    a.saved.Load(),
+		Dropped:  a.dropped.Load(),
+		Failed:   a.failed.Load(),
+		Spilled:  a.spilled.Load(),
+		Replayed: a.replayed.Load(),
+	}
+}
+
+// run collects batches by size or interval until the queue is closed.
+func (a *AsyncLogger) run() {
+	buf := make([]LogEntry, 0, a.batch)
+	t := time.NewTicker(a.interval)
+	defer t.Stop()
+	flush := func() {
+		asyncQueueDepth.Set(float64(len(a.ch)))
+		if len(buf) > 0 {
+			a.write(buf)
+			buf = buf[:0]
+		}
+	}
+	for {
+		select {
+		case e, ok := <-a.ch:
+			if !ok {
+				flush()
+				if !a.aborted() {
+					a.replay() // leave nothing on disk we could have saved
+				}
+				if a.spill != nil {
+					a.spill.close()
+				}
+				return
+			}
+			buf = append(buf, e)
+			if len(buf) >= a.batch {
+				flush()
+			}
+		case <-t.C:
+			flush()
+			a.replay()
+		}
+	}
+}
+
+// write saves one batch, spilling it when Redis is down or failing.
+func (a *AsyncLogger) write(batch []LogEntry) {
+	if a.aborted() || time.Now().Before(a.downUntil) {
+		a.spillOrFail(batch)
+		return
+	}
+	if err := a.save(batch); err != nil {
+		a.markDown()
+		a.spillOrFail(batch)
+		return
+	}
+	a.markUp()
+	a.saved.Add(int64(len(batch)))
+	asyncEntries.WithLabelValues("saved").Add(float64(len(batch)))
+}
+
+// save pipelines the whole batch in one round trip.
+func (a *AsyncLogger) save(batch []LogEntry) error {
+	ctx, cancel := context.WithTimeout(context.Background(), asyncSaveTimeout)
+	defer cancel()
+	go func() { // Stop deadline cancels an in-flight write
+		select {
+		case <-a.abort:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	_, err := a.store.c.Pipelined(ctx, func(p rds.Pipeliner) error {
+		for _, e := range batch {
+			if err := a.store.queueSave(ctx, p, e); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return err
+}
+
+// replay moves spilled entries back to Redis while it is reachable.
+func (a *AsyncLogger) replay() {
+	if a.spill == nil || !a.spill.pending() || a.aborted() || time.Now().Before(a.downUntil) {
+		return
+	}
+	n, err := a.spill.drain(a.batch, a.save)
+	a.replayed.Add(int64(n))
+	asyncEntries.WithLabelValues("replayed").Add(float64(n))
+	if err != nil {
+		a.markDown()
+	}
+}
+
+// spillOrFail appends batch to the spill file, or counts it as failed.
+func (a *AsyncLogger) spillOrFail(batch []LogEntry) {
+	if a.spill == nil {
+		a.failed.Add(int64(len(batch)))
+		asyncEntries.WithLabelValues("failed").Add(float64(len(batch)))
+		return
+	}
+	n := a.spill.append(batch)
+	a.spilled.Add(int64(n))
+	asyncEntries.WithLabelValues("spilled").Add(float64(n))
+	a.drop(len(batch) - n) // spill file full or unwritable
+}
+
+func (a *AsyncLogger) drop(n int) {
+	if n > 0 {
+		a.dropped.Add(int64(n))
+		asyncEntries.WithLabelValues("dropped").Add(float64(n))
+	}
+}
+
+// markDown doubles the backoff (1s..30s) before Redis is tried again.
+func (a *AsyncLogger) markDown() {
+	if a.backoff == 0 {
+		a.backoff = asyncMinBackoff
+	} else if a.backoff *= 2; a.backoff > asyncMaxBackoff {
+		a.backoff = asyncMaxBackoff
+	}
+	a.downUntil = time.Now().Add(a.backoff)
+}
+
+func (a *AsyncLogger) markUp() { a.backoff, a.downUntil = 0, time.Time{} }
+
+func (a *AsyncLogger) aborted() bool {
+	select {
+	case <-a.abort:
+		return true
+	default:
+		return false
+	}
+}
@@ -5,10 +5,10 @@ package redis // Client init for standalone or sentinel
 import (
 	"context"
 	"crypto/tls"
-	"fmt"
 	"time"
 
 	"example.com/api-gateway/config"
+	"example.com/api-gateway/internal/tracing"
 	redis "github.com/redis/go-redis/v9"
 	"go.uber.org/zap"
 )
@@ -16,31 +16,41 @@ import (
 // Client is a thin alias to decouple imports.
 type Client = redis.UniversalClient
 
-// NewClient returns a universal client supporting standalone or sentinel.
-func NewClient(cfg config.Redis, log *zap.Logger) (Client, error) {
+// Nil is the error returned when a key does not exist.
+const Nil = redis.Nil
+
+// Dial returns a universal client supporting standalone or sentinel without
+// contacting the server; go-redis connects lazily and redials on demand.
+func Dial(cfg config.Redis) Client {
 	opt := &redis.UniversalOptions{ // works for single or sentinel
 		Addrs:    cfg.Addresses,
 		DB:       cfg.DB,
 		Username: cfg.Username,
 		Password: cfg.Password,
 	}
-	fmt.Println("============TEST CONNECTION OF REDDIS IF IT READS THE ADDRESS OR NOT =============")
-	fmt.Println(opt.Addrs)
-	fmt.Println("=======================66666666666666666666666666==")
 	if cfg.Mode == "sentinel" {
 		opt.MasterName = cfg.MasterName
 	}
 	if cfg.TLS {
 		opt.TLSConfig = &tls.Config{MinVersion: tls.VersionTLS12}
 	}
-	client := redis.NewUniversalClient(opt)
+	c := redis.NewUniversalClient(opt)
+	c.AddHook(tracing.RedisHook()) // spans for commands issued within a trace
+	return c
+}
+
+// NewClient dials Redis and runs a quick health check.
+// The client is returned even when the ping fails, so callers that treat
+// Redis as optional can keep it and let it reconnect once Redis is back.
+func NewClient(cfg config.Redis, log *zap.Logger) (Client, error) {
+	client := Dial(cfg)
 
 	// Quick health check with timeout (v9 requires you to pass a context)
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 	if _, err := client.Ping(ctx).Result(); err != nil {
 		log.Error("redis ping failed", zap.Error(err))
-		return nil, err
+		return client, err
 	}
 
 	log.Info("redis connected", zap.Strings("addrs", cfg.Addresses), zap.String("mode", cfg.Mode))
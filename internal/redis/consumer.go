@@ -0,0 +1,165 @@
+// internal/redis/consumer.go
+package redis // Consumer-group readers for the log stream
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	rds "github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+// HandleFunc processes a batch of stream entries. Returning nil acknowledges
+// the whole batch; returning an error leaves it pending so it is redelivered
+// (to this or another consumer of the group) after the claim idle time.
+type HandleFunc func(ctx context.Context, batch []LogEntry) error
+
+// Consumer reads the log stream as one member of a consumer group. Several
+// groups (e.g. a file shipper and an alerting worker) each see every entry;
+// consumers within a group split the entries between them.
+type Consumer struct {
+	store     *LogStore
+	group     string
+	name      string
+	handle    HandleFunc
+	batch     int64
+	block     time.Duration
+	claimIdle time.Duration
+	log       *zap.Logger
+}
+
+// NewConsumer builds a consumer; name must be unique within group
+// (e.g. hostname + pid).
+func NewConsumer(store *LogStore, group, name string, handle HandleFunc, log *zap.Logger) *Consumer {
+	return &Consumer{
+		store:     store,
+		group:     group,
+		name:      name,
+		handle:    handle,
+		batch:     100,
+		block:     2 * time.Second,
+		claimIdle: time.Minute,
+		log:       log.With(zap.String("group", group), zap.String("consumer", name)),
+	}
+}
+
+// Run creates the group if needed and consumes until ctx is cancelled.
+// Entries left pending by a crashed consumer are reclaimed after claimIdle.
+func (c *Consumer) Run(ctx context.Context) error {
+	if err := c.ensureGroup(ctx); err != nil {
+		return err
+	}
+	lastClaim := time.Time{}
+	for ctx.Err() == nil {
+		if time.Since(lastClaim) >= c.claimIdle {
+			c.reclaim(ctx)
+			lastClaim = time.Now()
+		}
+		streams, err := c.store.c.XReadGroup(ctx, &rds.XReadGroupArgs{
+			Group:    c.group,
+			Consumer: c.name,
+			Streams:  []string{c.store.stream, ">"},
+			Count:    c.batch,
+			Block:    c.block,
+		}).Result()
+		if err != nil {
+			if errors.Is(err, rds.Nil) || ctx.Err() != nil {
+				continue // no new entries within block / shutting down
+			}
+			c.log.Warn("stream read failed", zap.Error(err))
+			sleepCtx(ctx, time.Second)
+			continue
+		}
+		for _, st := range streams {
+			c.process(ctx, st.Messages)
+		}
+	}
+	return nil
+}
+
+// ensureGroup creates the consumer group (and the stream) if missing.
+// New groups start at the end of the stream ("$").
+func (c *Consumer) ensureGroup(ctx context.Context) error {
+	err := c.store.c.XGroupCreateMkStream(ctx, c.store.stream, c.group, "$").Err()
+	if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		return err
+	}
+	return nil
+}
+
+// reclaim takes over entries that stayed unacknowledged for claimIdle.
+func (c *Consumer) reclaim(ctx context.Context) {
+	start := "0-0"
+	for {
+		msgs, next, err := c.store.c.XAutoClaim(ctx, &rds.XAutoClaimArgs{
+			Stream:   c.store.stream,
+			Group:    c.group,
+			Consumer: c.name,
+			MinIdle:  c.claimIdle,
+			Start:    start,
+			Count:    c.batch,
+		}).Result()
+		if err != nil {
+			c.log.Debug("stream reclaim failed", zap.Error(err))
+			return
+		}
+		if len(msgs) > 0 {
+			c.process(ctx, msgs)
+		}
+		if next == "0-0" || len(msgs) == 0 {
+			return
+		}
+		start = next
+	}
+}
+
+// process decodes, handles and acknowledges one batch.
+func (c *Consumer) process(ctx context.Context, msgs []rds.XMessage) {
+	ids := make([]string, 0, len(msgs))
+	batch := make([]LogEntry, 0, len(msgs))
+	for _, m := range msgs {
+		ids = append(ids, m.ID)
+		if e, ok := decodeMessage(m); ok {
+			batch = append(batch, e)
+		}
+	}
+	if err := c.handle(ctx, batch); err != nil {
+		c.log.Warn("stream handler failed, batch left pending", zap.Error(err), zap.Int("entries", len(batch)))
+		return
+	}
+	if err := c.store.c.XAck(ctx, c.store.stream, c.group, ids...).Err(); err != nil {
+		c.log.Warn("stream ack failed", zap.Error(err))
+	}
+}
+
+// NDJSONWriter returns a HandleFunc that appends each entry as one JSON line
+// to w (e.g. a lumberjack file). Writes are serialized.
+func NDJSONWriter(w io.Writer) HandleFunc {
+	var mu sync.Mutex
+	return func(_ context.Context, batch []LogEntry) error {
+		mu.Lock()
+		defer mu.Unlock()
+		enc := json.NewEncoder(w)
+		for _, e := range batch {
+			if err := enc.Encode(e); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// sleepCtx waits for d or until ctx is done.
+func sleepCtx(ctx context.Context, d time.Duration) {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+	case <-t.C:
+	}
+}
@@ -0,0 +1,387 @@
+// internal/redis/log_query.go
+package redis // Filtered, cursor-paged reads over the log stream and daily lists
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxScan bounds how many stored entries one query inspects, so a selective
+// filter can't turn a request into a full scan. When the budget runs out the
+// page is returned short with a cursor to continue from.
+const maxScan = 5000
+
+// dayCursorPrefix marks cursors that point into the per-day lists.
+const dayCursorPrefix = "d:"
+
+// LogFilter selects log entries; zero fields match everything.
+type LogFilter struct {
+	Level       string    // exact level, case-insensitive (INFO, ERROR, ...)
+	From        time.Time // inclusive lower bound on Timestamp (whole seconds, like Timestamp)
+	To          time.Time // inclusive upper bound on Timestamp, through the end of its second
+	RequestID   string    // context.requestId
+	UserID      string    // context.userID
+	PathPrefix  string    // context.path prefix
+	StatusClass int       // 2 for 2xx, 4 for 4xx, ...; 0 = any
+	Text        string    // case-insensitive substring of Message
+}
+
+// IsZero reports whether the filter matches everything.
+func (f LogFilter) IsZero() bool { return f == LogFilter{} }
+
+// Match reports whether e satisfies every set field of f.
+func (f LogFilter) Match(e LogEntry) bool {
+	if f.Level != "" && !strings.EqualFold(e.Level, f.Level) {
+		return false
+	}
+	if !f.From.IsZero() || !f.To.IsZero() {
+		ts, err := time.Parse(time.RFC3339, e.Timestamp)
+		if err != nil || (!f.From.IsZero() && ts.Before(f.From.Truncate(time.Second))) ||
+			(!f.To.IsZero() && ts.After(f.To.Truncate(time.Second))) {
+			return false
+		}
+	}
+	if f.RequestID != "" && ctxString(e, "requestId") != f.RequestID {
+		return false
+	}
+	if f.UserID != "" && ctxString(e, "userID") != f.UserID {
+		return false
+	}
+	if f.PathPrefix != "" && !strings.HasPrefix(ctxString(e, "path"), f.PathPrefix) {
+		return false
+	}
+	if f.StatusClass != 0 {
+		status, ok := e.Context["status"].(float64) // JSON numbers decode as float64
+		if !ok {
+			if i, isInt := e.Context["status"].(int); isInt { // entries not round-tripped through JSON
+				status, ok = float64(i), true
+			}
+		}
+		if !ok || int(status)/100 != f.StatusClass {
+			return false
+		}
+	}
+	if f.Text != "" && !strings.Contains(strings.ToLower(e.Message), strings.ToLower(f.Text)) {
+		return false
+	}
+	return true
+}
+
+// ctxString returns Context[key] when it is a string.
+func ctxString(e LogEntry, key string) string {
+	s, _ := e.Context[key].(string)
+	return s
+}
+
+// Query returns up to limit entries matching f, newest first, plus a cursor
+// for the next page ("" when done). Cursors of the unfiltered listing
+// (LoadRecent's legacy ones) and malformed ones yield ErrBadCursor.
+//
+// Recent data comes from the stream, whose IDs are millisecond timestamps,
+// so From/To become an ID range covering their whole seconds (Timestamp
+// has second precision; Match compares the same way). When From reaches back past the oldest
+// stream entry the query is historical and reads the per-day lists instead
+// (they hold the same entries, for longer).
+//
+// Stream IDs are write times. Entries the AsyncLogger replays from its
+// spill file after an outage keep their original Timestamp but get a
+// replay-time ID, so a stream-served From/To range does not find them; a
+// range starting before the oldest stream entry reads the per-day lists,
+// which file them under their Timestamp's day.
+func (s *LogStore) Query(ctx context.Context, f LogFilter, limit int, cursor string) ([]LogEntry, string, error) {
+	if limit <= 0 {
+		limit = 100
+	}
+	if strings.HasPrefix(cursor, dayCursorPrefix) {
+		return s.queryDaily(ctx, f, limit, cursor)
+	}
+	if strings.HasPrefix(cursor, legacyCursorPrefix) {
+		return nil, "", fmt.Errorf("%w %q: it pages the unfiltered listing, start the filtered query without it", ErrBadCursor, cursor)
+	}
+	if err := checkStreamCursor(cursor); err != nil {
+		return nil, "", err
+	}
+	if cursor == "" && !f.From.IsZero() {
+		historical, err := s.beforeStream(ctx, f.From)
+		if err != nil {
+			return nil, "", err
+		}
+		if historical {
+			return s.queryDaily(ctx, f, limit, "")
+		}
+	}
+	return s.queryStream(ctx, f, limit, cursor)
+}
+
+// beforeStream reports whether t is older than the oldest stream entry.
+func (s *LogStore) beforeStream(ctx context.Context, t time.Time) (bool, error) {
+	oldest, err := s.c.XRangeN(ctx, s.stream, "-", "+", 1).Result()
+	if err != nil {
+		return false, err
+	}
+	if len(oldest) == 0 {
+		return true, nil
+	}
+	ms, _ := strconv.ParseInt(strings.SplitN(oldest[0].ID, "-", 2)[0], 10, 64)
+	return t.UnixMilli() < ms, nil
+}
+
+// queryStream scans the stream backwards in chunks.
+func (s *LogStore) queryStream(ctx context.Context, f LogFilter, limit int, cursor string) ([]LogEntry, string, error) {
+	end, start := "+", "-"
+	if !f.To.IsZero() {
+		end = strconv.FormatInt(f.To.Truncate(time.Second).Add(time.Second-time.Millisecond).UnixMilli(), 10)
+	}
+	if !f.From.IsZero() {
+		start = strconv.FormatInt(f.From.Truncate(time.Second).UnixMilli(), 10)
+	}
+	if cursor != "" {
+		end = "(" + cursor
+	}
+
+	out := []LogEntry{} // grows with matches; limit comes from the caller
+	for scanned := 0; scanned < maxScan; {
+		chunk := int64(min(500, maxScan-scanned))
+		msgs, err := s.c.XRevRangeN(ctx, s.stream, end, start, chunk).Result()
+		if err != nil {
+			return nil, "", err
+		}
+		for _, m := range msgs {
+			scanned++
+			end = "(" + m.ID
+			e, ok := decodeMessage(m)
+			if !ok || !f.Match(e) {
+				continue
+			}
+			out = append(out, e)
+			if len(out) == limit {
+				return out, m.ID, nil
+			}
+		}
+		if int64(len(msgs)) < chunk {
+			return out, "", nil // reached the start of the range
+		}
+	}
+	return out, strings.TrimPrefix(end, "("), nil // budget spent; resume here
+}
+
+// queryDaily walks per-day lists from the newest relevant day back to the
+// day of f.From (or as far as lists can exist when From is open). A day has
+// one list per retention class; they are merged newest first by timestamp.
+// Cursor form: d:YYYY-MM-DD:n1,n2,... (entries of each class list not
+// returned yet, in retention.classes order). Lists only grow at the head
+// (LPUSH), so counting from the tail keeps a cursor valid while today's
+// lists receive new entries.
+func (s *LogStore) queryDaily(ctx context.Context, f LogFilter, limit int, cursor string) ([]LogEntry, string, error) {
+	classes := s.ret.classes()
+	day, left, err := parseDayCursor(cursor, f, len(classes))
+	if err != nil {
+		return nil, "", err
+	}
+	last := day.AddDate(0, 0, -max(31, s.ret.maxDays()))
+	if !f.From.IsZero() {
+		last = truncateDay(f.From)
+	}
+
+	out := []LogEntry{} // grows with matches; limit comes from the caller
+	scanned := 0
+	for scanned < maxScan && !day.Before(last) {
+		m := newDayMerge(s.c, day, classes, left)
+		exhausted := false
+		for scanned < maxScan {
+			e, ok, err := m.next(ctx)
+			if err != nil {
+				return nil, "", err
+			}
+			if !ok {
+				exhausted = true
+				break
+			}
+			scanned++
+			if !e.ok || !f.Match(e.entry) {
+				continue
+			}
+			out = append(out, e.entry)
+			if len(out) == limit {
+				return out, dayCursor(day, m.left), nil
+			}
+		}
+		left = m.left
+		if exhausted && scanned < maxScan { // go one day back
+			day, left = day.AddDate(0, 0, -1), unknownLeft(len(classes))
+		}
+	}
+	if day.Before(last) {
+		return out, "", nil
+	}
+	return out, dayCursor(day, left), nil // budget spent; resume here
+}
+
+// unknownLeft marks every class list as not measured yet (read from its head).
+func unknownLeft(n int) []int {
+	left := make([]int, n)
+	for i := range left {
+		left[i] = -1
+	}
+	return left
+}
+
+// dayItem is one list element; ok is false when it failed to decode.
+type dayItem struct {
+	entry LogEntry
+	ok    bool
+}
+
+// dayMerge reads the class lists of one day in chunks and yields their
+// entries newest first. Lists are LPUSH-ed, so each is already newest first;
+// reads use negative (tail-relative) indices, which later pushes don't shift.
+type dayMerge struct {
+	c     Client
+	keys  []string
+	left  []int       // entries not yet consumed per list; -1 = not measured yet
+	heads [][]dayItem // fetched, not yet consumed
+	done  []bool      // list has no more elements to fetch
+}
+
+const dayMergeChunk = 200
+
+func newDayMerge(c Client, day time.Time, classes []string, left []int) *dayMerge {
+	m := &dayMerge{
+		c:     c,
+		keys:  make([]string, len(classes)),
+		left:  append([]int(nil), left...),
+		heads: make([][]dayItem, len(classes)),
+		done:  make([]bool, len(classes)),
+	}
+	for i, class := range classes {
+		m.keys[i] = keyDailyClass(day, class)
+	}
+	return m
+}
+
+// next returns the newest remaining entry of the day; ok is false when
+// every list is exhausted.
+func (m *dayMerge) next(ctx context.Context) (dayItem, bool, error) {
+	best := -1
+	for i := range m.keys {
+		if len(m.heads[i]) == 0 && !m.done[i] {
+			if err := m.fill(ctx, i); err != nil {
+				return dayItem{}, false, err
+			}
+		}
+		if len(m.heads[i]) == 0 {
+			continue
+		}
+		if best < 0 || m.heads[i][0].entry.Timestamp > m.heads[best][0].entry.Timestamp {
+			best = i // RFC3339 UTC timestamps order lexically
+		}
+	}
+	if best < 0 {
+		return dayItem{}, false, nil
+	}
+	it := m.heads[best][0]
+	m.heads[best] = m.heads[best][1:]
+	m.left[best]--
+	return it, true, nil
+}
+
+// fill fetches the next chunk of list i after what was consumed. A list met
+// for the first time is measured, so entries pushed from then on are not read.
+func (m *dayMerge) fill(ctx context.Context, i int) error {
+	if m.left[i] < 0 {
+		n, err := m.c.LLen(ctx, m.keys[i]).Result()
+		if err != nil {
+			return err
+		}
+		m.left[i] = int(n)
+	}
+	if m.left[i] == 0 {
+		m.done[i] = true
+		return nil
+	}
+	start := -int64(m.left[i])
+	stop := min(start+dayMergeChunk-1, -1)
+	raws, err := m.c.LRange(ctx, m.keys[i], start, stop).Result()
+	if err != nil {
+		return err
+	}
+	if stop == -1 || int64(len(raws)) < stop-start+1 {
+		m.done[i] = true
+	}
+	if len(raws) == 0 {
+		m.left[i] = 0 // the list expired meanwhile
+	}
+	for _, raw := range raws {
+		var it dayItem
+		it.ok = json.Unmarshal([]byte(raw), &it.entry) == nil
+		m.heads[i] = append(m.heads[i], it)
+	}
+	return nil
+}
+
+// parseDayCursor decodes a day cursor into a day and the entries left per
+// class list, or starts at f.To's day (today if open) with nothing measured.
+func parseDayCursor(cursor string, f LogFilter, n int) (time.Time, []int, error) {
+	if cursor == "" {
+		if !f.To.IsZero() {
+			return truncateDay(f.To), unknownLeft(n), nil
+		}
+		return truncateDay(time.Now()), unknownLeft(n), nil
+	}
+	parts := strings.Split(strings.TrimPrefix(cursor, dayCursorPrefix), ":")
+	if len(parts) != 2 {
+		return time.Time{}, nil, fmt.Errorf("%w %q", ErrBadCursor, cursor)
+	}
+	day, err := time.Parse("2006-01-02", parts[0])
+	counts := strings.Split(parts[1], ",")
+	if err != nil || len(counts) != n {
+		return time.Time{}, nil, fmt.Errorf("%w %q", ErrBadCursor, cursor)
+	}
+	left := make([]int, n)
+	for i, p := range counts {
+		if left[i], err = strconv.Atoi(p); err != nil || left[i] < 0 {
+			return time.Time{}, nil, fmt.Errorf("%w %q", ErrBadCursor, cursor)
+		}
+	}
+	return day, left, nil
+}
+
+func dayCursor(day time.Time, left []int) string {
+	parts := make([]string, len(left))
+	for i, n := range left {
+		parts[i] = strconv.Itoa(n)
+	}
+	return dayCursorPrefix + day.Format("2006-01-02") + ":" + strings.Join(parts, ",")
+}
+
+// truncateDay returns midnight UTC of t's day.
+func truncateDay(t time.Time) time.Time {
+	return t.UTC().Truncate(24 * time.Hour)
+}
+
+// ByRequestID returns every entry carrying requestId, oldest first. It scans
+// the recent stream and falls back to the per-day lists of the last two days
+// when the request has already left the stream. Each scan inspects at most
+// maxScan entries; truncated reports that the budget ran out before the
+// range did, so older entries of the request may be missing.
+func (s *LogStore) ByRequestID(ctx context.Context, requestID string) (items []LogEntry, truncated bool, err error) {
+	f := LogFilter{RequestID: requestID}
+	items, next, err := s.queryStream(ctx, f, maxScan, "")
+	if err != nil {
+		return nil, false, err
+	}
+	if len(items) == 0 {
+		f.From = truncateDay(time.Now()).AddDate(0, 0, -1)
+		if items, next, err = s.queryDaily(ctx, f, maxScan, ""); err != nil {
+			return nil, false, err
+		}
+	}
+	for i, j := 0, len(items)-1; i < j; i, j = i+1, j-1 {
+		items[i], items[j] = items[j], items[i]
+	}
+	return items, next != "", nil
+}
@@ -0,0 +1,163 @@
+// internal/redis/log_tail.go
+package redis // Live fan-out of new log stream entries to subscribers
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	rds "github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+// Tailer follows the log stream with a single blocking XREAD and fans new
+// entries out to subscribers. Reading the stream (rather than hooking the
+// local AsyncLogger) means every replica's entries reach every watcher.
+// The stream is only read while at least one subscriber is attached.
+type Tailer struct {
+	store *LogStore
+	log   *zap.Logger
+
+	mu   sync.Mutex
+	subs map[*Subscription]struct{}
+	wake chan struct{} // signalled on first Subscribe
+
+	stop     chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+// Subscription receives entries matching its filter on C. A subscriber that
+// falls behind does not slow the others down: entries that do not fit in its
+// buffer are dropped and counted.
+type Subscription struct {
+	C       <-chan LogEntry
+	ch      chan LogEntry
+	filter  LogFilter
+	dropped atomic.Int64
+}
+
+// Dropped returns and resets the number of entries dropped since the last call.
+func (s *Subscription) Dropped() int64 { return s.dropped.Swap(0) }
+
+// NewTailer builds a tailer for store; call Start to run it.
+func NewTailer(store *LogStore, log *zap.Logger) *Tailer {
+	return &Tailer{
+		store: store,
+		log:   log.Named("log-tail"),
+		subs:  make(map[*Subscription]struct{}),
+		wake:  make(chan struct{}, 1),
+		stop:  make(chan struct{}),
+		done:  make(chan struct{}),
+	}
+}
+
+// Subscribe registers a subscriber with a buffer of size entries.
+func (t *Tailer) Subscribe(f LogFilter, buffer int) *Subscription {
+	if buffer <= 0 {
+		buffer = 256
+	}
+	ch := make(chan LogEntry, buffer)
+	s := &Subscription{C: ch, ch: ch, filter: f}
+	t.mu.Lock()
+	t.subs[s] = struct{}{}
+	t.mu.Unlock()
+	select {
+	case t.wake <- struct{}{}:
+	default:
+	}
+	return s
+}
+
+// Unsubscribe detaches s. Its channel is left open; callers just stop reading.
+func (t *Tailer) Unsubscribe(s *Subscription) {
+	t.mu.Lock()
+	delete(t.subs, s)
+	t.mu.Unlock()
+}
+
+// Start launches the read loop.
+func (t *Tailer) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-t.stop
+		cancel()
+	}()
+	go func() {
+		defer close(t.done)
+		t.run(ctx)
+	}()
+}
+
+// Stop ends the read loop and waits for it.
+func (t *Tailer) Stop() {
+	t.stopOnce.Do(func() { close(t.stop) })
+	<-t.done
+}
+
+// Closing is closed once Stop is called; streams end on it so they do
+// not hold up a graceful shutdown.
+func (t *Tailer) Closing() <-chan struct{} { return t.stop }
+
+// run reads from "$" each time the first subscriber arrives, so idle periods
+// are not replayed, and from the last seen ID while subscribers remain.
+func (t *Tailer) run(ctx context.Context) {
+	last := "$"
+	for ctx.Err() == nil {
+		if t.Subscribers() == 0 {
+			last = "$"
+			select {
+			case <-ctx.Done():
+				return
+			case <-t.wake:
+				continue
+			}
+		}
+		streams, err := t.store.c.XRead(ctx, &rds.XReadArgs{
+			Streams: []string{t.store.stream, last},
+			Count:   100,
+			Block:   2 * time.Second,
+		}).Result()
+		if err != nil {
+			if errors.Is(err, rds.Nil) || ctx.Err() != nil {
+				continue // nothing new within block / shutting down
+			}
+			t.log.Debug("log tail read failed", zap.Error(err))
+			sleepCtx(ctx, time.Second)
+			continue
+		}
+		for _, st := range streams {
+			for _, m := range st.Messages {
+				last = m.ID
+				if e, ok := decodeMessage(m); ok {
+					t.publish(e)
+				}
+			}
+		}
+	}
+}
+
+// publish hands e to every matching subscriber without blocking.
+func (t *Tailer) publish(e LogEntry) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for s := range t.subs {
+		if !s.filter.Match(e) {
+			continue
+		}
+		select {
+		case s.ch <- e:
+		default:
+			s.dropped.Add(1) // slow consumer
+		}
+	}
+}
+
+// Subscribers returns how many subscriptions are attached.
+func (t *Tailer) Subscribers() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return len(t.subs)
+}
@@ -4,9 +4,15 @@ package redis // Structured log saving to Redis + async worker
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
 	"time"
 
-	// rds "github.com/redis/go-redis/v9"
+	"example.com/api-gateway/config"
+	rds "github.com/redis/go-redis/v9"
 )
 
 // Client is declared in client.go as a thin alias:
@@ -14,6 +20,8 @@ import (
 
 // LogEntry is a structured log record that will be saved to Redis.
 type LogEntry struct {
+	// Stream ID (set when read back; empty for legacy list entries)
+	ID        string         `json:"id,omitempty"`
 	// RFC3339 timestamp (UTC)
 	Timestamp string         `json:"ts"`
 	// Upper-case level: INFO | WARN | ERROR, etc.
@@ -24,98 +32,195 @@ type LogEntry struct {
 	Context   map[string]any `json:"context,omitempty"`
 }
 
+// Defaults for LogStore when config fields are empty.
+const (
+	defaultStream       = "logs:stream"
+	defaultStreamMaxLen = 10000
+	legacyCursorPrefix  = "legacy:" // cursor into the pre-stream logs:recent list
+)
+
+// ErrBadCursor is returned for a cursor this kind of listing did not
+// hand out (malformed, or a legacy cursor with filters).
+var ErrBadCursor = errors.New("invalid cursor")
+
+// streamID matches the stream IDs handed out as cursors.
+var streamID = regexp.MustCompile(`^\d+-\d+$`)
+
+// checkStreamCursor rejects a cursor that is neither "" nor a stream ID.
+func checkStreamCursor(cursor string) error {
+	if cursor != "" && !streamID.MatchString(cursor) {
+		return fmt.Errorf("%w %q", ErrBadCursor, cursor)
+	}
+	return nil
+}
+
 // keyDaily returns a per-date Redis key for archival lists.
 // Example: logs:2025-10-21
 func keyDaily(t time.Time) string {
 	return "logs:" + t.UTC().Format("2006-01-02")
 }
 
-// keyRecent returns a rolling list key for fast recent fetch.
+// keyRecent returns the legacy rolling list key. Nothing writes it anymore;
+// it is still read so entries saved before the stream migration stay visible.
 func keyRecent() string {
 	return "logs:recent"
 }
 
-// SaveLog persists a log entry into Redis.
-// It LPUSH-es the entry into both a recent list and also a per-day list
-// and trims the recent list to a reasonable cap.
-func SaveLog(ctx context.Context, c Client, entry LogEntry) error {
+// LogStore saves structured logs into a Redis stream (XADD MAXLEN ~), which
+// consumer groups can read with acknowledgments, plus per-day lists.
+type LogStore struct {
+	c      Client
+	stream string
+	maxLen int64
+	ret    retention
+}
+
+// NewLogStore builds a store from config (zero values get defaults).
+func NewLogStore(c Client, cfg config.LogStore) *LogStore {
+	s := &LogStore{c: c, stream: cfg.Stream, maxLen: cfg.MaxLen, ret: newRetention(cfg.Retention)}
+	if s.stream == "" {
+		s.stream = defaultStream
+	}
+	if s.maxLen <= 0 {
+		s.maxLen = defaultStreamMaxLen
+	}
+	return s
+}
+
+// Client returns the underlying Redis client.
+func (s *LogStore) Client() Client { return s.c }
+
+// Stream returns the stream key.
+func (s *LogStore) Stream() string { return s.stream }
+
+// Save persists a log entry into Redis.
+// It XADDs the entry to the stream (approximately trimmed to maxLen) and
+// LPUSH-es it into the per-day list of its retention class, which expires
+// after the class lifetime (logging.redis.retention, see retention.go).
+func (s *LogStore) Save(ctx context.Context, entry LogEntry) error {
+	pipe := s.c.TxPipeline()
+	if err := s.queueSave(ctx, pipe, entry); err != nil {
+		return err
+	}
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// queueSave adds the commands for one entry to pipe.
+// The day list is chosen by entry.Timestamp (now if it does not parse), so
+// entries replayed from the spill file after an outage land in the day they
+// were logged. A past day's list never outlives that day by more than its
+// class lifetime; entries already beyond it only go to the stream. Stream
+// IDs, by contrast, are assigned at write time: replayed entries sit in the
+// stream under their replay time (see Query).
+func (s *LogStore) queueSave(ctx context.Context, pipe rds.Pipeliner, entry LogEntry) error {
+	entry.ID = "" // assigned by Redis
 	b, err := json.Marshal(entry)
 	if err != nil {
 		return err
 	}
 	now := time.Now().UTC()
-	pipe := c.TxPipeline()
-	pipe.LPush(ctx, keyRecent(), b)
-	pipe.LTrim(ctx, keyRecent(), 0, 2000) // keep last ~2000 entries
-	pipe.LPush(ctx, keyDaily(now), b)
-	pipe.Expire(ctx, keyDaily(now), 7*24*time.Hour) // keep per-day logs ~7 days
-	_, err = pipe.Exec(ctx)
-	return err
+	pipe.XAdd(ctx, &rds.XAddArgs{
+		Stream: s.stream,
+		MaxLen: s.maxLen,
+		Approx: true, // MAXLEN ~ lets Redis trim whole macro nodes cheaply
+		Values: map[string]any{"entry": b},
+	})
+	day := now
+	if ts, err := time.Parse(time.RFC3339, entry.Timestamp); err == nil {
+		day = ts.UTC()
+	}
+	class := s.ret.class(entry)
+	ttl := s.ret.ttl(class)
+	ttl = min(ttl, truncateDay(day).AddDate(0, 0, 1).Add(ttl).Sub(now))
+	if ttl <= 0 {
+		return nil // the day's list has expired already
+	}
+	key := keyDailyClass(day, class)
+	pipe.LPush(ctx, key, b)
+	pipe.Expire(ctx, key, ttl)
+	return nil
 }
 
-// LoadRecentLogs loads up to N most recent logs from Redis (newest first).
-func LoadRecentLogs(ctx context.Context, c Client, limit int) ([]LogEntry, error) {
+// LoadRecent loads up to limit logs, newest first, starting strictly before
+// cursor (a stream ID returned as next by a previous call; "" = newest).
+// When the stream runs out, it continues into the legacy logs:recent list.
+// next is "" when there is nothing more to read. A cursor it did not hand
+// out yields ErrBadCursor.
+func (s *LogStore) LoadRecent(ctx context.Context, limit int, cursor string) (items []LogEntry, next string, err error) {
 	if limit <= 0 {
 		limit = 100
 	}
-	raws, err := c.LRange(ctx, keyRecent(), 0, int64(limit-1)).Result()
+	if strings.HasPrefix(cursor, legacyCursorPrefix) {
+		off, err := strconv.Atoi(strings.TrimPrefix(cursor, legacyCursorPrefix))
+		if err != nil || off < 0 {
+			return nil, "", fmt.Errorf("%w %q", ErrBadCursor, cursor)
+		}
+		return s.loadLegacy(ctx, limit, off)
+	}
+	if err := checkStreamCursor(cursor); err != nil {
+		return nil, "", err
+	}
+
+	end := "+"
+	if cursor != "" {
+		end = "(" + cursor // exclusive range (Redis >= 6.2)
+	}
+	msgs, err := s.c.XRevRangeN(ctx, s.stream, end, "-", int64(limit)).Result()
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
-	out := make([]LogEntry, 0, len(raws))
-	for _, s := range raws {
-		var e LogEntry
-		if err := json.Unmarshal([]byte(s), &e); err == nil {
-			out = append(out, e)
+	items = make([]LogEntry, 0, len(msgs))
+	for _, m := range msgs {
+		if e, ok := decodeMessage(m); ok {
+			items = append(items, e)
 		}
 	}
-	return out, nil
-}
+	if len(msgs) == limit {
+		return items, msgs[len(msgs)-1].ID, nil
+	}
 
-// AsyncLogger provides a non-blocking queue that flushes logs to Redis in background.
-type AsyncLogger struct {
-	c      Client
-	ch     chan LogEntry
-	closed chan struct{}
+	// Stream exhausted: fill the page from the legacy list.
+	legacy, next, err := s.loadLegacy(ctx, limit-len(items), 0)
+	if err != nil {
+		return items, "", nil // legacy data is best-effort
+	}
+	return append(items, legacy...), next, nil
 }
 
-// NewAsyncLogger creates an async logger with a buffered queue.
-func NewAsyncLogger(c Client, buffer int) *AsyncLogger {
-	if buffer <= 0 {
-		buffer = 1024
+// loadLegacy reads the pre-migration logs:recent list from offset.
+func (s *LogStore) loadLegacy(ctx context.Context, limit, offset int) ([]LogEntry, string, error) {
+	raws, err := s.c.LRange(ctx, keyRecent(), int64(offset), int64(offset+limit-1)).Result()
+	if err != nil {
+		return nil, "", err
 	}
-	return &AsyncLogger{
-		c:      c,
-		ch:     make(chan LogEntry, buffer),
-		closed: make(chan struct{}),
+	out := decodeList(raws)
+	next := ""
+	if len(raws) == limit {
+		next = legacyCursorPrefix + strconv.Itoa(offset+limit)
 	}
+	return out, next, nil
 }
 
-// Start launches the background worker.
-func (a *AsyncLogger) Start() {
-	go func() {
-		defer close(a.closed)
-		ctx := context.Background()
-		for e := range a.ch {
-			// Best-effort with small timeout; we do not block request flow.
-			ctxTimeout, cancel := context.WithTimeout(ctx, 500*time.Millisecond)
-			_ = SaveLog(ctxTimeout, a.c, e)
-			cancel()
-		}
-	}()
-}
-
-// Stop closes the queue and waits for the worker to finish.
-func (a *AsyncLogger) Stop() {
-	close(a.ch)
-	<-a.closed
+// decodeMessage turns a stream message into a LogEntry carrying its ID.
+func decodeMessage(m rds.XMessage) (LogEntry, bool) {
+	var e LogEntry
+	raw, _ := m.Values["entry"].(string)
+	if err := json.Unmarshal([]byte(raw), &e); err != nil {
+		return e, false
+	}
+	e.ID = m.ID
+	return e, true
 }
 
-// Enqueue adds a log entry to the background queue (non-blocking).
-func (a *AsyncLogger) Enqueue(e LogEntry) {
-	select {
-	case a.ch <- e:
-	default:
-		// queue full, drop to remain non-blocking
+// decodeList parses JSON list items, skipping malformed ones.
+func decodeList(raws []string) []LogEntry {
+	out := make([]LogEntry, 0, len(raws))
+	for _, s := range raws {
+		var e LogEntry
+		if err := json.Unmarshal([]byte(s), &e); err == nil {
+			out = append(out, e)
+		}
 	}
+	return out
 }
@@ -0,0 +1,119 @@
+// internal/redis/monitor.go
+package redis // Background health tracking for outage/recovery detection
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.uber.org/zap"
+)
+
+// redisUp mirrors Monitor.Healthy for dashboards and alerts.
+var redisUp = promauto.NewGauge(prometheus.GaugeOpts{
+	Name: "gateway_redis_up",
+	Help: "1 when the last Redis health check succeeded, 0 otherwise.",
+})
+
+// Monitor pings Redis periodically and tracks whether it is reachable, so
+// callers can skip Redis entirely during an outage instead of paying a
+// timeout per request, and notice when it comes back.
+type Monitor struct {
+	c        Client
+	interval time.Duration
+	log      *zap.Logger
+	healthy  atomic.Bool
+
+	stop      chan struct{}
+	done      chan struct{}
+	startOnce sync.Once // taken by Start, or by Stop when Start never ran
+	stopOnce  sync.Once
+}
+
+// NewMonitor builds a monitor; initial is the result of the startup ping.
+func NewMonitor(c Client, interval time.Duration, initial bool, log *zap.Logger) *Monitor {
+	if interval <= 0 {
+		interval = 2 * time.Second
+	}
+	m := &Monitor{
+		c:        c,
+		interval: interval,
+		log:      log.With(zap.String("component", "redis-monitor")),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	m.set(initial)
+	return m
+}
+
+// Healthy reports whether Redis answered the last check.
+// A nil Monitor is treated as always healthy.
+func (m *Monitor) Healthy() bool {
+	return m == nil || m.healthy.Load()
+}
+
+// MarkDown records a failure observed by a caller (e.g. a command error)
+// so others stop using Redis right away; the next good ping restores it.
+func (m *Monitor) MarkDown(err error) {
+	if m == nil {
+		return
+	}
+	if m.healthy.Swap(false) {
+		redisUp.Set(0)
+		m.log.Warn("redis marked down", zap.Error(err))
+	}
+}
+
+// Start launches the ping loop. It is a no-op after Stop.
+func (m *Monitor) Start() {
+	m.startOnce.Do(func() {
+		go func() {
+			defer close(m.done)
+			t := time.NewTicker(m.interval)
+			defer t.Stop()
+			for {
+				select {
+				case <-m.stop:
+					return
+				case <-t.C:
+					m.check()
+				}
+			}
+		}()
+	})
+}
+
+// Stop ends the ping loop and waits for it to exit. Without a prior Start
+// there is no loop to wait for. Safe to call twice.
+func (m *Monitor) Stop() {
+	m.stopOnce.Do(func() { close(m.stop) })
+	m.startOnce.Do(func() { close(m.done) })
+	<-m.done
+}
+
+// check pings once and logs state transitions.
+func (m *Monitor) check() {
+	ctx, cancel := context.WithTimeout(context.Background(), m.interval/2)
+	defer cancel()
+	err := m.c.Ping(ctx).Err()
+	was := m.healthy.Load()
+	m.set(err == nil)
+	switch {
+	case err == nil && !was:
+		m.log.Info("redis reconnected")
+	case err != nil && was:
+		m.log.Warn("redis unreachable", zap.Error(err))
+	}
+}
+
+func (m *Monitor) set(ok bool) {
+	m.healthy.Store(ok)
+	if ok {
+		redisUp.Set(1)
+	} else {
+		redisUp.Set(0)
+	}
+}
@@ -0,0 +1,97 @@
+// internal/redis/retention.go
+package redis // Per-class lifetimes of the per-day log lists
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"example.com/api-gateway/config"
+)
+
+// AccessMessage is the message of the HTTP access entries the router
+// enqueues; they form the "access" retention class.
+const AccessMessage = "http"
+
+const (
+	defaultRetentionDays = 7
+	accessClass          = "access"
+)
+
+// retention maps entries to a class and each class to a lifetime. Each
+// class has its own list per day: logs:YYYY-MM-DD for the default class,
+// logs:YYYY-MM-DD:<class> for the others (access, lower-cased levels).
+type retention struct {
+	days       int
+	levels     map[string]int // upper-case level -> days
+	accessDays int
+}
+
+// newRetention applies defaults; unset or non-positive days mean "default".
+func newRetention(cfg config.Retention) retention {
+	r := retention{days: cfg.Days, accessDays: cfg.AccessDays, levels: map[string]int{}}
+	if r.days <= 0 {
+		r.days = defaultRetentionDays
+	}
+	for lvl, d := range cfg.Levels {
+		if d > 0 {
+			r.levels[strings.ToUpper(lvl)] = d
+		}
+	}
+	return r
+}
+
+// class returns the retention class of e ("" = default).
+func (r retention) class(e LogEntry) string {
+	if e.Message == AccessMessage && r.accessDays > 0 {
+		return accessClass
+	}
+	if _, ok := r.levels[strings.ToUpper(e.Level)]; ok {
+		return strings.ToLower(e.Level)
+	}
+	return ""
+}
+
+// ttl is how long a class list lives after its last write.
+func (r retention) ttl(class string) time.Duration {
+	days := r.days
+	switch {
+	case class == accessClass:
+		days = r.accessDays
+	case class != "":
+		days = r.levels[strings.ToUpper(class)]
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// classes lists the configured classes in a stable order: default first,
+// then access, then levels by name. Day cursors index into this order.
+func (r retention) classes() []string {
+	out := []string{""}
+	if r.accessDays > 0 {
+		out = append(out, accessClass)
+	}
+	levels := make([]string, 0, len(r.levels))
+	for lvl := range r.levels {
+		levels = append(levels, strings.ToLower(lvl))
+	}
+	sort.Strings(levels)
+	return append(out, levels...)
+}
+
+// maxDays is the longest lifetime of any class.
+func (r retention) maxDays() int {
+	m := max(r.days, r.accessDays)
+	for _, d := range r.levels {
+		m = max(m, d)
+	}
+	return m
+}
+
+// keyDailyClass returns the list key of class for t's day.
+func keyDailyClass(t time.Time, class string) string {
+	if class == "" {
+		return keyDaily(t)
+	}
+	return keyDaily(t) + ":" + class
+}
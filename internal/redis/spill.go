@@ -0,0 +1,170 @@
+// internal/redis/spill.go
+package redis // On-disk NDJSON buffer for log entries Redis could not take
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// spillFile is an append-only NDJSON file capped at max bytes. Replay
+// renames it to <path>.replay first, so new spills and the replay never
+// share a file; a .replay left by a crash is picked up on the next drain.
+// Delivery is at-least-once: entries saved just before a crash may repeat.
+// Only the AsyncLogger worker goroutine uses it.
+type spillFile struct {
+	path string
+	max  int64
+	size int64
+	f    *os.File
+}
+
+// openSpill opens (or creates) path for appending.
+func openSpill(path string, max int64) (*spillFile, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+	s := &spillFile{path: path, max: max}
+	if err := s.reopen(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *spillFile) reopen() error {
+	f, err := os.OpenFile(s.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	st, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	s.f, s.size = f, st.Size()
+	return nil
+}
+
+func (s *spillFile) replayPath() string { return s.path + ".replay" }
+
+// pending reports whether anything waits to be replayed.
+func (s *spillFile) pending() bool {
+	if s.size > 0 {
+		return true
+	}
+	_, err := os.Stat(s.replayPath())
+	return err == nil
+}
+
+// append writes entries until the cap is reached; it returns how many fit.
+func (s *spillFile) append(batch []LogEntry) int {
+	if s.f == nil {
+		return 0
+	}
+	w := bufio.NewWriter(s.f)
+	n := 0
+	for _, e := range batch {
+		b, err := json.Marshal(e)
+		if err != nil {
+			continue
+		}
+		if s.size+int64(len(b))+1 > s.max {
+			break
+		}
+		w.Write(b)
+		w.WriteByte('\n')
+		s.size += int64(len(b)) + 1
+		n++
+	}
+	if w.Flush() != nil {
+		return 0
+	}
+	return n
+}
+
+// drain feeds spilled entries to save in batches and removes them once
+// saved. On a save error the unsaved rest is kept for the next drain.
+func (s *spillFile) drain(batch int, save func([]LogEntry) error) (int, error) {
+	replay := s.replayPath()
+	if _, err := os.Stat(replay); errors.Is(err, os.ErrNotExist) {
+		if s.size == 0 {
+			return 0, nil
+		}
+		s.f.Close()
+		renameErr := os.Rename(s.path, replay)
+		if err := s.reopen(); err != nil {
+			s.f = nil // spilling disabled until the next successful reopen
+			return 0, err
+		}
+		if renameErr != nil {
+			return 0, renameErr
+		}
+	}
+
+	f, err := os.Open(replay)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	r := bufio.NewReader(f)
+	buf := make([]LogEntry, 0, batch)
+	raw := make([][]byte, 0, batch) // lines of buf, to write back on failure
+	done := 0
+	for {
+		line, readErr := r.ReadBytes('\n')
+		if len(line) > 0 {
+			var e LogEntry
+			if json.Unmarshal(line, &e) == nil {
+				buf = append(buf, e)
+				raw = append(raw, line)
+			}
+		}
+		last := readErr != nil
+		if len(buf) == batch || (last && len(buf) > 0) {
+			if err := save(buf); err != nil {
+				return done, keepRest(replay, raw, r)
+			}
+			done += len(buf)
+			buf, raw = buf[:0], raw[:0]
+		}
+		if last {
+			if readErr != io.EOF {
+				return done, readErr
+			}
+			return done, os.Remove(replay)
+		}
+	}
+}
+
+// keepRest rewrites the replay file as the failed lines plus the unread tail.
+func keepRest(replay string, failed [][]byte, tail io.Reader) error {
+	tmp := replay + ".tmp"
+	out, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	for _, l := range failed {
+		out.Write(l)
+	}
+	if _, err := io.Copy(out, tail); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, replay); err != nil {
+		return err
+	}
+	return errors.New("spill replay interrupted")
+}
+
+// close closes the append handle.
+func (s *spillFile) close() {
+	if s.f != nil {
+		s.f.Close()
+	}
+}
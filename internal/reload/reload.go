@@ -0,0 +1,298 @@
+// internal/reload/reload.go
+package reload // Hot config reload: file watch + SIGHUP, staged apply, status
+
+import (
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"example.com/api-gateway/config"
+	"github.com/fsnotify/fsnotify"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.uber.org/zap"
+)
+
+// reloads counts reload attempts; result is applied|unchanged|rejected.
+var reloads = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "gateway_config_reloads_total",
+	Help: "Configuration reload attempts, by result.",
+}, []string{"result"})
+
+// Outcomes of a reload attempt.
+const (
+	Applied   = "applied"
+	Unchanged = "unchanged"
+	Rejected  = "rejected"
+)
+
+// debounce coalesces the burst of events an editor or a ConfigMap update makes.
+const debounce = 300 * time.Millisecond
+
+// Stage applies one reloadable part of the config. Prepare runs only when
+// a field under one of Paths changed; it validates next and builds what
+// the returned commit swaps in. If any stage fails, no stage commits and
+// the running config is kept.
+type Stage struct {
+	Name    string
+	Paths   []string // YAML path prefixes, e.g. "server.cors", "logging.level"
+	Prepare func(prev, next config.Root) (commit func(), err error)
+}
+
+// Result describes one reload attempt.
+type Result struct {
+	At              time.Time `json:"at"`
+	Trigger         string    `json:"trigger"` // file|sighup|refresh
+	Outcome         string    `json:"outcome"` // applied|unchanged|rejected
+	Error           string    `json:"error,omitempty"`
+	Applied         []string  `json:"applied,omitempty"`         // paths now live
+	RestartRequired []string  `json:"restartRequired,omitempty"` // changed in the file, ignored until restart
+}
+
+// Status is the body of GET /api/config/status.
+type Status struct {
+	Files    []string  `json:"files"` // watched layers, base first
+	Version  int       `json:"version"` // applied reloads since start
+	LoadedAt time.Time `json:"loadedAt"`
+	Last     *Result   `json:"last,omitempty"`
+	// Reloadable lists the path prefixes that are swapped without a restart.
+	Reloadable []string `json:"reloadable"`
+}
+
+// Reloader re-reads the config files when one changes or on SIGHUP and
+// applies the reloadable differences through its stages. Reloads are
+// serialized; everything else keeps its startup value and is reported as
+// requiring a restart.
+type Reloader struct {
+	files  []string
+	load   func() (config.Root, error)
+	log    *zap.Logger
+	stages []Stage
+	every  time.Duration // periodic refresh, 0 = off
+
+	mu      sync.Mutex // serializes Reload
+	running config.Root
+
+	smu    sync.RWMutex
+	status Status
+
+	stop     chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+// New builds a reloader watching files (the layers load reads, e.g.
+// config.Sources.Paths), currently running cfg.
+func New(files []string, cfg config.Root, load func() (config.Root, error), log *zap.Logger) *Reloader {
+	return &Reloader{
+		files:   files,
+		load:    load,
+		log:     log,
+		running: cfg,
+		status:  Status{Files: files, LoadedAt: time.Now(), Reloadable: []string{}},
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+}
+
+// Register adds a stage; call before Start.
+func (r *Reloader) Register(s Stage) {
+	r.stages = append(r.stages, s)
+	r.smu.Lock()
+	r.status.Reloadable = append(r.status.Reloadable, s.Paths...)
+	r.smu.Unlock()
+}
+
+// RefreshEvery makes Start also reload every d, so secrets rotated behind
+// file:, env: or enc: references (the JWT secret) apply without a file
+// change. d <= 0 turns it off; call before Start.
+func (r *Reloader) RefreshEvery(d time.Duration) { r.every = d }
+
+// Status returns the outcome of the last attempt.
+func (r *Reloader) Status() Status {
+	r.smu.RLock()
+	defer r.smu.RUnlock()
+	st := r.status
+	st.Files = append([]string(nil), st.Files...)
+	st.Reloadable = append([]string(nil), st.Reloadable...)
+	return st
+}
+
+// Reload loads the config and applies what changed. trigger is recorded in
+// the status ("file", "sighup", ...).
+func (r *Reloader) Reload(trigger string) Result {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	res := r.apply(trigger)
+	reloads.WithLabelValues(res.Outcome).Inc()
+	fields := []zap.Field{zap.String("trigger", trigger), zap.Strings("applied", res.Applied)}
+	if len(res.RestartRequired) > 0 {
+		fields = append(fields, zap.Strings("restart_required", res.RestartRequired))
+	}
+	// a refresh that found nothing new is routine: debug only, not recorded
+	if trigger == "refresh" && res.Outcome == Unchanged {
+		r.log.Debug("config refresh: nothing new", fields...)
+		return res
+	}
+	switch res.Outcome {
+	case Rejected:
+		r.log.Error("config reload rejected, keeping the running config", append(fields, zap.String("error", res.Error))...)
+	case Applied:
+		r.log.Info("config reloaded", fields...)
+	default:
+		r.log.Info("config reload: nothing to apply", fields...)
+	}
+
+	r.smu.Lock()
+	r.status.Last = &res
+	if res.Outcome == Applied {
+		r.status.Version++
+		r.status.LoadedAt = res.At
+	}
+	r.smu.Unlock()
+	return res
+}
+
+func (r *Reloader) apply(trigger string) Result {
+	res := Result{At: time.Now(), Trigger: trigger}
+	next, err := r.load()
+	if err != nil {
+		res.Outcome, res.Error = Rejected, err.Error()
+		return res
+	}
+
+	// Start from the running config and take only the reloadable changes.
+	effective := r.running
+	touched := make([]bool, len(r.stages))
+	for _, path := range config.Diff(r.running, next) {
+		handled := false
+		for i, s := range r.stages {
+			if covers(s.Paths, path) {
+				touched[i], handled = true, true
+			}
+		}
+		if handled {
+			config.Assign(&effective, next, path)
+			res.Applied = append(res.Applied, path)
+		} else {
+			res.RestartRequired = append(res.RestartRequired, path)
+		}
+	}
+	if len(res.Applied) == 0 {
+		res.Outcome = Unchanged
+		return res
+	}
+
+	commits := make([]func(), 0, len(r.stages))
+	for i, s := range r.stages {
+		if !touched[i] {
+			continue
+		}
+		commit, err := s.Prepare(r.running, effective)
+		if err != nil {
+			res.Outcome, res.Error, res.Applied = Rejected, s.Name+": "+err.Error(), nil
+			return res
+		}
+		if commit != nil {
+			commits = append(commits, commit)
+		}
+	}
+	for _, commit := range commits {
+		commit()
+	}
+	r.running = effective
+	res.Outcome = Applied
+	return res
+}
+
+// covers reports whether path equals or lies under one of the prefixes.
+func covers(prefixes []string, path string) bool {
+	for _, p := range prefixes {
+		if path == p || strings.HasPrefix(path, p+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// Start watches the directories of the config files (editors and
+// Kubernetes ConfigMaps replace a file rather than write it), SIGHUP and,
+// with RefreshEvery, a ticker.
+// When the watch cannot be set up, SIGHUP still works and the error is
+// returned.
+func (r *Reloader) Start() error {
+	w, err := watch(r.files)
+	var events <-chan fsnotify.Event
+	var errs <-chan error
+	if err == nil {
+		events, errs = w.Events, w.Errors
+	}
+	watched := map[string]bool{}
+	for _, f := range r.files {
+		watched[filepath.Clean(f)] = true
+	}
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+
+	go func() {
+		defer close(r.done)
+		defer signal.Stop(hup)
+		if w != nil {
+			defer w.Close()
+		}
+		var tick <-chan time.Time
+		if r.every > 0 {
+			t := time.NewTicker(r.every)
+			defer t.Stop()
+			tick = t.C
+		}
+		var pending <-chan time.Time
+		for {
+			select {
+			case <-r.stop:
+				return
+			case <-hup:
+				r.Reload("sighup")
+			case <-tick:
+				r.Reload("refresh")
+			case ev := <-events:
+				// ..data is the symlink a ConfigMap update swaps
+				if watched[filepath.Clean(ev.Name)] || filepath.Base(ev.Name) == "..data" {
+					pending = time.After(debounce)
+				}
+			case err := <-errs:
+				r.log.Warn("config watch error", zap.Error(err))
+			case <-pending:
+				pending = nil
+				r.Reload("file")
+			}
+		}
+	}()
+	return err
+}
+
+// watch watches the directories holding files.
+func watch(files []string) (*fsnotify.Watcher, error) {
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range files {
+		if err := w.Add(filepath.Dir(f)); err != nil {
+			w.Close()
+			return nil, err
+		}
+	}
+	return w, nil
+}
+
+// Stop ends the watch loop started by Start. Safe to call twice.
+func (r *Reloader) Stop() {
+	r.stopOnce.Do(func() { close(r.stop) })
+	<-r.done
+}
@@ -2,10 +2,13 @@
 package repository // GORM-backed adapter (sqlite/mysql/postgres)
 
 import (
+	"context"
 	"errors"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
 	"gorm.io/driver/mysql"
 	"gorm.io/driver/postgres"
 	"gorm.io/driver/sqlite"
@@ -13,6 +16,9 @@ import (
 	"gorm.io/gorm/logger"
 
 	"example.com/api-gateway/internal/domain"
+	"example.com/api-gateway/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 )
 
@@ -60,7 +66,9 @@ func fromDomain(u *domain.User) gormUser {
 
 // gormRepo holds the DB connection and implements UserRepository.
 type gormRepo struct {
-	db *gorm.DB
+	db     *gorm.DB
+	driver string      // db.system on spans
+	log    *zap.Logger // failed queries, with the trace of the request
 }
 
 // NewGormRepo opens a GORM connection for the given driver and DSN.
@@ -87,11 +95,70 @@ func NewGormRepo(driver, dsn string, log *zap.Logger) (*gormRepo, error) {
 	if err := db.AutoMigrate(&gormUser{}); err != nil {
 		return nil, err
 	}
-	return &gormRepo{db: db}, nil
+	registerPoolStats(db, driver, log)
+	return &gormRepo{db: db, driver: driver, log: log}, nil
+}
+
+// registerPoolStats exports the connection pool of db (go_sql_* metrics,
+// db_name=driver). A second repo for the same driver keeps the first
+// registration, as Prometheus rejects duplicate collectors.
+func registerPoolStats(db *gorm.DB, driver string, log *zap.Logger) {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return
+	}
+	err = prometheus.Register(collectors.NewDBStatsCollector(sqlDB, driver))
+	var dup prometheus.AlreadyRegisteredError
+	if err != nil && !errors.As(err, &dup) {
+		log.Warn("db pool metrics not registered", zap.Error(err))
+	}
+}
+
+// Ping checks the database connection (readiness probe).
+func (r *gormRepo) Ping(ctx context.Context) error {
+	sqlDB, err := r.db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.PingContext(ctx)
+}
+
+// Close closes the connection pool (after the HTTP server has drained).
+func (r *gormRepo) Close() error {
+	sqlDB, err := r.db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.Close()
+}
+
+// tracer names the spans of repository calls.
+var tracer = tracing.Tracer("repository")
+
+// begin starts the "repository.<op>" span and binds the query to its context.
+func (r *gormRepo) begin(ctx context.Context, op string) (*gorm.DB, trace.Span) {
+	ctx, span := tracer.Start(ctx, "repository."+op,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("db.system", r.driver)))
+	return r.db.WithContext(ctx), span
+}
+
+// end closes span; ErrNotFound is an answer, not a failure. Failures are
+// logged with the span's trace_id, so the log line and trace join up.
+func (r *gormRepo) end(span trace.Span, op string, err error) {
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		tracing.RecordError(span, err)
+		ctx := trace.ContextWithSpan(context.Background(), span)
+		tracing.Logger(ctx, r.log).Warn("query failed", zap.String("op", op), zap.Error(err))
+	}
+	span.End()
 }
 
 // Create inserts a new user and assigns defaults where necessary.
-func (r *gormRepo) Create(u *domain.User) error {
+func (r *gormRepo) Create(ctx context.Context, u *domain.User) (err error) {
+	db, span := r.begin(ctx, "Create")
+	defer func() { r.end(span, "Create", err) }()
+
 	if u.ID == "" {
 		u.ID = uuid.NewString()
 	}
@@ -101,13 +168,15 @@ func (r *gormRepo) Create(u *domain.User) error {
 	u.UpdatedAt = time.Now()
 
 	gu := fromDomain(u)
-	return r.db.Create(&gu).Error
+	return db.Create(&gu).Error
 }
 
 // GetByID fetches a user by primary key.
-func (r *gormRepo) GetByID(id string) (*domain.User, error) {
+func (r *gormRepo) GetByID(ctx context.Context, id string) (_ *domain.User, err error) {
+	db, span := r.begin(ctx, "GetByID")
+	defer func() { r.end(span, "GetByID", err) }()
 	var gu gormUser
-	if err := r.db.First(&gu, "id = ?", id).Error; err != nil {
+	if err := db.First(&gu, "id = ?", id).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, ErrNotFound
 		}
@@ -118,9 +187,11 @@ func (r *gormRepo) GetByID(id string) (*domain.User, error) {
 }
 
 // GetByEmail fetches a user by unique email.
-func (r *gormRepo) GetByEmail(email string) (*domain.User, error) {
+func (r *gormRepo) GetByEmail(ctx context.Context, email string) (_ *domain.User, err error) {
+	db, span := r.begin(ctx, "GetByEmail")
+	defer func() { r.end(span, "GetByEmail", err) }()
 	var gu gormUser
-	if err := r.db.First(&gu, "email = ?", email).Error; err != nil {
+	if err := db.First(&gu, "email = ?", email).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, ErrNotFound
 		}
@@ -131,9 +202,11 @@ func (r *gormRepo) GetByEmail(email string) (*domain.User, error) {
 }
 
 // List returns users with simple paging.
-func (r *gormRepo) List(offset, limit int) ([]domain.User, error) {
+func (r *gormRepo) List(ctx context.Context, offset, limit int) (_ []domain.User, err error) {
+	db, span := r.begin(ctx, "List")
+	defer func() { r.end(span, "List", err) }()
 	var gus []gormUser
-	if err := r.db.Offset(offset).
+	if err := db.Offset(offset).
 		Limit(limit).
 		Order("created_at DESC").
 		Find(&gus).Error; err != nil {
@@ -149,7 +222,9 @@ func (r *gormRepo) List(offset, limit int) ([]domain.User, error) {
 // Update persists changes from the provided domain.User.
 // ⚠️ Uses a map to ensure "zero values" (e.g., Active=false) are not skipped by GORM.
 // Email is treated as immutable here; if you choose to allow changing it, explicitly include it in the map.
-func (r *gormRepo) Update(u *domain.User) error {
+func (r *gormRepo) Update(ctx context.Context, u *domain.User) (err error) {
+	db, span := r.begin(ctx, "Update")
+	defer func() { r.end(span, "Update", err) }()
 	u.UpdatedAt = time.Now()
 
 	// Build a map of fields we intend to persist. This avoids the "zero-value is ignored" behavior
@@ -163,7 +238,7 @@ func (r *gormRepo) Update(u *domain.User) error {
 		// "email":       u.Email, // ← keep commented to make email immutable by design
 	}
 
-	tx := r.db.Model(&gormUser{}).Where("id = ?", u.ID).Updates(update)
+	tx := db.Model(&gormUser{}).Where("id = ?", u.ID).Updates(update)
 	if tx.Error != nil {
 		return tx.Error
 	}
@@ -174,8 +249,10 @@ func (r *gormRepo) Update(u *domain.User) error {
 }
 
 // Delete removes a user by id.
-func (r *gormRepo) Delete(id string) error {
-	res := r.db.Delete(&gormUser{ID: id})
+func (r *gormRepo) Delete(ctx context.Context, id string) (err error) {
+	db, span := r.begin(ctx, "Delete")
+	defer func() { r.end(span, "Delete", err) }()
+	res := db.Delete(&gormUser{ID: id})
 	if res.Error != nil {
 		return res.Error
 	}
@@ -3,6 +3,7 @@
 package repository // Abstraction + constructors for adapters
 
 import (
+	"context"
 	"errors"
 	"fmt"
 
@@ -12,13 +13,15 @@ import (
 )
 
 // UserRepository abstracts CRUD regardless of DB.
+// Every call takes the request context: it carries the trace span and
+// cancels the query when the client goes away.
 type UserRepository interface {
-	Create(u *domain.User) error
-	GetByID(id string) (*domain.User, error)
-	GetByEmail(email string) (*domain.User, error)
-	List(offset, limit int) ([]domain.User, error)
-	Update(u *domain.User) error
-	Delete(id string) error
+	Create(ctx context.Context, u *domain.User) error
+	GetByID(ctx context.Context, id string) (*domain.User, error)
+	GetByEmail(ctx context.Context, email string) (*domain.User, error)
+	List(ctx context.Context, offset, limit int) ([]domain.User, error)
+	Update(ctx context.Context, u *domain.User) error
+	Delete(ctx context.Context, id string) error
 }
 
 // NewUserRepository selects concrete adapter by cfg.Database.Driver.
@@ -2,34 +2,60 @@
 package service // Auth service combines repo + jwt + password
 
 import (
+	"context"
 	"errors"
 
 	"example.com/api-gateway/config"
 	"example.com/api-gateway/internal/auth"
 	"example.com/api-gateway/internal/domain"
 	"example.com/api-gateway/internal/repository"
+	"example.com/api-gateway/internal/tracing"
 	"go.uber.org/zap"
 )
 
+// tracer names the spans of the service layer.
+var tracer = tracing.Tracer("service")
+
 // AuthService validates credentials and issues JWTs.
 type AuthService struct {
 	repo repository.UserRepository // read user by email
-	jwt  config.JWT                // signing config
+	keys *auth.Keys                // signing config (rotated on reload)
 	log  *zap.Logger               // logger
 }
 
 // NewAuthService wires dependencies.
 func NewAuthService(r repository.UserRepository, jwtCfg config.JWT, l *zap.Logger) *AuthService {
-	return &AuthService{repo: r, jwt: jwtCfg, log: l}
+	return &AuthService{repo: r, keys: auth.NewKeys(jwtCfg), log: l}
 }
 
+// Keys returns the key set tokens are signed with; the auth middleware
+// verifies against the same set, and config reloads rotate it.
+func (s *AuthService) Keys() *auth.Keys { return s.keys }
+
 // Login checks credentials and returns token string.
-func (s *AuthService) Login(email, password string) (string, *domain.User, error) {
-	u, err := s.repo.GetByEmail(email)
-	if err != nil { return "", nil, err }
-	if !u.Active { return "", nil, errors.New("inactive user") }
-	if !auth.Verify(u.PasswordHash, password) { return "", nil, errors.New("invalid credentials") }
-	tok, err := auth.Sign(s.jwt, u.ID, u.Role)
+// It runs in an "AuthService.Login" span with the repository lookup and
+// the bcrypt check as children; rejections are logged with its trace_id.
+func (s *AuthService) Login(ctx context.Context, email, password string) (_ string, _ *domain.User, err error) {
+	ctx, span := tracer.Start(ctx, "AuthService.Login")
+	defer func() {
+		if err != nil {
+			tracing.Logger(ctx, s.log).Info("login rejected", zap.Error(err))
+		}
+		tracing.RecordError(span, err)
+		span.End()
+	}()
+
+	u, err := s.repo.GetByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) { auth.CountFailure(auth.ReasonUnknownUser) }
+		return "", nil, err
+	}
+	if !u.Active { auth.CountFailure(auth.ReasonInactiveUser); return "", nil, errors.New("inactive user") }
+	_, verify := tracer.Start(ctx, "bcrypt.Verify")
+	ok := auth.Verify(u.PasswordHash, password)
+	verify.End()
+	if !ok { auth.CountFailure(auth.ReasonBadPassword); return "", nil, errors.New("invalid credentials") }
+	tok, err := s.keys.Sign(u.ID, u.Role)
 	if err != nil { return "", nil, err }
 	return tok, u, nil
 }
\ No newline at end of file
@@ -2,10 +2,12 @@
 package service // Business logic around users
 
 import (
+	"context"
 	"errors"
 
 	"example.com/api-gateway/internal/domain"
 	"example.com/api-gateway/internal/repository"
+	"example.com/api-gateway/internal/tracing"
 	"go.uber.org/zap"
 )
 
@@ -20,25 +22,38 @@ func NewUserService(r repository.UserRepository, l *zap.Logger) *UserService {
 	return &UserService{repo: r, log: l}
 }
 
+// logger returns the service logger with the trace of ctx attached.
+func (s *UserService) logger(ctx context.Context) *zap.Logger { return tracing.Logger(ctx, s.log) }
+
 // Create creates a user, ensuring unique email is enforced at DB.
-func (s *UserService) Create(u *domain.User) error { return s.repo.Create(u) }
+func (s *UserService) Create(ctx context.Context, u *domain.User) error {
+	if err := s.repo.Create(ctx, u); err != nil { return err }
+	s.logger(ctx).Info("user created", zap.String("id", u.ID))
+	return nil
+}
 
 // Get returns user by id.
-func (s *UserService) Get(id string) (*domain.User, error) { return s.repo.GetByID(id) }
+func (s *UserService) Get(ctx context.Context, id string) (*domain.User, error) { return s.repo.GetByID(ctx, id) }
 
 // GetByEmail returns user by email.
-func (s *UserService) GetByEmail(email string) (*domain.User, error) { return s.repo.GetByEmail(email) }
+func (s *UserService) GetByEmail(ctx context.Context, email string) (*domain.User, error) { return s.repo.GetByEmail(ctx, email) }
 
 // List pages users.
-func (s *UserService) List(offset, limit int) ([]domain.User, error) { return s.repo.List(offset, limit) }
+func (s *UserService) List(ctx context.Context, offset, limit int) ([]domain.User, error) { return s.repo.List(ctx, offset, limit) }
 
 // Update updates fields.
-func (s *UserService) Update(u *domain.User) error { 
-	return s.repo.Update(u) 
+func (s *UserService) Update(ctx context.Context, u *domain.User) error { 
+	if err := s.repo.Update(ctx, u); err != nil { return err }
+	s.logger(ctx).Info("user updated", zap.String("id", u.ID))
+	return nil
 }
 
 // Delete removes a user.
-func (s *UserService) Delete(id string) error { return s.repo.Delete(id) }
+func (s *UserService) Delete(ctx context.Context, id string) error {
+	if err := s.repo.Delete(ctx, id); err != nil { return err }
+	s.logger(ctx).Info("user deleted", zap.String("id", id))
+	return nil
+}
 
 // CanSelf checks if requester id matches target id.
 func (s *UserService) CanSelf(requesterID, targetID string) bool { return requesterID == targetID }
@@ -0,0 +1,69 @@
+// internal/tracing/redis_hook.go
+package tracing // Child spans for Redis commands issued within a trace
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+
+	rds "github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var redisTracer = Tracer("redis")
+
+// RedisHook returns a go-redis hook creating a client span per command or
+// pipeline. Commands without a span in their context (background loops such
+// as the async log writer or the tail reader) are not traced, so they do not
+// flood the exporter with root spans.
+func RedisHook() rds.Hook { return redisHook{} }
+
+type redisHook struct{}
+
+func (redisHook) DialHook(next rds.DialHook) rds.DialHook {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		return next(ctx, network, addr)
+	}
+}
+
+func (redisHook) ProcessHook(next rds.ProcessHook) rds.ProcessHook {
+	return func(ctx context.Context, cmd rds.Cmder) error {
+		if !trace.SpanFromContext(ctx).SpanContext().IsValid() {
+			return next(ctx, cmd)
+		}
+		ctx, span := redisTracer.Start(ctx, "redis."+cmd.Name(),
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(attribute.String("db.system", "redis"), attribute.String("db.operation", cmd.Name())))
+		defer span.End()
+		err := next(ctx, cmd)
+		if err != nil && !errors.Is(err, rds.Nil) { // a missing key is an answer
+			RecordError(span, err)
+		}
+		return err
+	}
+}
+
+func (redisHook) ProcessPipelineHook(next rds.ProcessPipelineHook) rds.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []rds.Cmder) error {
+		if !trace.SpanFromContext(ctx).SpanContext().IsValid() {
+			return next(ctx, cmds)
+		}
+		names := make([]string, len(cmds))
+		for i, c := range cmds {
+			names[i] = c.Name()
+		}
+		ctx, span := redisTracer.Start(ctx, "redis.pipeline",
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(attribute.String("db.system", "redis"),
+				attribute.String("db.operation", strings.Join(names, " ")),
+				attribute.Int("db.redis.pipeline_length", len(cmds))))
+		defer span.End()
+		err := next(ctx, cmds)
+		if err != nil && !errors.Is(err, rds.Nil) {
+			RecordError(span, err)
+		}
+		return err
+	}
+}
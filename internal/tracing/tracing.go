@@ -0,0 +1,138 @@
+// internal/tracing/tracing.go
+package tracing // OpenTelemetry setup, tracers and trace/log correlation
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"example.com/api-gateway/config"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/embedded"
+	"go.uber.org/zap"
+)
+
+// instrumentation is the prefix of every tracer name.
+const instrumentation = "example.com/api-gateway/"
+
+// Setup installs the exporter chosen by cfg.Exporter and returns a
+// shutdown func that flushes pending spans. With "none" (or empty) only
+// the propagator is installed: incoming traceparent headers still flow to
+// upstreams, but no spans are recorded.
+func Setup(cfg config.Tracing) (func(context.Context) error, error) {
+	setPropagator()
+
+	var exp sdktrace.SpanExporter
+	var err error
+	switch cfg.Exporter {
+	case "", "none":
+		return func(context.Context) error { return nil }, nil
+	case "stdout":
+		exp, err = stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+	case "otlp":
+		endpoint := cfg.Endpoint
+		if endpoint == "" {
+			endpoint = "localhost:4318"
+		}
+		opts := []otlptracehttp.Option{otlptracehttp.WithEndpoint(endpoint)}
+		if cfg.Insecure {
+			opts = append(opts, otlptracehttp.WithInsecure())
+		}
+		exp, err = otlptracehttp.New(context.Background(), opts...)
+	default:
+		return nil, fmt.Errorf("unknown tracing exporter %q (want otlp, stdout or none)", cfg.Exporter)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return Install(exp, cfg).Shutdown, nil
+}
+
+// Install makes a batching provider around exp the global one (tests pass
+// an in-memory exporter and call ForceFlush before reading it).
+func Install(exp sdktrace.SpanExporter, cfg config.Tracing) *sdktrace.TracerProvider {
+	name := cfg.ServiceName
+	if name == "" {
+		name = "api-gateway"
+	}
+	ratio := 1.0 // unset: record every new trace
+	if cfg.SampleRatio != nil {
+		ratio = *cfg.SampleRatio
+	}
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exp),
+		sdktrace.WithResource(resource.NewSchemaless(attribute.String("service.name", name))),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(ratio))),
+	)
+	otel.SetTracerProvider(tp)
+	setPropagator()
+	return tp
+}
+
+// setPropagator reads and writes W3C traceparent/tracestate and baggage.
+func setPropagator() {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{}, propagation.Baggage{}))
+}
+
+// lazyTracer looks the global provider up on every Start, so package-level
+// tracers follow a provider installed (or replaced) after they were made.
+type lazyTracer struct {
+	embedded.Tracer
+	name string
+}
+
+func (t lazyTracer) Start(ctx context.Context, span string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	return otel.Tracer(t.name).Start(ctx, span, opts...)
+}
+
+// Tracer returns the tracer of a component ("service", "repository", ...).
+func Tracer(component string) trace.Tracer {
+	return lazyTracer{name: instrumentation + component}
+}
+
+// RecordError marks span failed with err; nil is ignored.
+func RecordError(span trace.Span, err error) {
+	if err == nil {
+		return
+	}
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
+
+// TraceID returns the trace ID carried by ctx, or "" without a valid span.
+func TraceID(ctx context.Context) string {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.HasTraceID() {
+		return ""
+	}
+	return sc.TraceID().String()
+}
+
+// LogFields returns trace_id/span_id fields for zap, or nil without a span.
+func LogFields(ctx context.Context) []zap.Field {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return nil
+	}
+	return []zap.Field{
+		zap.String("trace_id", sc.TraceID().String()),
+		zap.String("span_id", sc.SpanID().String()),
+	}
+}
+
+// Logger returns log with the trace fields of ctx attached.
+func Logger(ctx context.Context, log *zap.Logger) *zap.Logger {
+	if f := LogFields(ctx); f != nil {
+		return log.With(f...)
+	}
+	return log
+}
@@ -0,0 +1,59 @@
+// internal/tracing/transport.go
+package tracing // Client spans + traceparent injection for upstream calls
+
+import (
+	"net/http"
+	"strconv"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var clientTracer = Tracer("http.client")
+
+// Transport wraps base (nil = http.DefaultTransport) so every upstream
+// request runs in a client span and carries its traceparent header. Use it
+// for any http.Client or reverse proxy that forwards gateway traffic.
+// Requests without a span in their context (background senders such as the
+// HTTP log sink) pass through untouched, as in RedisHook.
+func Transport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &transport{base: base}
+}
+
+type transport struct {
+	base http.RoundTripper
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !trace.SpanContextFromContext(req.Context()).IsValid() {
+		return t.base.RoundTrip(req)
+	}
+	ctx, span := clientTracer.Start(req.Context(), req.Method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("http.request.method", req.Method),
+			attribute.String("server.address", req.URL.Host),
+			attribute.String("url.full", req.URL.Redacted()),
+		))
+	defer span.End()
+
+	req = req.Clone(ctx) // RoundTrippers must not modify the caller's request
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		RecordError(span, err)
+		return nil, err
+	}
+	span.SetAttributes(attribute.Int("http.response.status_code", resp.StatusCode))
+	if resp.StatusCode >= 500 {
+		span.SetStatus(codes.Error, "upstream status "+strconv.Itoa(resp.StatusCode))
+	}
+	return resp, nil
+}
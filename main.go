@@ -3,90 +3,307 @@ package main
 
 import (
 	"context"
+	"errors"
+	"flag"
 	"fmt"
+	"io"
+	"net"
 	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
 	"time"
 
 	"example.com/api-gateway/config"
+	"example.com/api-gateway/internal/capture"
+	"example.com/api-gateway/internal/health"
 	"example.com/api-gateway/internal/http"
 	"example.com/api-gateway/internal/logger"
 	"example.com/api-gateway/internal/rate"
 	rds "example.com/api-gateway/internal/redis"
+	"example.com/api-gateway/internal/reload"
 	"example.com/api-gateway/internal/repository"
 	"example.com/api-gateway/internal/service"
+	"example.com/api-gateway/internal/tracing"
 	"go.uber.org/zap"
+	"gopkg.in/natefinch/lumberjack.v2"
 )
 
 func main() {
-	// 1) Load configuration
-	cfg, err := config.Load()
+	// Deferred stops run in reverse order once the HTTP server has drained
+	// (step 8): signal handler, config reloader, current limiter, tracing
+	// flush, DB, capture/list janitors, log shipper, archiver, tail, Redis
+	// monitor, logger and sinks, async Redis logger, Redis client.
+
+	// Flags (--config, --set); subcommands (config check, secret seal) run instead of the server
+	src, args, err := parseFlags(os.Args[1:], os.Stderr)
+	if errors.Is(err, flag.ErrHelp) {
+		os.Exit(0)
+	} else if err != nil {
+		os.Exit(2)
+	}
+	if len(args) > 0 {
+		os.Exit(runCommand(src, args, os.Stdin, os.Stdout, os.Stderr))
+	}
+
+	// 1) Load configuration: files (+ environment overlay), GATEWAY_* variables,
+	// --set; strict: unknown keys and invalid values refuse to start
+	cfg, err := src.Load()
 	if err != nil {
-		panic(fmt.Errorf("load config: %w", err))
+		fmt.Fprintf(os.Stderr, "invalid config %s:\n%v\n", strings.Join(src.Files, " + "), err)
+		os.Exit(1)
 	}
 
-	// 2) Redis client (for rate limiting or other features) + async log worker
-	rclient, err := rds.NewClient(cfg.Redis, zap.NewNop())
+	// 2) Redis client (for rate limiting or other features) + async log worker.
+	// Redis is optional unless redis.required is set: the client reconnects on
+	// its own and the monitor below reports when it comes back.
+	rclient, redisErr := rds.NewClient(cfg.Redis, zap.NewNop())
+	if redisErr != nil && cfg.Redis.Required {
+		panic(fmt.Errorf("redis connect: %w", redisErr))
+	}
+	defer rclient.Close() // last: the async logger drains into it
+	logStore := rds.NewLogStore(rclient, cfg.Logging.Redis)
+	asyncRedis, err := rds.NewAsyncLogger(logStore, cfg.Logging.Redis.Async)
 	if err != nil {
-		panic(fmt.Errorf("redis connect: %w", err))
+		panic(fmt.Errorf("init async redis logger: %w", err))
 	}
-	asyncRedis := rds.NewAsyncLogger(rclient, 2048)
 	asyncRedis.Start()
-	defer asyncRedis.Stop()
+	defer func() {
+		// Drain what Redis takes within the deadline; the rest is spilled to disk.
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = asyncRedis.Stop(ctx)
+	}()
 
-	// 3) Logger (console + rotating file) + Redis hook (forward each entry to async)
-	log, err := logger.New(cfg.Logging, asyncRedis)
+	// 3) Logger (console + rotating file) + Redis hook (forward each entry to async) + sinks
+	log, logCtl, err := logger.New(cfg.Logging, asyncRedis)
 	if err != nil {
 		panic(fmt.Errorf("init logger: %w", err))
 	}
+	defer logCtl.Close() // after Sync below: flush queued sink batches, then close
 	defer log.Sync()
+	// Named per package so logging.levels / PUT /api/log-level can target them.
+	redisLog, rateLog := log.Named("redis"), log.Named("rate")
+	if redisErr != nil {
+		log.Warn("redis unavailable at startup, continuing without it", zap.Error(redisErr))
+	}
+	redisMon := rds.NewMonitor(rclient, time.Duration(cfg.Redis.HealthCheckIntervalMS)*time.Millisecond, redisErr == nil, redisLog)
+	redisMon.Start()
+	defer redisMon.Stop()
+
+	// 3b) Live tail for /api/logs/stream (reads the stream only while watched)
+	logTail := rds.NewTailer(logStore, redisLog)
+	logTail.Start()
+	defer logTail.Stop()
+
+	// 3c) Archival of per-day lists before they expire (logging.redis.retention.archive)
+	logArchive := rds.NewArchiver(logStore, cfg.Logging.Redis.Retention.Archive, redisLog)
+	if logArchive != nil {
+		logArchive.Start()
+		defer logArchive.Stop()
+	}
+
+	// 3d) Optional stream consumer copying stored logs to an NDJSON file
+	bgCtx, cancelBg := context.WithCancel(context.Background())
+	defer cancelBg()
+	if path := cfg.Logging.Redis.ShipperPath; path != "" {
+		host, _ := os.Hostname()
+		shipper := rds.NewConsumer(logStore, "shipper", fmt.Sprintf("%s-%d", host, os.Getpid()),
+			rds.NDJSONWriter(&lumberjack.Logger{Filename: path, MaxSize: 100, MaxBackups: 7, Compress: true}), redisLog)
+		go func() {
+			if err := shipper.Run(bgCtx); err != nil {
+				log.Error("log shipper stopped", zap.Error(err))
+			}
+		}()
+	}
 
-	// 4) Rate limiter
-	var limiter rate.Limiter
-	if cfg.RateLimit.Enabled {
-		switch cfg.RateLimit.Strategy {
+	// 4) Rate limiter (replaced on config reload; the one current at exit is stopped)
+	newLimiter := func(rc config.RateLimit) rate.Limiter {
+		if !rc.Enabled {
+			return rate.Noop{}
+		}
+		switch rc.Strategy {
 		case "memory":
-			limiter = rate.NewMemoryLimiter(cfg.RateLimit, log)
+			return rate.NewMemoryLimiter(rc, rateLog)
 		case "redis":
-			limiter = rate.NewRedisLimiter(rclient, cfg.RateLimit, log)
+			return rate.NewRedisLimiter(rclient, rc, redisMon, rateLog)
+		case "hybrid":
+			return rate.NewHybridLimiter(rclient, rc, redisMon, rateLog)
 		default:
-			limiter = rate.Noop{}
+			return rate.Noop{}
 		}
-	} else {
-		limiter = rate.Noop{}
 	}
+	limiter := newLimiter(cfg.RateLimit)
+
+	// 4a) Runtime allow/deny lists (shared via Redis, cached locally)
+	accessList := rate.NewAccessList(rclient, time.Duration(cfg.RateLimit.AccessListRefreshMS)*time.Millisecond, rateLog)
+	accessList.Start()
+	defer accessList.Stop()
+
+	// 4b) Debug capture rules (toggled by admins, shared via Redis)
+	captureRules := capture.NewRules(rclient,
+		time.Duration(cfg.Logging.Capture.RefreshMS)*time.Millisecond,
+		time.Duration(cfg.Logging.Capture.MaxDurationSec)*time.Second,
+		cfg.Logging.Capture.MaxBodyBytes, redisLog)
+	captureRules.Start()
+	defer captureRules.Stop()
+
+	// 4c) Concurrency limiter (in-flight caps + adaptive load shedding)
+	newConcurrency := func(cc config.Concurrency) rate.ConcurrencyLimiter {
+		if !cc.Enabled {
+			return nil
+		}
+		return rate.NewConcurrencyLimiter(cc, rateLog)
+	}
+	concurrency := newConcurrency(cfg.RateLimit.Concurrency)
 
 	// 5) Repository + services (GORM-based repo constructed from cfg.Database)
-	userRepo, err := repository.NewUserRepository(cfg.Database, log)
+	userRepo, err := repository.NewUserRepository(cfg.Database, log.Named("repository"))
 	if err != nil {
 		log.Fatal("user repo init failed", zap.Error(err))
 	}
+	if c, ok := userRepo.(io.Closer); ok {
+		defer c.Close()
+	}
+
+	svcLog := log.Named("service")
+	authSvc := service.NewAuthService(userRepo, cfg.Security.JWT, svcLog)
+	userSvc := service.NewUserService(userRepo, svcLog)
+
+	// 5b) Tracing (exporter from cfg.Tracing; pending spans flushed on exit)
+	shutdownTracing, err := tracing.Setup(cfg.Tracing)
+	if err != nil {
+		log.Fatal("tracing init failed", zap.Error(err))
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = shutdownTracing(ctx)
+	}()
 
-	authSvc := service.NewAuthService(userRepo, cfg.Security.JWT, log)
-	userSvc := service.NewUserService(userRepo, log)
+	// 5c) Readiness checks for /health/ready. Redis only fails readiness when
+	// the gateway cannot run without it (redis.required).
+	readiness := health.NewReadiness(time.Duration(cfg.Health.TimeoutMS) * time.Millisecond)
+	if p, ok := userRepo.(health.Pinger); ok {
+		readiness.Register(health.Ping("database", p))
+	}
+	redisCheck := health.Func("redis", func(ctx context.Context) error { return rclient.Ping(ctx).Err() })
+	if cfg.Redis.Required {
+		readiness.Register(redisCheck)
+	} else {
+		readiness.RegisterOptional(redisCheck)
+	}
+	for _, u := range cfg.Health.Upstreams {
+		if u.Optional {
+			readiness.RegisterOptional(health.HTTP(u.Name, u.URL, nil))
+		} else {
+			readiness.Register(health.HTTP(u.Name, u.URL, nil))
+		}
+	}
+
+	// 5d) Hot reload of the config files (file watch + SIGHUP); stages below
+	reloader := reload.New(src.Paths(cfg.Environment), cfg, src.Load, log.Named("config"))
+	// Re-resolving file:/env:/enc: secrets periodically feeds a rotated JWT
+	// secret to the "jwt keys" stage; a rotated DSN or Redis password is
+	// reported as needing a restart.
+	reloader.RefreshEvery(time.Duration(cfg.Secrets.RefreshMS) * time.Millisecond)
 
 	// 6) Router
-	engine := httpx.NewRouter(cfg, log, authSvc, userSvc, limiter, asyncRedis, rclient)
+	deps := httpx.Deps{
+		Config:      cfg,
+		Log:         log,
+		AuthSvc:     authSvc,
+		UserSvc:     userSvc,
+		Limiter:     limiter,
+		Concurrency: concurrency,
+		AccessList:  accessList,
+		RedisAsync:  asyncRedis,
+		LogStore:    logStore,
+		LogTail:     logTail,
+		LogLevels:   logCtl.Levels,
+		Redactor:    logCtl.Redactor,
+		Capture:     captureRules,
+		LogArchive:  logArchive,
+		Health:      readiness,
+		Keys:        authSvc.Keys(),
+		Reloader:    reloader,
+	}
+	public := httpx.NewSwappable(httpx.NewRouter(deps))
 
 	// 7) HTTP Server with timeouts from config
 	srv := &http.Server{
 		Addr:              fmt.Sprintf("%s:%d", cfg.Server.Host, cfg.Server.Port),
-		Handler:           engine,
+		Handler:           public,
 		ReadTimeout:       time.Duration(cfg.Server.Timeouts.ReadMS) * time.Millisecond,
 		ReadHeaderTimeout: time.Duration(cfg.Server.Timeouts.ReadHeaderMS) * time.Millisecond,
 		WriteTimeout:      time.Duration(cfg.Server.Timeouts.WriteMS) * time.Millisecond,
 		IdleTimeout:       time.Duration(cfg.Server.Timeouts.IdleMS) * time.Millisecond,
 	}
 
+	// Live-tail streams end when the tailer stops, instead of holding the drain.
+	srv.RegisterOnShutdown(logTail.Stop)
+
+	// 7b) Optional admin listener: metrics, pprof and admin APIs off the public port
+	var adminSrv *http.Server
+	var admin *httpx.Swappable
+	if addr := cfg.Server.Admin.Address; addr != "" {
+		adminEngine, err := httpx.NewAdminRouter(deps)
+		if err != nil {
+			log.Fatal("admin listener init failed", zap.Error(err))
+		}
+		admin = httpx.NewSwappable(adminEngine)
+		adminSrv = &http.Server{
+			Addr:              addr,
+			Handler:           admin,
+			ReadHeaderTimeout: time.Duration(cfg.Server.Timeouts.ReadHeaderMS) * time.Millisecond,
+			IdleTimeout:       time.Duration(cfg.Server.Timeouts.IdleMS) * time.Millisecond,
+			// no WriteTimeout: pprof profiles and the log tail stream for longer
+		}
+		log.Info("admin server starting", zap.String("addr", addr), zap.Bool("pprof", cfg.Server.Admin.Pprof))
+		go func() {
+			if err := adminSrv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				log.Fatal("admin listen and serve failed", zap.Error(err))
+			}
+		}()
+	}
+
+	// 7c) Reload stages: log levels, JWT keys and the router (CORS, rate
+	// limits). Anything else that changes in the file is reported at
+	// /api/config/status as requiring a restart.
+	live := httpx.NewLive(deps, public, admin, newLimiter, newConcurrency)
+	defer live.Stop() // the limiter current at exit, after the reloader stopped
+	for _, stage := range live.Stages() {
+		reloader.Register(stage)
+	}
+	if err := reloader.Start(); err != nil {
+		log.Warn("config file watch unavailable, reload with SIGHUP only", zap.Error(err))
+	}
+	defer reloader.Stop()
+
 	log.Info("http server starting",
 		zap.String("addr", srv.Addr),
 	)
 
-	// 8) Start server (basic blocking; add graceful shutdown as needed)
-	if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-		log.Fatal("listen and serve failed", zap.Error(err))
+	// 8) Serve until SIGTERM/SIGINT, then drain (a second signal kills at once)
+	sigCtx, stopSignals := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stopSignals()
+	context.AfterFunc(sigCtx, stopSignals)
+	ln, err := net.Listen("tcp", srv.Addr)
+	if err != nil {
+		log.Fatal("listen failed", zap.Error(err))
+	}
+	if err := httpx.NewGraceful(cfg.Server.Shutdown, readiness, log).Serve(sigCtx, srv, ln); err != nil {
+		log.Error("http server stopped", zap.Error(err))
 	}
 
-	// 9) On exit, best-effort context cancel for Redis pings (example)
-	_ = rclient.Close()
-	_ = context.Canceled
+	// 9) Admin listener last, so metrics stay scrapable during the drain
+	if adminSrv != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		if err := adminSrv.Shutdown(ctx); err != nil {
+			_ = adminSrv.Close()
+		}
+		cancel()
+	}
+	log.Info("shutdown complete, stopping background workers")
 }
@@ -2,7 +2,17 @@ package errors // Consistent error envelope helpers
 
 import "github.com/gin-gonic/gin"
 
-// JSON writes a standardized error object.
+// JSON writes a standardized error object. traceId is the OpenTelemetry
+// trace ID when the request is traced (see middleware.Tracing), so clients
+// can quote it to find the whole trace; otherwise the request ID.
 func JSON(c *gin.Context, status int, code, msg string) {
-	c.JSON(status, gin.H{"error": msg, "code": code, "traceId": c.GetString("req.id")})
-}
\ No newline at end of file
+	c.JSON(status, gin.H{"error": msg, "code": code, "traceId": TraceID(c)})
+}
+
+// TraceID returns the trace ID of the request, falling back to its request ID.
+func TraceID(c *gin.Context) string {
+	if id := c.GetString("trace.id"); id != "" {
+		return id
+	}
+	return c.GetString("req.id")
+}
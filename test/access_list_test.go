@@ -0,0 +1,179 @@
+package test
+
+
+import (
+"context"
+"errors"
+"net/http"
+"net/http/httptest"
+"strings"
+"testing"
+"time"
+"example.com/api-gateway/config"
+"example.com/api-gateway/internal/auth"
+httpx "example.com/api-gateway/internal/http"
+"example.com/api-gateway/internal/rate"
+rds "example.com/api-gateway/internal/redis"
+"github.com/alicebob/miniredis/v2"
+"github.com/gin-gonic/gin"
+"go.uber.org/zap"
+)
+
+
+func miniClient(t *testing.T) (*miniredis.Miniredis, rds.Client) {
+t.Helper()
+mr := miniredis.RunT(t)
+c := rds.Dial(config.Redis{Addresses: []string{mr.Addr()}})
+t.Cleanup(func() { c.Close() })
+return mr, c
+}
+
+
+// do sends method path from remote with an optional bearer token and JSON body.
+func do(h http.Handler, method, path, remote, token, body string) *httptest.ResponseRecorder {
+req := httptest.NewRequest(method, path, strings.NewReader(body))
+req.RemoteAddr = remote
+if body != "" { req.Header.Set("Content-Type", "application/json") }
+if token != "" { req.Header.Set("Authorization", "Bearer "+token) }
+w := httptest.NewRecorder()
+h.ServeHTTP(w, req)
+return w
+}
+
+
+func TestAccessListMatching(t *testing.T) {
+_, c := miniClient(t)
+ctx := context.Background()
+al := rate.NewAccessList(c, time.Hour, zap.NewNop())
+for _, e := range [][2]string{{"deny", "10.1.0.0/16"}, {"deny", "2001:db8::/32"}, {"deny", "user-bad"}, {"allow", "10.1.2.0/24"}, {"allow", "::ffff:192.0.2.9"}, {"allow", "user-7"}} {
+if err := al.Add(ctx, e[0], e[1]); err != nil { t.Fatalf("%v: %v", e, err) }
+}
+for _, tc := range []struct{ ip, user string; allowed, denied bool }{
+{"10.1.2.3", "", false, true},   // deny wins over the narrower allow
+{"10.2.0.1", "", false, false},
+{"2001:db8::1", "", false, true},
+{"192.0.2.9", "", true, false},  // v4-mapped entry normalized
+{"203.0.113.1", "user-7", true, false},
+{"203.0.113.1", "user-bad", false, true},
+{"10.1.255.255", "user-7", false, true},
+} {
+if a, d := al.Check(tc.ip, tc.user); a != tc.allowed || d != tc.denied { t.Errorf("%s/%s: allowed=%v denied=%v", tc.ip, tc.user, a, d) }
+}
+if e := al.Entries(); strings.Join(e["allow"], ",") != "10.1.2.0/24,192.0.2.9,user-7" { t.Fatalf("%v", e) }
+
+if err := al.Add(ctx, "deny", "10.0.0.0/33"); !errors.Is(err, rate.ErrInvalidEntry) { t.Fatalf("bad cidr: %v", err) }
+if err := al.Add(ctx, "grey", "1.2.3.4"); !errors.Is(err, rate.ErrUnknownList) { t.Fatalf("bad list: %v", err) }
+if err := al.Remove(ctx, "deny", "10.9.0.0/16"); !errors.Is(err, rate.ErrNoSuchEntry) { t.Fatalf("missing entry: %v", err) }
+
+// another replica picks the lists up from Redis
+other := rate.NewAccessList(c, time.Hour, zap.NewNop())
+other.Start()
+defer other.Stop()
+if _, d := other.Check("10.1.9.9", ""); !d { t.Fatal("replica did not load the deny list") }
+if err := al.Remove(ctx, "deny", "10.1.0.0/16"); err != nil { t.Fatal(err) }
+if _, d := al.Check("10.1.9.9", ""); d { t.Fatal("removed entry still denies") }
+}
+
+
+func TestLimiterInspectAndReset(t *testing.T) {
+_, c := miniClient(t)
+ctx := context.Background()
+cfg := config.RateLimit{RequestsPerMinute: 10, Burst: 2}
+mem := rate.NewMemoryLimiter(cfg, zap.NewNop())
+red := rate.NewRedisLimiter(c, cfg, nil, zap.NewNop())
+hyb := rate.NewHybridLimiter(c, cfg, nil, zap.NewNop())
+defer hyb.(rate.Stopper).Stop()
+for _, l := range []rate.Limiter{mem, red, hyb} {
+in := l.(rate.Inspector)
+st, err := in.Inspect(ctx, "k")
+if err != nil || st.Tracked { t.Fatalf("fresh key: %+v %v", st, err) }
+l.Allow("k"); l.Allow("k")
+st, _ = in.Inspect(ctx, "k")
+if !st.Tracked || st.Remaining >= float64(st.Limit) { t.Fatalf("%s after 2 hits: %+v", st.Strategy, st) }
+if st.Strategy != "memory" && st.Used != 2 { t.Fatalf("%s used: %+v", st.Strategy, st) }
+if err := in.Reset(ctx, "k"); err != nil { t.Fatal(err) }
+if st, _ = in.Inspect(ctx, "k"); st.Tracked || st.Remaining != float64(st.Limit) { t.Fatalf("%s after reset: %+v", st.Strategy, st) }
+}
+}
+
+
+// limitedRouter is the public router with a memory limiter and Redis-backed access lists.
+func limitedRouter(t *testing.T, rl config.RateLimit, admin config.Admin) (*gin.Engine, rate.Limiter, *rate.AccessList, config.JWT) {
+t.Helper()
+gin.SetMode(gin.TestMode)
+_, c := miniClient(t)
+cfg := config.Root{}
+cfg.Security.JWT = config.JWT{Secret: "s", TTLMinutes: 5}
+cfg.RateLimit = rl
+cfg.Server.Admin = admin
+lim := rate.NewMemoryLimiter(rl, zap.NewNop())
+al := rate.NewAccessList(c, time.Hour, zap.NewNop())
+return httpx.NewRouter(httpx.Deps{Config: cfg, Log: zap.NewNop(), Limiter: lim, AccessList: al}), lim, al, cfg.Security.JWT
+}
+
+
+func TestDenyListAndIPLimitRunBeforeAuth(t *testing.T) {
+r, _, al, jwt := limitedRouter(t, config.RateLimit{RequestsPerMinute: 1, Burst: 2}, config.Admin{})
+ctx := context.Background()
+al.Add(ctx, "deny", "10.9.0.0/16")
+al.Add(ctx, "deny", "user-bad")
+
+if w := do(r, "GET", "/users", "10.9.1.1:4000", "", ""); w.Code != 403 { t.Fatalf("deny-listed IP without token: %d", w.Code) }
+if w := do(r, "GET", "/users", "10.9.1.1:4000", "forged", ""); w.Code != 403 { t.Fatalf("deny-listed IP with bad token: %d", w.Code) }
+
+// token guessing is throttled per IP
+codes := []int{}
+for i := 0; i < 4; i++ { codes = append(codes, do(r, "GET", "/users", "203.0.113.5:4000", "guess", "").Code) }
+if codes[0] != 401 || codes[3] != 429 { t.Fatalf("bad tokens from one IP: %v", codes) }
+
+// the per-user list still applies once auth has run
+bad, _ := auth.Sign(jwt, "user-bad", "admin")
+if w := do(r, "GET", "/api/ratelimits/x", "198.51.100.1:4000", bad, ""); w.Code != 403 || !strings.Contains(w.Body.String(), "access denied") { t.Fatalf("deny-listed user: %d %s", w.Code, w.Body) }
+}
+
+
+func TestRateLimitAdminHandler(t *testing.T) {
+r, lim, _, jwt := limitedRouter(t, config.RateLimit{RequestsPerMinute: 10, Burst: 100}, config.Admin{})
+admin, _ := auth.Sign(jwt, "admin-1", "admin")
+user, _ := auth.Sign(jwt, "u1", "user")
+ip := "198.51.100.2:4000"
+
+lim.Allow("u9")
+if w := do(r, "GET", "/api/ratelimits/u9", ip, admin, ""); w.Code != 200 || !strings.Contains(w.Body.String(), `"tracked":true`) { t.Fatalf("inspect: %d %s", w.Code, w.Body) }
+if w := do(r, "GET", "/api/ratelimits/u9", ip, user, ""); w.Code != 403 { t.Fatalf("non-admin inspect: %d", w.Code) }
+if w := do(r, "DELETE", "/api/ratelimits/u9", ip, user, ""); w.Code != 403 { t.Fatalf("non-admin reset: %d", w.Code) }
+if w := do(r, "DELETE", "/api/ratelimits/u9", ip, admin, ""); w.Code != 204 { t.Fatalf("reset: %d", w.Code) }
+if w := do(r, "GET", "/api/ratelimits/u9", ip, admin, ""); !strings.Contains(w.Body.String(), `"tracked":false`) { t.Fatalf("after reset: %s", w.Body) }
+
+if w := do(r, "POST", "/api/ratelimit-lists/deny", ip, user, `{"entry":"10.0.0.0/8"}`); w.Code != 403 { t.Fatalf("non-admin add: %d", w.Code) }
+if w := do(r, "POST", "/api/ratelimit-lists/deny", ip, admin, `{"entry":"10.0.0.0/33"}`); w.Code != 422 { t.Fatalf("bad cidr: %d", w.Code) }
+if w := do(r, "POST", "/api/ratelimit-lists/grey", ip, admin, `{"entry":"10.0.0.1"}`); w.Code != 422 { t.Fatalf("bad list: %d", w.Code) }
+if w := do(r, "POST", "/api/ratelimit-lists/deny", ip, admin, `{}`); w.Code != 400 { t.Fatalf("no entry: %d", w.Code) }
+if w := do(r, "POST", "/api/ratelimit-lists/deny", ip, admin, `{"entry":"10.0.0.0/8"}`); w.Code != 201 || !strings.Contains(w.Body.String(), "10.0.0.0/8") { t.Fatalf("add: %d %s", w.Code, w.Body) }
+if w := do(r, "DELETE", "/api/ratelimit-lists/deny?entry=10.0.0.0/8", ip, admin, ""); w.Code != 204 { t.Fatalf("remove: %d", w.Code) }
+if w := do(r, "DELETE", "/api/ratelimit-lists/deny?entry=10.0.0.0/8", ip, admin, ""); w.Code != 404 { t.Fatalf("remove twice: %d", w.Code) }
+
+// with a separate admin listener the public one does not serve these
+pub, _, _, _ := limitedRouter(t, config.RateLimit{RequestsPerMinute: 10, Burst: 100}, config.Admin{Address: "127.0.0.1:0", Auth: config.AdminAuth{BearerToken: "t"}})
+if w := do(pub, "GET", "/api/ratelimits/u9", ip, admin, ""); w.Code != 404 { t.Fatalf("public listener: %d", w.Code) }
+}
+
+
+func TestUsersBehindOneIPKeepSeparateBuckets(t *testing.T) {
+r, lim, _, jwt := limitedRouter(t, config.RateLimit{RequestsPerMinute: 1, Burst: 2}, config.Admin{})
+alice, _ := auth.Sign(jwt, "alice", "admin")
+bob, _ := auth.Sign(jwt, "bob", "admin")
+nat := "192.0.2.50:4000"
+
+// each authenticated request costs one token, from the user's bucket only
+for i, tok := range []string{alice, alice, bob, bob} {
+if w := do(r, "GET", "/api/ratelimits/x", nat, tok, ""); w.Code != 200 { t.Fatalf("request %d: %d %s", i, w.Code, w.Body) }
+}
+if w := do(r, "GET", "/api/ratelimits/x", nat, alice, ""); w.Code != 429 { t.Fatalf("alice over burst: %d", w.Code) }
+if st, _ := lim.(rate.Inspector).Inspect(context.Background(), "192.0.2.50"); st.Tracked { t.Fatalf("IP bucket charged for authenticated requests: %+v", st) }
+
+// anonymous requests from the same IP still have the whole IP bucket
+codes := []int{}
+for i := 0; i < 3; i++ { codes = append(codes, do(r, "GET", "/users", nat, "", "").Code) }
+if codes[0] != 401 || codes[1] != 401 || codes[2] != 429 { t.Fatalf("anonymous from shared IP: %v", codes) }
+}
@@ -0,0 +1,100 @@
+package test
+
+
+import (
+"context"
+"errors"
+"net/http"
+"net/http/httptest"
+"testing"
+"example.com/api-gateway/config"
+httpx "example.com/api-gateway/internal/http"
+"example.com/api-gateway/internal/http/middleware"
+"example.com/api-gateway/internal/logger"
+rds "example.com/api-gateway/internal/redis"
+"github.com/gin-gonic/gin"
+"go.uber.org/zap"
+)
+
+
+func adminDeps(admin config.Admin) httpx.Deps {
+gin.SetMode(gin.TestMode)
+cfg := config.Root{}
+cfg.Server.Admin = admin
+return httpx.Deps{Config: cfg, Log: zap.NewNop()}
+}
+
+
+func call(h http.Handler, path, remote string, set func(*http.Request)) *httptest.ResponseRecorder {
+req := httptest.NewRequest(http.MethodGet, path, nil)
+if remote != "" { req.RemoteAddr = remote }
+if set != nil { set(req) }
+w := httptest.NewRecorder()
+h.ServeHTTP(w, req)
+return w
+}
+
+
+func TestAdminAuthNeedsAMethod(t *testing.T) {
+if _, err := middleware.AdminAuth(config.AdminAuth{}); !errors.Is(err, middleware.ErrAdminAuthUnset) { t.Fatalf("want ErrAdminAuthUnset, got %v", err) }
+if _, err := middleware.AdminAuth(config.AdminAuth{AllowIPs: []string{"not-an-ip"}}); err == nil { t.Fatal("invalid allow_ips must fail") }
+if _, err := httpx.NewAdminRouter(adminDeps(config.Admin{Address: ":0"})); err == nil { t.Fatal("admin router without auth must fail") }
+}
+
+
+func TestAdminListenerCredentials(t *testing.T) {
+r, err := httpx.NewAdminRouter(adminDeps(config.Admin{Address: ":0", Pprof: true, Auth: config.AdminAuth{BasicUser: "ops", BasicPassword: "pw", BearerToken: "tok"}}))
+if err != nil { t.Fatal(err) }
+w := call(r, "/metrics", "", nil)
+if w.Code != 401 || w.Header().Get("WWW-Authenticate") == "" { t.Fatalf("want 401 with challenge, got %d", w.Code) }
+if w := call(r, "/metrics", "", func(q *http.Request) { q.SetBasicAuth("ops", "wrong") }); w.Code != 401 { t.Fatalf("wrong password: %d", w.Code) }
+if w := call(r, "/metrics", "", func(q *http.Request) { q.SetBasicAuth("ops", "pw") }); w.Code != 200 { t.Fatalf("basic: %d", w.Code) }
+if w := call(r, "/debug/pprof/cmdline", "", func(q *http.Request) { q.Header.Set("Authorization", "Bearer tok") }); w.Code != 200 { t.Fatalf("bearer pprof: %d", w.Code) }
+if w := call(r, "/health", "", func(q *http.Request) { q.Header.Set("Authorization", "Bearer nope") }); w.Code != 401 { t.Fatalf("bad bearer: %d", w.Code) }
+}
+
+
+func TestAdminListenerIPAllowList(t *testing.T) {
+r, err := httpx.NewAdminRouter(adminDeps(config.Admin{Address: ":0", Auth: config.AdminAuth{AllowIPs: []string{"10.0.0.0/8", "192.0.2.7"}}}))
+if err != nil { t.Fatal(err) }
+if w := call(r, "/health", "10.1.2.3:5000", nil); w.Code != 200 { t.Fatalf("cidr: %d", w.Code) }
+if w := call(r, "/health", "192.0.2.7:5000", nil); w.Code != 200 { t.Fatalf("single ip: %d", w.Code) }
+spoof := func(q *http.Request) { q.Header.Set("X-Forwarded-For", "10.0.0.1") }
+if w := call(r, "/health", "203.0.113.9:5000", spoof); w.Code != 403 { t.Fatalf("X-Forwarded-For must not bypass the allow-list: %d", w.Code) }
+if w := call(r, "/debug/pprof/", "10.1.2.3:5000", nil); w.Code != 404 { t.Fatalf("pprof must be off unless enabled: %d", w.Code) }
+}
+
+
+func TestPublicListenerHidesInternalEndpoints(t *testing.T) {
+public := httpx.NewRouter(adminDeps(config.Admin{Address: "127.0.0.1:9090", Auth: config.AdminAuth{BearerToken: "t"}}))
+for _, p := range []string{"/metrics", "/api/log-level", "/api/logs"} {
+if w := call(public, p, "", nil); w.Code != 404 { t.Fatalf("%s must not be on the public listener: %d", p, w.Code) }
+}
+if w := call(public, "/health", "", nil); w.Code != 200 { t.Fatalf("public /health: %d", w.Code) }
+
+single := httpx.NewRouter(adminDeps(config.Admin{}))
+if w := call(single, "/metrics", "", nil); w.Code != 200 { t.Fatalf("without an admin listener /metrics stays public: %d", w.Code) }
+if w := call(single, "/api/log-level", "", nil); w.Code != 401 { t.Fatalf("admin APIs need a JWT on the public listener: %d", w.Code) }
+}
+
+
+func TestAdminListenerRecordsEachRequestOnceInRedis(t *testing.T) {
+_, c := miniClient(t)
+st := rds.NewLogStore(c, config.LogStore{})
+a, _ := rds.NewAsyncLogger(st, config.AsyncLog{FlushIntervalMS: 10})
+a.Start()
+log, ctl, err := logger.New(config.Logging{Level: "info", File: config.LogFile{Path: "-"}}, a)
+if err != nil { t.Fatal(err) }
+defer ctl.Close()
+
+d := adminDeps(config.Admin{Address: ":0", Auth: config.AdminAuth{BearerToken: "tok"}})
+d.Log, d.RedisAsync = log, a
+r, err := httpx.NewAdminRouter(d)
+if err != nil { t.Fatal(err) }
+if w := call(r, "/health/live", "", func(req *http.Request) { req.Header.Set("Authorization", "Bearer tok") }); w.Code != 200 { t.Fatalf("live: %d", w.Code) }
+if err := a.Stop(context.Background()); err != nil { t.Fatal(err) }
+
+items, _, err := st.LoadRecent(context.Background(), 10, "")
+if err != nil { t.Fatal(err) }
+if len(items) != 1 || items[0].Message != "http" || items[0].Context["requestId"] == nil { t.Fatalf("want one access entry, got %+v", items) }
+}
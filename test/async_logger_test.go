@@ -0,0 +1,52 @@
+package test
+
+
+import (
+"context"
+"os"
+"path/filepath"
+"strings"
+"testing"
+"time"
+"example.com/api-gateway/config"
+rds "example.com/api-gateway/internal/redis"
+)
+
+
+func TestAsyncLoggerSpillsWhenRedisDown(t *testing.T) {
+spill := filepath.Join(t.TempDir(), "spill.ndjson")
+a, err := rds.NewAsyncLogger(rds.NewLogStore(deadRedis(), config.LogStore{}), config.AsyncLog{BatchSize: 4, FlushIntervalMS: 10, SpillPath: spill})
+if err != nil { t.Fatal(err) }
+a.Start()
+for i := 0; i < 10; i++ { a.Enqueue(rds.LogEntry{Level: "INFO", Message: "m"}) }
+
+ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+defer cancel()
+if err := a.Stop(ctx); err != nil { t.Fatal(err) }
+
+st := a.Stats()
+if st.Spilled != 10 || st.Failed != 0 || st.Saved != 0 { t.Fatalf("unexpected stats %+v", st) }
+b, _ := os.ReadFile(spill)
+if n := strings.Count(string(b), "\n"); n != 10 { t.Fatalf("want 10 spilled lines, got %d", n) }
+
+a.Enqueue(rds.LogEntry{Message: "after stop"})
+if a.Stats().Dropped != 1 { t.Fatal("enqueue after Stop should count a drop") }
+}
+
+
+func TestAsyncLoggerCountsFailuresWithoutSpill(t *testing.T) {
+a, _ := rds.NewAsyncLogger(rds.NewLogStore(deadRedis(), config.LogStore{}), config.AsyncLog{FlushIntervalMS: 10})
+a.Start()
+a.Enqueue(rds.LogEntry{Message: "x"})
+a.Enqueue(rds.LogEntry{Message: "y"})
+_ = a.Stop(context.Background())
+if st := a.Stats(); st.Failed != 2 { t.Fatalf("want 2 failed, got %+v", st) }
+}
+
+
+func TestAsyncLoggerDropsWhenQueueFull(t *testing.T) {
+a, _ := rds.NewAsyncLogger(rds.NewLogStore(deadRedis(), config.LogStore{}), config.AsyncLog{Buffer: 2})
+// worker not started: the queue only fills
+for i := 0; i < 5; i++ { a.Enqueue(rds.LogEntry{Message: "x"}) }
+if st := a.Stats(); st.Queued != 2 || st.Dropped != 3 { t.Fatalf("unexpected stats %+v", st) }
+}
@@ -0,0 +1,218 @@
+package test
+
+
+import (
+"context"
+"encoding/json"
+"errors"
+"net/http"
+"net/http/httptest"
+"strings"
+"testing"
+"time"
+"example.com/api-gateway/config"
+"example.com/api-gateway/internal/capture"
+"example.com/api-gateway/internal/http/middleware"
+"example.com/api-gateway/internal/redact"
+rds "example.com/api-gateway/internal/redis"
+"github.com/gin-gonic/gin"
+"go.uber.org/zap"
+)
+
+
+func captured(limit int, body string) *capture.Buffer {
+b := capture.NewBuffer(limit)
+if n, _ := b.Write([]byte(body)); n != len(body) { panic("short write") }
+return b
+}
+
+
+func TestCaptureDescribeJSON(t *testing.T) {
+out := capture.Describe("application/json; charset=utf-8", captured(1024, `{"user":"ann","password":"x"}`))
+v, ok := out["json"].(map[string]any)
+if !ok { t.Fatalf("want decoded json, got %v", out) }
+if v["user"] != "ann" || v["password"] != redact.Masked || out["bytes"] != 29 { t.Fatalf("unexpected %v", out) }
+if _, ok := out["truncated"]; ok { t.Fatal("not truncated") }
+}
+
+
+func TestCaptureDescribeTruncated(t *testing.T) {
+b := captured(8, `{"user":"ann","password":"x"}`)
+out := capture.Describe("application/json", b)
+if out["truncated"] != true || out["text"] != `{"user":` { t.Fatalf("want truncated text, got %v", out) }
+if out["bytes"] != 29 { t.Fatalf("want full size counted, got %v", out["bytes"]) }
+
+b = captured(20, `{"password":"hunter2","user":"ann"}`)
+if out := capture.Describe("application/json", b); strings.Contains(out["text"].(string), "hunter2") { t.Fatalf("password in truncated text: %v", out) }
+
+b = captured(100, "hello world")
+b.Limit(5)
+if out := capture.Describe("text/plain", b); out["text"] != "hello" || out["truncated"] != true { t.Fatalf("limit not applied: %v", out) }
+}
+
+
+func TestCaptureDescribeFormAndBinary(t *testing.T) {
+out := capture.Describe("application/x-www-form-urlencoded", captured(1024, "a=1&a=2&token=s"))
+form, ok := out["form"].(map[string]any)
+if !ok || form["a"] != "1,2" || form["token"] != redact.Masked { t.Fatalf("want form map with token masked, got %v", out) }
+
+out = capture.Describe("image/png", captured(1024, "\x89PNG\r\n"))
+if _, ok := out["omitted"]; !ok { t.Fatalf("binary must be summarized, got %v", out) }
+if _, ok := out["text"]; ok { t.Fatal("binary must not be kept") }
+
+out = capture.Describe("", capture.NewBuffer(10))
+if len(out) != 1 || out["bytes"] != 0 { t.Fatalf("empty body: %v", out) }
+}
+
+
+func TestCaptureHeaders(t *testing.T) {
+h := http.Header{"Accept": {"a", "b"}, "Authorization": {"Bearer x"}, "Cookie": {"sid=1"}, "Set-Cookie": {"sid=2"}}
+out := capture.Headers(h)
+if out["Accept"] != "a, b" { t.Fatalf("unexpected %v", out) }
+for _, k := range []string{"Authorization", "Cookie", "Set-Cookie"} {
+if out[k] != redact.Masked { t.Fatalf("%s not masked: %v", k, out) }
+}
+}
+
+
+func TestCaptureRulesSharedThroughRedis(t *testing.T) {
+mr, c := miniClient(t)
+ctx := context.Background()
+rules := capture.NewRules(c, 20*time.Millisecond, time.Minute, 64, zap.NewNop())
+rules.Start()
+defer rules.Stop()
+replica := capture.NewRules(c, 20*time.Millisecond, time.Minute, 64, zap.NewNop())
+replica.Start()
+defer replica.Stop()
+
+if _, err := rules.Add(ctx, capture.Rule{}, time.Minute); !errors.Is(err, capture.ErrNoTarget) { t.Fatalf("no target: %v", err) }
+for _, d := range []time.Duration{0, 2 * time.Minute} {
+if _, err := rules.Add(ctx, capture.Rule{Route: "/x"}, d); !errors.Is(err, capture.ErrBadDuration) { t.Fatalf("duration %v: %v", d, err) }
+}
+if rules.Active() { t.Fatal("rejected rules must not be stored") }
+
+route, err := rules.Add(ctx, capture.Rule{Route: "/users/:id", MaxBodyBytes: 1 << 20}, time.Minute)
+if err != nil { t.Fatal(err) }
+if route.MaxBodyBytes != 64 || route.ID == "" { t.Fatalf("rule not normalized: %+v", route) }
+if mr.HGet("capture:rules", route.ID) == "" { t.Fatal("rule not stored in the hash") }
+prefix, _ := rules.Add(ctx, capture.Rule{Route: "/admin/*"}, time.Minute)
+both, _ := rules.Add(ctx, capture.Rule{Route: "/orders", UserID: "u1"}, time.Minute)
+
+for _, tc := range []struct{ route, path, user, want string }{
+{"/users/:id", "/users/5", "", route.ID},
+{"/admin/logs", "/admin/logs", "u9", prefix.ID},
+{"/orders", "/orders", "u1", both.ID},
+{"/orders", "/orders", "u2", ""},
+{"/other", "/other", "u1", ""},
+} {
+got, ok := rules.Match(tc.route, tc.path, tc.user)
+if ok != (tc.want != "") || got.ID != tc.want { t.Fatalf("Match(%s, %s, %s) = %s %v, want %q", tc.route, tc.path, tc.user, got.ID, ok, tc.want) }
+}
+waitFor(t, func() bool { return len(replica.List()) == 3 })
+
+if ok, err := rules.Remove(ctx, route.ID); !ok || err != nil { t.Fatalf("remove: %v %v", ok, err) }
+if ok, _ := rules.Remove(ctx, route.ID); ok { t.Fatal("second remove should report a missing rule") }
+if _, ok := rules.Match("/users/:id", "/users/5", ""); ok { t.Fatal("removed rule still matches") }
+waitFor(t, func() bool { _, ok := replica.Match("/users/:id", "/users/5", ""); return !ok })
+
+short, _ := rules.Add(ctx, capture.Rule{UserID: "u7"}, 50*time.Millisecond)
+if _, ok := rules.Match("/any", "/any", "u7"); !ok { t.Fatal("fresh rule should match") }
+time.Sleep(60 * time.Millisecond)
+if _, ok := rules.Match("/any", "/any", "u7"); ok { t.Fatal("expired rule still matches") }
+waitFor(t, func() bool { return mr.HGet("capture:rules", short.ID) == "" }) // deleted on refresh
+}
+
+
+func TestCaptureMiddlewareRecordsOnlyMatchedRequests(t *testing.T) {
+_, c := miniClient(t)
+ctx := context.Background()
+st := rds.NewLogStore(c, config.LogStore{})
+a, _ := rds.NewAsyncLogger(st, config.AsyncLog{FlushIntervalMS: 10})
+a.Start()
+rules := capture.NewRules(c, time.Hour, time.Hour, 1024, zap.NewNop())
+rules.Start()
+defer rules.Stop()
+red, _ := redact.New(config.Redaction{Enabled: true, Fields: []config.RedactField{{Name: "password"}, {Name: "authorization"}}})
+if _, err := rules.Add(ctx, capture.Rule{Route: "/users/:id", MaxBodyBytes: 32}, time.Minute); err != nil { t.Fatal(err) }
+
+gin.SetMode(gin.TestMode)
+r := gin.New()
+r.Use(func(c *gin.Context) { c.Set("req.id", c.GetHeader("X-Request-ID")); c.Next() })
+r.Use(middleware.Capture(rules, a, red))
+r.POST("/users/:id", func(c *gin.Context) { var m map[string]any; _ = c.ShouldBindJSON(&m); c.String(200, strings.Repeat("x", 100)) })
+r.POST("/other", func(c *gin.Context) { c.String(200, "ok") })
+for id, path := range map[string]string{"rid-users": "/users/5", "rid-other": "/other"} {
+req := httptest.NewRequest("POST", path, strings.NewReader(`{"name":"ann","password":"pw"}`))
+req.Header.Set("Content-Type", "application/json")
+req.Header.Set("Authorization", "Bearer zzz")
+req.Header.Set("X-Request-ID", id)
+w := httptest.NewRecorder()
+r.ServeHTTP(w, req)
+if w.Code != 200 { t.Fatalf("%s: %d %s", path, w.Code, w.Body) }
+}
+if err := a.Stop(ctx); err != nil { t.Fatal(err) }
+
+if items, _, _ := st.ByRequestID(ctx, "rid-other"); len(items) != 0 { t.Fatalf("unmatched request captured: %+v", items) }
+items, _, err := st.ByRequestID(ctx, "rid-users")
+if err != nil || len(items) != 1 || items[0].Message != "http.capture" { t.Fatalf("captured: %+v %v", items, err) }
+raw, _ := json.Marshal(items[0])
+if strings.Contains(string(raw), "zzz") || strings.Contains(string(raw), `"pw"`) { t.Fatalf("secrets leaked: %s", raw) }
+
+var e struct {
+Context struct {
+Status  int `json:"status"`
+Request struct {
+Body struct { JSON map[string]any `json:"json"` } `json:"body"`
+} `json:"request"`
+Response struct {
+Body struct {
+Bytes     int    `json:"bytes"`
+Text      string `json:"text"`
+Truncated bool   `json:"truncated"`
+} `json:"body"`
+} `json:"response"`
+} `json:"context"`
+}
+if err := json.Unmarshal(raw, &e); err != nil { t.Fatal(err) }
+if e.Context.Status != 200 || e.Context.Request.Body.JSON["name"] != "ann" { t.Fatalf("request: %s", raw) }
+resp := e.Context.Response.Body
+if !resp.Truncated || resp.Bytes != 100 || resp.Text != strings.Repeat("x", 32) { t.Fatalf("response body not cut to the rule's limit: %+v", resp) }
+}
+
+
+func TestCaptureMasksCredentialsWithRedactionOff(t *testing.T) {
+_, c := miniClient(t)
+ctx := context.Background()
+st := rds.NewLogStore(c, config.LogStore{})
+a, _ := rds.NewAsyncLogger(st, config.AsyncLog{FlushIntervalMS: 10})
+a.Start()
+rules := capture.NewRules(c, time.Hour, time.Hour, 1024, zap.NewNop())
+rules.Start()
+defer rules.Stop()
+if _, err := rules.Add(ctx, capture.Rule{Route: "/auth/login"}, time.Minute); err != nil { t.Fatal(err) }
+
+gin.SetMode(gin.TestMode)
+r := gin.New()
+r.Use(func(c *gin.Context) { c.Set("req.id", "rid-login"); c.Next() })
+r.Use(middleware.Capture(rules, a, nil)) // redaction.enabled: false
+r.POST("/auth/login", func(c *gin.Context) {
+var m map[string]any
+_ = c.ShouldBindJSON(&m)
+c.Header("Set-Cookie", "session=s3cr3t")
+c.JSON(200, gin.H{"token": "tok-123"})
+})
+req := httptest.NewRequest("POST", "/auth/login", strings.NewReader(`{"email":"a@b.c","password":"pw-456"}`))
+req.Header.Set("Content-Type", "application/json")
+req.Header.Set("Authorization", "Bearer zzz")
+req.Header.Set("Cookie", "session=old")
+r.ServeHTTP(httptest.NewRecorder(), req)
+if err := a.Stop(ctx); err != nil { t.Fatal(err) }
+
+items, _, err := st.ByRequestID(ctx, "rid-login")
+if err != nil || len(items) != 1 { t.Fatalf("captured: %+v %v", items, err) }
+raw, _ := json.Marshal(items[0])
+for _, secret := range []string{"zzz", "session=old", "s3cr3t", "pw-456", "tok-123"} {
+if strings.Contains(string(raw), secret) { t.Fatalf("%q stored without redaction: %s", secret, raw) }
+}
+if !strings.Contains(string(raw), "a@b.c") { t.Fatalf("non-credential fields must be kept: %s", raw) }
+}
@@ -0,0 +1,135 @@
+package test
+
+
+import (
+"context"
+"testing"
+"time"
+"example.com/api-gateway/config"
+"example.com/api-gateway/internal/rate"
+"go.uber.org/zap"
+)
+
+
+func TestConcurrencyLimiterQueueAndShed(t *testing.T) {
+cfg := config.Concurrency{Enabled: true, MaxInFlight: 1, QueueSize: 1, QueueTimeoutMS: 500}
+l := rate.NewConcurrencyLimiter(cfg, zap.NewNop())
+ctx := context.Background()
+
+// 1st: takes the only slot
+release1, _, ok := l.Acquire(ctx, "/users")
+if !ok { t.Fatal("want acquire #1") }
+
+// 2nd: queues until #1 releases
+got := make(chan bool, 1)
+go func() {
+release2, _, ok := l.Acquire(ctx, "/users")
+if ok { release2() }
+got <- ok
+}()
+time.Sleep(50 * time.Millisecond) // let #2 enter the queue
+
+// 3rd: queue is full -> shed immediately with a retry hint
+start := time.Now()
+if _, retry, ok := l.Acquire(ctx, "/users"); ok || retry <= 0 { t.Fatalf("want shed #3, got ok=%v retry=%v", ok, retry) }
+if time.Since(start) > 100*time.Millisecond { t.Fatal("shed should not wait for the queue timeout") }
+
+release1()
+if !<-got { t.Fatal("want queued #2 to get the slot after release") }
+}
+
+
+func TestConcurrencyLimiterQueueTimeout(t *testing.T) {
+cfg := config.Concurrency{Enabled: true, MaxInFlight: 1, QueueSize: 4, QueueTimeoutMS: 50}
+l := rate.NewConcurrencyLimiter(cfg, zap.NewNop())
+
+release, _, _ := l.Acquire(context.Background(), "k")
+defer release()
+if _, _, ok := l.Acquire(context.Background(), "k"); ok { t.Fatal("want timeout while slot is held") }
+}
+
+
+// holdAll takes slots for key until one is refused (waiting at most 10ms
+// for it) and returns the releases of the ones it got.
+func holdAll(l rate.ConcurrencyLimiter, key string) []func() {
+var held []func()
+for len(held) < 100 {
+ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+release, _, ok := l.Acquire(ctx, key)
+cancel()
+if !ok { return held }
+held = append(held, release)
+}
+return held
+}
+
+
+func TestConcurrencyLimiterAdaptive(t *testing.T) {
+cfg := config.Concurrency{Enabled: true, MaxInFlight: 4, MinInFlight: 2, QueueSize: 2, QueueTimeoutMS: 1000, Adaptive: true, TargetLatencyMS: 50}
+l := rate.NewConcurrencyLimiter(cfg, zap.NewNop())
+bg := context.Background()
+slow := func() { release, _, _ := l.Acquire(bg, "k"); time.Sleep(60 * time.Millisecond); release() }
+fast := func() { release, _, _ := l.Acquire(bg, "k"); release() }
+
+if held := holdAll(l, "k"); len(held) != 4 { t.Fatalf("starts at max_in_flight: %d", len(held)) } else { for _, r := range held { r() } }
+
+// Slow releases shrink the cap x0.9 each; 10 of them would take 4 to 1.4,
+// but it stops at min_in_flight.
+for i := 0; i < 10; i++ { slow() }
+held := holdAll(l, "k")
+if len(held) != 2 { t.Fatalf("after slow releases: %d slots, want the floor of 2", len(held)) }
+time.Sleep(60 * time.Millisecond)
+for _, r := range held { r() } // slow again: stays at the floor
+
+// Fast releases add 1/limit each: 2 -> 2.5 -> 2.9.
+fast(); fast()
+// With both slots held and two waiters queued, one fast release frees a
+// slot for the first waiter and lifts the cap to 3, which admits the second.
+a, _, _ := l.Acquire(bg, "k")
+b, _, _ := l.Acquire(bg, "k")
+admitted := make(chan func(), 2)
+for i := 0; i < 2; i++ {
+go func() { if r, _, ok := l.Acquire(bg, "k"); ok { admitted <- r } }()
+}
+time.Sleep(20 * time.Millisecond) // let both queue
+a()
+var waiters []func()
+for i := 0; i < 2; i++ {
+select {
+case r := <-admitted: waiters = append(waiters, r)
+case <-time.After(500 * time.Millisecond): t.Fatalf("raised cap admitted %d of 2 queued waiters", i)
+}
+}
+b()
+for _, r := range waiters { r() }
+
+// More fast releases grow the cap back to max_in_flight, not past it.
+for i := 0; i < 10; i++ { fast() }
+if held := holdAll(l, "k"); len(held) != 4 { t.Fatalf("after fast releases: %d slots, want 4", len(held)) } else { for _, r := range held { r() } }
+}
+
+
+func TestConcurrencyLimiterPerKeyCap(t *testing.T) {
+cfg := config.Concurrency{Enabled: true, MaxInFlight: 10, PerKeyInFlight: 1, QueueSize: 1, QueueTimeoutMS: 1000}
+l := rate.NewConcurrencyLimiter(cfg, zap.NewNop())
+bg := context.Background()
+
+first, _, ok := l.Acquire(bg, "/users")
+if !ok { t.Fatal("want first /users admitted") }
+second := make(chan func(), 1)
+go func() { if r, _, ok := l.Acquire(bg, "/users"); ok { second <- r } }()
+select {
+case <-second: t.Fatal("second /users must queue behind the per-key cap")
+case <-time.After(30 * time.Millisecond):
+}
+
+other, _, ok := l.Acquire(bg, "/orders")
+if !ok { t.Fatal("another key must not wait for /users") }
+other()
+
+first()
+select {
+case r := <-second: r()
+case <-time.After(500 * time.Millisecond): t.Fatal("queued /users not admitted after release")
+}
+}
@@ -0,0 +1,165 @@
+package test
+
+
+import (
+"encoding/json"
+"errors"
+"net/http"
+"net/http/httptest"
+"os"
+"path/filepath"
+"reflect"
+"testing"
+"time"
+"example.com/api-gateway/config"
+"example.com/api-gateway/internal/auth"
+httpx "example.com/api-gateway/internal/http"
+"example.com/api-gateway/internal/logger"
+"example.com/api-gateway/internal/reload"
+"go.uber.org/zap"
+"go.uber.org/zap/zapcore"
+)
+
+
+const baseYAML = "server:\n  port: 8080\nsecurity:\n  jwt:\n    secret: one\n    ttl_minutes: 5\nlogging:\n  level: info\n"
+
+
+// writeConfig writes body plus the database section every valid config needs.
+func writeConfig(t *testing.T, path, body string) {
+t.Helper()
+if err := os.WriteFile(path, []byte(body+"database:\n  driver: sqlite\n  dsn: test.db\n"), 0o644); err != nil { t.Fatal(err) }
+}
+
+
+// newReloader starts from baseYAML with a log level stage and a JWT stage counting commits.
+func newReloader(t *testing.T) (string, *reload.Reloader, *logger.Levels, *auth.Keys) {
+t.Helper()
+t.Setenv("JWT_SECRET", "")
+path := filepath.Join(t.TempDir(), "config.yaml")
+writeConfig(t, path, baseYAML)
+cfg, err := config.LoadFile(path)
+if err != nil { t.Fatal(err) }
+levels, _ := logger.NewLevels(cfg.Logging.Level, nil)
+keys := auth.NewKeys(cfg.Security.JWT)
+r := reload.New([]string{path}, cfg, config.Sources{Files: []string{path}}.Load, zap.NewNop())
+r.Register(reload.Stage{Name: "log levels", Paths: []string{"logging.level", "logging.levels"}, Prepare: func(_, next config.Root) (func(), error) {
+if _, err := logger.NewLevels(next.Logging.Level, next.Logging.Levels); err != nil { return nil, err }
+return func() { _ = levels.Apply(next.Logging.Level, next.Logging.Levels) }, nil
+}})
+r.Register(reload.Stage{Name: "jwt", Paths: []string{"security.jwt"}, Prepare: func(_, next config.Root) (func(), error) {
+if next.Security.JWT.Secret == "" { return nil, errors.New("empty secret") }
+return func() { keys.Rotate(next.Security.JWT) }, nil
+}})
+return path, r, levels, keys
+}
+
+
+func TestConfigDiffAndAssign(t *testing.T) {
+a, b := config.Root{}, config.Root{}
+b.Server.Port = 9090
+b.Server.CORS.AllowedOrigins = []string{"https://app.example.com"}
+b.Logging.Levels = map[string]string{"rate": "debug"}
+got := config.Diff(a, b)
+want := []string{"server.port", "server.cors.allowed_origins", "logging.levels"}
+if !reflect.DeepEqual(got, want) { t.Fatalf("diff = %v, want %v", got, want) }
+config.Assign(&a, b, "server.cors.allowed_origins")
+if a.Server.Port != 0 || len(a.Server.CORS.AllowedOrigins) != 1 { t.Fatalf("assign copied the wrong fields: %+v", a.Server) }
+}
+
+
+func TestReloadAppliesReloadableAndReportsRestart(t *testing.T) {
+path, r, levels, _ := newReloader(t)
+if res := r.Reload("test"); res.Outcome != reload.Unchanged { t.Fatalf("same file: %+v", res) }
+
+writeConfig(t, path, "server:\n  port: 9090\nsecurity:\n  jwt:\n    secret: one\n    ttl_minutes: 5\nlogging:\n  level: debug\n  levels:\n    rate: error\n")
+res := r.Reload("test")
+if res.Outcome != reload.Applied || !reflect.DeepEqual(res.Applied, []string{"logging.level", "logging.levels"}) { t.Fatalf("%+v", res) }
+if !reflect.DeepEqual(res.RestartRequired, []string{"server.port"}) { t.Fatalf("restart required: %v", res.RestartRequired) }
+if levels.For("http") != zapcore.DebugLevel || levels.For("rate.memory") != zapcore.ErrorLevel { t.Fatal("levels not applied") }
+
+st := r.Status()
+if st.Version != 1 || st.Last == nil || st.Last.Outcome != reload.Applied { t.Fatalf("status: %+v", st) }
+// Still pending on the next attempt: the running port did not change.
+if res := r.Reload("test"); res.Outcome != reload.Unchanged || len(res.RestartRequired) != 1 { t.Fatalf("%+v", res) }
+}
+
+
+func TestReloadRejectsInvalidConfig(t *testing.T) {
+path, r, levels, keys := newReloader(t)
+
+writeConfig(t, path, "logging: [not a map\n")
+if res := r.Reload("test"); res.Outcome != reload.Rejected || res.Error == "" { t.Fatalf("broken YAML: %+v", res) }
+
+// One stage accepts, the other rejects: neither commits.
+writeConfig(t, path, "server:\n  port: 8080\nsecurity:\n  jwt:\n    secret: \"\"\n    ttl_minutes: 5\nlogging:\n  level: debug\n")
+res := r.Reload("test")
+if res.Outcome != reload.Rejected || len(res.Applied) != 0 { t.Fatalf("%+v", res) }
+if levels.For("x") != zapcore.InfoLevel || keys.Config().Secret != "one" { t.Fatal("a rejected reload changed the running config") }
+
+writeConfig(t, path, "server:\n  port: 8080\nsecurity:\n  jwt:\n    secret: one\n    ttl_minutes: 5\nlogging:\n  level: loud\n")
+if res := r.Reload("test"); res.Outcome != reload.Rejected { t.Fatalf("unknown level: %+v", res) }
+if st := r.Status(); st.Version != 0 || st.Last.Outcome != reload.Rejected { t.Fatalf("%+v", st) }
+}
+
+
+func TestReloadOnFileChange(t *testing.T) {
+path, r, levels, _ := newReloader(t)
+if err := r.Start(); err != nil { t.Fatal(err) }
+defer r.Stop()
+// Replace the file the way editors and ConfigMaps do.
+tmp := path + ".tmp"
+writeConfig(t, tmp, "server:\n  port: 8080\nsecurity:\n  jwt:\n    secret: one\n    ttl_minutes: 5\nlogging:\n  level: warn\n")
+if err := os.Rename(tmp, path); err != nil { t.Fatal(err) }
+deadline := time.Now().Add(5 * time.Second)
+for levels.For("x") != zapcore.WarnLevel {
+if time.Now().After(deadline) { t.Fatalf("file change not applied: %+v", r.Status().Last) }
+time.Sleep(20 * time.Millisecond)
+}
+if st := r.Status(); st.Last.Trigger != "file" { t.Fatalf("trigger: %+v", st.Last) }
+}
+
+
+func TestJWTKeyRotationKeepsOldTokens(t *testing.T) {
+old := config.JWT{Secret: "one", TTLMinutes: 5}
+keys := auth.NewKeys(old)
+tok, err := keys.Sign("u1", "user")
+if err != nil { t.Fatal(err) }
+if !keys.Rotate(config.JWT{Secret: "two", TTLMinutes: 5}) { t.Fatal("secret change must rotate") }
+if cl, err := keys.Parse(tok); err != nil || cl.Sub != "u1" { t.Fatalf("token from the retired secret: %v", err) }
+fresh, _ := keys.Sign("u2", "user")
+if _, err := auth.Parse(config.JWT{Secret: "two"}, fresh); err != nil { t.Fatalf("new tokens use the new secret: %v", err) }
+forged, _ := auth.Sign(config.JWT{Secret: "other", TTLMinutes: 5}, "u3", "admin")
+if _, err := keys.Parse(forged); err == nil { t.Fatal("unknown secret accepted") }
+
+short := auth.NewKeys(config.JWT{Secret: "one"}) // TTL 0: nothing it signed can still be valid
+tok, _ = auth.Sign(config.JWT{Secret: "one", TTLMinutes: 5}, "u1", "user")
+short.Rotate(config.JWT{Secret: "two"})
+if _, err := short.Parse(tok); err == nil { t.Fatal("retired secret must expire with its token TTL") }
+}
+
+
+func TestConfigStatusEndpoint(t *testing.T) {
+_, r, _, _ := newReloader(t)
+r.Reload("test")
+cfg := config.Root{}
+cfg.Security.JWT = config.JWT{Secret: "s", TTLMinutes: 5}
+router := httpx.NewRouter(httpx.Deps{Config: cfg, Log: zap.NewNop(), Reloader: r})
+tok, _ := auth.Sign(cfg.Security.JWT, "u1", "admin")
+req := httptest.NewRequest(http.MethodGet, "/api/config/status", nil)
+req.Header.Set("Authorization", "Bearer "+tok)
+w := httptest.NewRecorder()
+router.ServeHTTP(w, req)
+var st reload.Status
+if w.Code != 200 || json.Unmarshal(w.Body.Bytes(), &st) != nil { t.Fatalf("%d %s", w.Code, w.Body.String()) }
+if st.Last == nil || st.Last.Outcome != reload.Unchanged || len(st.Reloadable) != 3 { t.Fatalf("%+v", st) }
+}
+
+
+func TestSwappableHandler(t *testing.T) {
+s := httpx.NewSwappable(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) { w.WriteHeader(201) }))
+w := httptest.NewRecorder(); s.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+if w.Code != 201 { t.Fatal(w.Code) }
+s.Swap(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) { w.WriteHeader(202) }))
+w = httptest.NewRecorder(); s.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+if w.Code != 202 { t.Fatal(w.Code) }
+}
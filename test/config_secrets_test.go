@@ -0,0 +1,144 @@
+package test
+
+
+import (
+"errors"
+"os"
+"path/filepath"
+"strings"
+"testing"
+"time"
+"example.com/api-gateway/config"
+"example.com/api-gateway/internal/auth"
+"example.com/api-gateway/internal/reload"
+"go.uber.org/zap"
+)
+
+
+// secretConfig writes a config whose JWT secret, DSN (opted in) and Redis
+// password come from files in dir, plus extra keys under secrets.
+func secretConfig(t *testing.T, dir, extra string) config.Sources {
+t.Helper()
+for name, v := range map[string]string{"jwt": "jwt-1\n", "dsn": "db.sqlite", "redis": "r3dis"} {
+if err := os.WriteFile(filepath.Join(dir, name), []byte(v), 0o600); err != nil { t.Fatal(err) }
+}
+body := "server:\n  port: 8080\nsecurity:\n  jwt:\n    secret: file:" + filepath.Join(dir, "jwt") + "\n    ttl_minutes: 15\nredis:\n  password: file:" + filepath.Join(dir, "redis") + "\ndatabase:\n  driver: sqlite\n  dsn: file:" + filepath.Join(dir, "dsn") + "\nsecrets:\n  dsn: true\n" + extra
+path := filepath.Join(dir, "config.yaml")
+if err := os.WriteFile(path, []byte(body), 0o644); err != nil { t.Fatal(err) }
+return config.Sources{Files: []string{path}}
+}
+
+
+func TestSecretFileProvider(t *testing.T) {
+t.Setenv("JWT_SECRET", "")
+dir := t.TempDir()
+cfg, err := secretConfig(t, dir, "").Load()
+if err != nil { t.Fatal(err) }
+if cfg.Security.JWT.Secret != "jwt-1" || cfg.Database.DSN != "db.sqlite" || cfg.Redis.Password != "r3dis" { t.Fatalf("%+v", cfg) }
+
+os.Remove(filepath.Join(dir, "redis"))
+_, err = config.Sources{Files: []string{filepath.Join(dir, "config.yaml")}}.Load()
+if e := fieldErrors(t, err)["redis.password"]; e.Line != 8 || !strings.Contains(e.Msg, "no such file") { t.Fatalf("%+v", e) }
+
+// scheme-like values that are not secrets stay as they are
+t.Setenv("GATEWAY_DATABASE_DSN", "root:pw@tcp(db:3306)/app")
+os.WriteFile(filepath.Join(dir, "redis"), []byte("x"), 0o600)
+if cfg, err = (config.Sources{Files: []string{filepath.Join(dir, "config.yaml")}}).Load(); err != nil || cfg.Database.DSN != "root:pw@tcp(db:3306)/app" { t.Fatalf("%v %q", err, cfg.Database.DSN) }
+}
+
+
+func TestSecretReferencesOnlyInSecretFields(t *testing.T) {
+t.Setenv("JWT_SECRET", "")
+t.Setenv("GW_TEST_TOKEN", "admin-t0ken")
+dir := t.TempDir()
+path := filepath.Join(dir, "config.yaml")
+body := "server:\n  port: 8080\n  admin:\n    address: 127.0.0.1:9090\n    auth:\n      bearer_token: env:GW_TEST_TOKEN\nsecurity:\n  jwt:\n    issuer: env:GW_TEST_TOKEN\n    secret: s3cret\n    ttl_minutes: 15\ndatabase:\n  driver: sqlite\n  dsn: \"file:gateway.db?cache=shared&_fk=1\"\n"
+os.WriteFile(path, []byte(body), 0o644)
+cfg, err := config.Sources{Files: []string{path}}.Load()
+if err != nil { t.Fatal(err) }
+if cfg.Database.DSN != "file:gateway.db?cache=shared&_fk=1" { t.Fatalf("sqlite dsn resolved: %q", cfg.Database.DSN) }
+if cfg.Security.JWT.Issuer != "env:GW_TEST_TOKEN" { t.Fatalf("non-secret field resolved: %q", cfg.Security.JWT.Issuer) }
+if cfg.Server.Admin.Auth.BearerToken != "admin-t0ken" { t.Fatalf("bearer token: %q", cfg.Server.Admin.Auth.BearerToken) }
+
+// with secrets.dsn the DSN is a reference
+os.WriteFile(path, []byte(body+"secrets:\n  dsn: true\n"), 0o644)
+_, err = config.Sources{Files: []string{path}}.Load()
+if e := fieldErrors(t, err)["database.dsn"]; !strings.Contains(e.Msg, "no such file") { t.Fatalf("%+v", e) }
+}
+
+
+func TestSecretEnvAndSealedProviders(t *testing.T) {
+t.Setenv("JWT_SECRET", "")
+dir := t.TempDir()
+key, err := config.NewSecretKey()
+if err != nil { t.Fatal(err) }
+keyFile := filepath.Join(dir, "gateway.key")
+os.WriteFile(keyFile, []byte(key+"\n"), 0o600)
+k, err := config.ReadSecretKey(keyFile)
+if err != nil { t.Fatal(err) }
+sealed, _ := config.SealSecret(k, []byte("sealed-jwt"))
+os.WriteFile(filepath.Join(dir, "jwt.sealed"), []byte(sealed), 0o644)
+
+src := secretConfig(t, dir, "  key_file: "+keyFile+"\n")
+t.Setenv("GW_TEST_REDIS_PW", "from-env")
+src.Set = []string{"security.jwt.secret=enc:" + filepath.Join(dir, "jwt.sealed"), "redis.password=env:GW_TEST_REDIS_PW"}
+cfg, err := src.Load()
+if err != nil { t.Fatal(err) }
+if cfg.Security.JWT.Secret != "sealed-jwt" || cfg.Redis.Password != "from-env" { t.Fatalf("%+v", cfg) }
+
+// another key cannot open it; without a key enc: is refused
+other, _ := config.NewSecretKey()
+os.WriteFile(keyFile, []byte(other), 0o600)
+if e := fieldErrors(t, func() error { _, err := src.Load(); return err }())["security.jwt.secret"]; !strings.Contains(e.Msg, "wrong key") || e.Source != "--set" { t.Fatalf("%+v", e) }
+src = secretConfig(t, dir, "")
+src.Set = []string{"security.jwt.secret=enc:" + filepath.Join(dir, "jwt.sealed")}
+if e := fieldErrors(t, func() error { _, err := src.Load(); return err }())["security.jwt.secret"]; !strings.Contains(e.Msg, "secrets.key_file") { t.Fatalf("%+v", e) }
+}
+
+
+// vault is a custom SecretProvider.
+type vault map[string]string
+
+func (vault) Scheme() string { return "vault" }
+func (v vault) Resolve(ref string) (string, error) { if s, ok := v[ref]; ok { return s, nil }; return "", errors.New("no such secret") }
+
+
+func TestSecretCustomProvider(t *testing.T) {
+t.Setenv("JWT_SECRET", "")
+src := secretConfig(t, t.TempDir(), "")
+src.Providers = []config.SecretProvider{vault{"gateway/jwt": "from-vault"}}
+src.Set = []string{"security.jwt.secret=vault:gateway/jwt"}
+cfg, err := src.Load()
+if err != nil || cfg.Security.JWT.Secret != "from-vault" { t.Fatalf("%v %+v", err, cfg.Security.JWT) }
+}
+
+
+func TestSecretRefreshRotatesJWTKey(t *testing.T) {
+t.Setenv("JWT_SECRET", "")
+dir := t.TempDir()
+src := secretConfig(t, dir, "")
+cfg, err := src.Load()
+if err != nil { t.Fatal(err) }
+keys := auth.NewKeys(cfg.Security.JWT)
+old, _ := keys.Sign("u1", "user")
+
+r := reload.New(src.Paths(""), cfg, src.Load, zap.NewNop())
+r.Register(reload.Stage{Name: "jwt keys", Paths: []string{"security.jwt"}, Prepare: func(_, next config.Root) (func(), error) {
+return func() { keys.Rotate(next.Security.JWT) }, nil
+}})
+r.RefreshEvery(20 * time.Millisecond)
+if err := r.Start(); err != nil { t.Fatal(err) }
+defer r.Stop()
+time.Sleep(60 * time.Millisecond)
+if st := r.Status(); st.Last != nil { t.Fatalf("quiet refreshes recorded: %+v", st.Last) }
+
+// the secret file is rotated in place (not a config file: no watch event)
+os.WriteFile(filepath.Join(dir, "jwt"), []byte("jwt-2"), 0o600)
+deadline := time.Now().Add(3 * time.Second)
+for keys.Config().Secret != "jwt-2" {
+if time.Now().After(deadline) { t.Fatalf("rotated secret not picked up: %+v", r.Status().Last) }
+time.Sleep(10 * time.Millisecond)
+}
+if _, err := keys.Parse(old); err != nil { t.Fatalf("token signed before the rotation: %v", err) }
+if st := r.Status(); st.Version != 1 || st.Last.Trigger != "refresh" { t.Fatalf("%+v", st) }
+}
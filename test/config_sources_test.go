@@ -0,0 +1,99 @@
+package test
+
+
+import (
+"os"
+"path/filepath"
+"reflect"
+"strings"
+"testing"
+"example.com/api-gateway/config"
+)
+
+
+// writeFiles writes name→body into a temp dir and returns the dir.
+func writeFiles(t *testing.T, files map[string]string) string {
+t.Helper()
+t.Setenv("JWT_SECRET", "")
+dir := t.TempDir()
+for name, body := range files {
+if err := os.WriteFile(filepath.Join(dir, name), []byte(body), 0o644); err != nil { t.Fatal(err) }
+}
+return dir
+}
+
+
+func TestConfigLayersAndEnvironmentOverlay(t *testing.T) {
+dir := writeFiles(t, map[string]string{
+"config.yaml": "environment: staging\n" + validYAML + "logging:\n  levels: { http: info }\n",
+"config.staging.yaml": "server:\n  port: 9000\nlogging:\n  levels: { rate: debug }\n",
+"local.yaml": "rate_limit:\n  requests_per_minute: 7\n",
+})
+base, local := filepath.Join(dir, "config.yaml"), filepath.Join(dir, "local.yaml")
+src := config.Sources{Files: []string{base, local}}
+cfg, err := src.Load()
+if err != nil { t.Fatal(err) }
+if cfg.Server.Port != 9000 || cfg.Security.JWT.Secret != "s3cret" || cfg.RateLimit.RequestsPerMinute != 7 { t.Fatalf("%+v", cfg) }
+if !reflect.DeepEqual(cfg.Logging.Levels, map[string]string{"http": "info", "rate": "debug"}) { t.Fatalf("maps merge: %v", cfg.Logging.Levels) }
+if got := src.Paths("staging"); !reflect.DeepEqual(got, []string{base, filepath.Join(dir, "config.staging.yaml"), local}) { t.Fatalf("%v", got) }
+
+// GATEWAY_ENVIRONMENT picks the overlay; a missing overlay is fine
+t.Setenv("GATEWAY_ENVIRONMENT", "development")
+if cfg, err = src.Load(); err != nil || cfg.Server.Port != 8080 || cfg.Environment != "development" { t.Fatalf("%v %+v", err, cfg.Server) }
+
+// errors name the layer they come from
+os.WriteFile(local, []byte("# local tweaks\nserver:\n  port: 0\n"), 0o644)
+e := fieldErrors(t, func() error { _, err := src.Load(); return err }())["server.port"]
+if e.Source != local || e.Line != 3 { t.Fatalf("%+v", e) }
+}
+
+
+func TestConfigEnvAndSetOverrides(t *testing.T) {
+dir := writeFiles(t, map[string]string{"config.yaml": validYAML})
+src := config.Sources{Files: []string{filepath.Join(dir, "config.yaml")}}
+t.Setenv("GATEWAY_SERVER_PORT", "9090")
+t.Setenv("GATEWAY_SERVER_CORS_ALLOWED_ORIGINS", "https://a.example, https://b.example")
+t.Setenv("GATEWAY_LOGGING_LEVELS", "{http: debug}")
+t.Setenv("GATEWAY_TRACING_SAMPLE_RATIO", "0")
+t.Setenv("JWT_SECRET", "legacy")
+cfg, err := src.Load()
+if err != nil { t.Fatal(err) }
+if r := cfg.Tracing.SampleRatio; r == nil || *r != 0 { t.Fatalf("sample_ratio 0 must be kept, not read as unset: %v", r) }
+if cfg.Server.Port != 9090 || cfg.Security.JWT.Secret != "legacy" || cfg.Logging.Levels["http"] != "debug" { t.Fatalf("%+v", cfg) }
+if !reflect.DeepEqual(cfg.Server.CORS.AllowedOrigins, []string{"https://a.example", "https://b.example"}) { t.Fatalf("%q", cfg.Server.CORS.AllowedOrigins) }
+
+// GATEWAY_ beats the legacy name, --set beats both
+t.Setenv("GATEWAY_SECURITY_JWT_SECRET", "new")
+src.Set = []string{"server.port=9191", "logging.levels.rate=warn"}
+if cfg, err = src.Load(); err != nil || cfg.Security.JWT.Secret != "new" || cfg.Server.Port != 9191 || cfg.Logging.Levels["rate"] != "warn" { t.Fatalf("%v %+v", err, cfg) }
+
+t.Setenv("GATEWAY_SERVER_PORT", "abc")
+src.Set = []string{"server.prot=1", "oops"}
+_, err = src.Load()
+got := fieldErrors(t, err)
+if e := got["server.port"]; e.Source != "GATEWAY_SERVER_PORT" || !strings.Contains(e.Msg, "cannot unmarshal") { t.Fatalf("%+v", e) }
+if e := got["server.prot"]; e.Source != "--set" || e.Msg != "unknown field" { t.Fatalf("%+v", e) }
+if !strings.Contains(err.Error(), `--set: "oops" is not path=value`) { t.Fatalf("%v", err) }
+}
+
+
+func TestConfigReferences(t *testing.T) {
+dir := writeFiles(t, map[string]string{"jwt.key": "from-file\n"})
+body := strings.NewReplacer("port: 8080", "port: ${GW_TEST_PORT}", "s3cret", "file:"+filepath.Join(dir, "jwt.key"), "app.db", "${GW_TEST_DB:-fallback.db}").Replace(validYAML)
+os.WriteFile(filepath.Join(dir, "config.yaml"), []byte(body), 0o644)
+src := config.Sources{Files: []string{filepath.Join(dir, "config.yaml")}}
+t.Setenv("GW_TEST_PORT", "7070")
+cfg, err := src.Load()
+if err != nil { t.Fatal(err) }
+if cfg.Server.Port != 7070 || cfg.Security.JWT.Secret != "from-file" || cfg.Database.DSN != "fallback.db" { t.Fatalf("%+v", cfg) }
+
+t.Setenv("GW_TEST_PORT", "")
+_, err = src.Load()
+if e := fieldErrors(t, err)["server.port"]; e.Line != 2 || e.Msg != "${GW_TEST_PORT} is not set" { t.Fatalf("%+v", e) }
+
+// references work in overrides too
+t.Setenv("GW_TEST_PORT", "7070")
+t.Setenv("GATEWAY_REDIS_PASSWORD", "file:"+filepath.Join(dir, "missing"))
+_, err = src.Load()
+if _, ok := fieldErrors(t, err)["redis.password"]; !ok { t.Fatalf("%v", err) }
+}
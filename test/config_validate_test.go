@@ -0,0 +1,84 @@
+package test
+
+
+import (
+"bytes"
+"errors"
+"os"
+"path/filepath"
+"strings"
+"testing"
+"example.com/api-gateway/config"
+)
+
+
+func loadYAML(t *testing.T, body string) error {
+t.Helper()
+t.Setenv("JWT_SECRET", "")
+path := filepath.Join(t.TempDir(), "config.yaml")
+if err := os.WriteFile(path, []byte(body), 0o644); err != nil { t.Fatal(err) }
+_, err := config.LoadFile(path)
+return err
+}
+
+
+func fieldErrors(t *testing.T, err error) map[string]config.FieldError {
+t.Helper()
+var errs config.Errors
+if !errors.As(err, &errs) { t.Fatalf("want config.Errors, got %v", err) }
+out := map[string]config.FieldError{}
+for _, e := range errs { out[e.Path] = e }
+return out
+}
+
+
+const validYAML = "server:\n  port: 8080\nsecurity:\n  jwt:\n    secret: s3cret\n    ttl_minutes: 15\ndatabase:\n  driver: sqlite\n  dsn: app.db\n"
+
+
+func TestShippedConfigIsValid(t *testing.T) {
+t.Setenv("JWT_SECRET", "")
+if _, err := config.LoadFile("../config/config.yaml"); err != nil { t.Fatalf("config/config.yaml: %v", err) }
+if err := loadYAML(t, validYAML); err != nil { t.Fatal(err) }
+}
+
+
+func TestConfigUnknownKeysAreErrors(t *testing.T) {
+err := loadYAML(t, validYAML+"logging:\n  levle: debug\n  sinks:\n    - type: file\n      dirr: logs\n")
+got := fieldErrors(t, err)
+if e, ok := got["logging.levle"]; !ok || e.Line != 11 || e.Msg != "unknown field" { t.Fatalf("%v", err) }
+if _, ok := got["logging.sinks[0].dirr"]; !ok { t.Fatalf("nested typo not reported: %v", err) }
+if _, ok := fieldErrors(t, loadYAML(t, "server:\n  port: 0\n  cros: {}\n"))["server.port"]; !ok { t.Fatal("unknown keys hid the other problems") }
+}
+
+
+func TestConfigValidateReportsEveryProblem(t *testing.T) {
+err := loadYAML(t, "server:\n  port: 0\nsecurity:\n  jwt:\n    secret: \"\"\nrate_limit:\n  enabled: true\n  strategy: token\n  requests_per_minute: 60\ndatabase:\n  driver: oracle\n  dsn: x\nlogging:\n  level: loud\n  sinks:\n    - type: http\n      http:\n        url: not-a-url\n")
+got := fieldErrors(t, err)
+for _, path := range []string{"server.port", "security.jwt.secret", "security.jwt.ttl_minutes", "rate_limit.strategy", "database.driver", "logging.level", "logging.sinks[0].http.url"} {
+if _, ok := got[path]; !ok { t.Errorf("missing error for %s in:\n%v", path, err) }
+}
+if e := got["rate_limit.strategy"]; e.Line != 8 || !strings.Contains(e.Error(), `"token" is not one of memory|redis|hybrid`) { t.Fatalf("%q", e.Error()) }
+if e := got["server.port"]; e.Error() != "server.port (line 2): must be between 1 and 65535" { t.Fatalf("%q", e.Error()) }
+}
+
+
+func TestConfigRefusesDefaultSecretInProduction(t *testing.T) {
+prod := strings.Replace(validYAML, "s3cret", config.DefaultJWTSecret, 1)
+if err := loadYAML(t, prod); err != nil { t.Fatalf("default secret is fine outside production: %v", err) }
+got := fieldErrors(t, loadYAML(t, "environment: production\n"+prod))
+if _, ok := got["security.jwt.secret"]; !ok { t.Fatalf("%v", got) }
+if err := loadYAML(t, "environment: production\n"+validYAML); err != nil { t.Fatal(err) }
+if _, ok := fieldErrors(t, loadYAML(t, "environment: prod\n"+validYAML))["environment"]; !ok { t.Fatal("unknown environment accepted") }
+hashing := validYAML+"logging:\n  redaction:\n    enabled: true\n    fields: [{name: ip, mode: hash}]\n"
+if _, ok := fieldErrors(t, loadYAML(t, hashing))["logging.redaction.hash_key"]; !ok { t.Fatal("empty hash key accepted") }
+if _, ok := fieldErrors(t, loadYAML(t, "environment: production\n"+hashing))["logging.redaction.hash_key"]; !ok { t.Fatal("empty hash key accepted in production") }
+if err := loadYAML(t, hashing+"    hash_key: k\n"); err != nil { t.Fatal(err) }
+}
+
+
+func TestConfigErrorsMessage(t *testing.T) {
+errs := config.Errors{{Path: "server.port", Line: 3, Msg: "bad"}, {Path: "database.dsn", Msg: "must be set"}, {Msg: "yaml: line 1: oops"}}
+var b bytes.Buffer
+b.WriteString(errs.Error())
+if b.String() != "server.port (line 3): bad\ndatabase.dsn: must be set\nyaml: line 1: oops" { t.Fatalf("%q", b.String()) }
+}
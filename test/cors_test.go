@@ -0,0 +1,111 @@
+package test
+
+
+import (
+"net/http"
+"net/http/httptest"
+"strings"
+"testing"
+"example.com/api-gateway/config"
+"example.com/api-gateway/internal/http/middleware"
+"github.com/gin-gonic/gin"
+)
+
+
+func corsEngine(cfg config.CORS) *gin.Engine {
+gin.SetMode(gin.TestMode)
+r := gin.New()
+r.Use(middleware.CORS(cfg))
+ok := func(c *gin.Context) { c.String(200, "ok") }
+r.GET("/users", ok)
+r.POST("/auth/login", ok)
+r.GET("/public/info", ok)
+return r
+}
+
+
+func corsCall(h http.Handler, method, path, origin string, hdr map[string]string) *httptest.ResponseRecorder {
+req := httptest.NewRequest(method, path, nil)
+if origin != "" { req.Header.Set("Origin", origin) }
+for k, v := range hdr { req.Header.Set(k, v) }
+w := httptest.NewRecorder()
+h.ServeHTTP(w, req)
+return w
+}
+
+
+func preflight(method, headers string) map[string]string {
+return map[string]string{"Access-Control-Request-Method": method, "Access-Control-Request-Headers": headers}
+}
+
+
+func TestCORSAllowList(t *testing.T) {
+r := corsEngine(config.CORS{AllowedOrigins: []string{"https://app.example.com", "https://*.example.org"}, AllowCredentials: true})
+
+w := corsCall(r, "GET", "/users", "https://app.example.com", nil)
+if w.Header().Get("Access-Control-Allow-Origin") != "https://app.example.com" || w.Header().Get("Access-Control-Allow-Credentials") != "true" { t.Fatalf("%v", w.Header()) }
+if !strings.Contains(w.Header().Get("Access-Control-Expose-Headers"), "X-RateLimit-Remaining") || !strings.Contains(w.Header().Get("Access-Control-Expose-Headers"), "X-Request-Id") { t.Fatalf("expose: %v", w.Header()) }
+if w.Header().Values("Vary")[0] != "Origin" { t.Fatalf("vary: %v", w.Header()) }
+
+for _, o := range []string{"https://a.example.org", "https://a.b.example.org"} {
+if corsCall(r, "GET", "/users", o, nil).Header().Get("Access-Control-Allow-Origin") != o { t.Fatalf("%s: wildcard subdomain not allowed", o) }
+}
+for _, o := range []string{"https://example.org", "http://a.example.org", "https://evil-example.org", "https://a.example.org.evil.com", "https://evil.com"} {
+w = corsCall(r, "GET", "/users", o, nil)
+if w.Code != 200 || w.Header().Get("Access-Control-Allow-Origin") != "" { t.Fatalf("%s: %d %v", o, w.Code, w.Header()) }
+}
+// no Origin: plain request, still varies on it
+w = corsCall(r, "GET", "/users", "", nil)
+if w.Header().Get("Access-Control-Allow-Origin") != "" || w.Header().Get("Vary") != "Origin" { t.Fatalf("%v", w.Header()) }
+}
+
+
+func TestCORSWildcardWithoutCredentials(t *testing.T) {
+r := corsEngine(config.CORS{AllowedOrigins: []string{"*"}})
+w := corsCall(r, "GET", "/users", "https://anything.test", nil)
+if w.Header().Get("Access-Control-Allow-Origin") != "*" || w.Header().Get("Access-Control-Allow-Credentials") != "" { t.Fatalf("%v", w.Header()) }
+}
+
+
+func TestCORSPreflight(t *testing.T) {
+r := corsEngine(config.CORS{AllowedOrigins: []string{"https://app.example.com"}, AllowedMethods: []string{"GET", "POST"}, MaxAgeS: 120})
+
+w := corsCall(r, "OPTIONS", "/users", "https://app.example.com", preflight("POST", "authorization, content-type"))
+if w.Code != 204 || w.Header().Get("Access-Control-Allow-Methods") != "GET, POST" || w.Header().Get("Access-Control-Max-Age") != "120" { t.Fatalf("%d %v", w.Code, w.Header()) }
+if w.Header().Get("Access-Control-Allow-Headers") != "Authorization, Content-Type, X-Request-Id" { t.Fatalf("%v", w.Header()) }
+
+if w = corsCall(r, "OPTIONS", "/users", "https://evil.com", preflight("GET", "")); w.Code != 403 || w.Header().Get("Access-Control-Allow-Origin") != "" { t.Fatalf("foreign origin: %d %v", w.Code, w.Header()) }
+if w = corsCall(r, "OPTIONS", "/users", "https://app.example.com", preflight("DELETE", "")); w.Code != 403 { t.Fatalf("method: %d", w.Code) }
+if w = corsCall(r, "OPTIONS", "/users", "https://app.example.com", preflight("GET", "X-Secret")); w.Code != 403 { t.Fatalf("header: %d", w.Code) }
+
+// "*" headers echo the request
+r = corsEngine(config.CORS{AllowedOrigins: []string{"https://app.example.com"}, AllowedHeaders: []string{"*"}})
+if w = corsCall(r, "OPTIONS", "/users", "https://app.example.com", preflight("GET", "X-Custom")); w.Code != 204 || w.Header().Get("Access-Control-Allow-Headers") != "X-Custom" { t.Fatalf("%d %v", w.Code, w.Header()) }
+}
+
+
+func TestCORSRouteOverrides(t *testing.T) {
+yes := true
+r := corsEngine(config.CORS{
+AllowedOrigins: []string{"*"},
+Routes: []config.CORSRoute{
+{Prefix: "/auth/", AllowedOrigins: []string{"https://login.example.com"}, AllowCredentials: &yes, ExposedHeaders: []string{"X-Request-Id"}},
+{Prefix: "/public/", AllowedOrigins: []string{}},
+},
+})
+w := corsCall(r, "POST", "/auth/login", "https://login.example.com", nil)
+if w.Header().Get("Access-Control-Allow-Origin") != "https://login.example.com" || w.Header().Get("Access-Control-Allow-Credentials") != "true" || w.Header().Get("Access-Control-Expose-Headers") != "X-Request-Id" { t.Fatalf("%v", w.Header()) }
+if corsCall(r, "POST", "/auth/login", "https://other.test", nil).Header().Get("Access-Control-Allow-Origin") != "" { t.Fatal("route allow-list ignored") }
+if corsCall(r, "GET", "/public/info", "https://other.test", nil).Header().Get("Access-Control-Allow-Origin") != "" { t.Fatal("allowed_origins: [] must turn CORS off") }
+if corsCall(r, "GET", "/users", "https://other.test", nil).Header().Get("Access-Control-Allow-Origin") != "*" { t.Fatal("base policy lost") }
+}
+
+
+func TestCORSConfigValidation(t *testing.T) {
+got := fieldErrors(t, loadYAML(t, strings.Replace(validYAML, "  port: 8080\n", "  port: 8080\n  cors:\n    allowed_origins: [\"*\", \"app.example.com\", \"https://*.*.example.com\"]\n    allow_credentials: true\n    allowed_methods: [get]\n    routes:\n      - { prefix: auth, allowed_origins: [\"https://ok.example.com\"] }\n", 1)))
+for _, p := range []string{"server.cors.allow_credentials", "server.cors.allowed_origins[1]", "server.cors.allowed_origins[2]", "server.cors.allowed_methods[0]", "server.cors.routes[0].prefix"} {
+if _, ok := got[p]; !ok { t.Errorf("no error for %s: %v", p, got) }
+}
+if _, ok := got["server.cors.routes[0].allow_credentials"]; ok { t.Error("a route with listed origins may use credentials") }
+if err := loadYAML(t, strings.Replace(validYAML, "  port: 8080\n", "  port: 8080\n  cors:\n    allowed_origins: [\"https://*.example.com:8443\", \"http://localhost:3000\"]\n    allow_credentials: true\n", 1)); err != nil { t.Fatal(err) }
+}
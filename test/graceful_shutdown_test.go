@@ -0,0 +1,88 @@
+package test
+
+
+import (
+"context"
+"errors"
+"io"
+"net"
+"net/http"
+"testing"
+"time"
+"example.com/api-gateway/config"
+"example.com/api-gateway/internal/health"
+httpx "example.com/api-gateway/internal/http"
+"go.uber.org/zap"
+)
+
+
+// startGraceful serves h with a Graceful on a free port; cancel plays SIGTERM.
+func startGraceful(t *testing.T, cfg config.Shutdown, ready *health.Readiness, h http.Handler) (string, context.CancelFunc, chan error) {
+t.Helper()
+ln, err := net.Listen("tcp", "127.0.0.1:0")
+if err != nil { t.Fatal(err) }
+ctx, cancel := context.WithCancel(context.Background())
+done := make(chan error, 1)
+go func() { done <- httpx.NewGraceful(cfg, ready, zap.NewNop()).Serve(ctx, &http.Server{Handler: h}, ln) }()
+return "http://" + ln.Addr().String(), cancel, done
+}
+
+
+func TestGracefulShutdownCompletesInFlight(t *testing.T) {
+entered := make(chan struct{}, 1)
+h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+entered <- struct{}{}
+time.Sleep(300 * time.Millisecond)
+io.WriteString(w, "finished")
+})
+ready := health.NewReadiness(0)
+url, cancel, done := startGraceful(t, config.Shutdown{TimeoutMS: 5000}, ready, h)
+
+type result struct { body string; err error }
+res := make(chan result, 1)
+go func() {
+resp, err := http.Get(url)
+if err != nil { res <- result{err: err}; return }
+defer resp.Body.Close()
+b, err := io.ReadAll(resp.Body)
+res <- result{string(b), err}
+}()
+<-entered
+cancel() // SIGTERM while the request is in flight
+
+if r := <-res; r.err != nil || r.body != "finished" { t.Fatalf("in-flight request cut off: %q %v", r.body, r.err) }
+select {
+case err := <-done: if err != nil { t.Fatalf("clean drain expected: %v", err) }
+case <-time.After(3 * time.Second): t.Fatal("Serve did not return after the drain")
+}
+if !ready.Draining() { t.Fatal("readiness must flip to draining") }
+if _, err := http.Get(url); err == nil { t.Fatal("listener must be closed after the drain") }
+}
+
+
+func TestGracefulShutdownDelayKeepsServing(t *testing.T) {
+ready := health.NewReadiness(0)
+url, cancel, done := startGraceful(t, config.Shutdown{DelayMS: 300, TimeoutMS: 1000}, ready, http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) { io.WriteString(w, "ok") }))
+cancel()
+time.Sleep(50 * time.Millisecond)
+if !ready.Draining() { t.Fatal("readiness must flip before the delay") }
+resp, err := http.Get(url)
+if err != nil { t.Fatalf("new requests must still be served during the delay: %v", err) }
+resp.Body.Close()
+if err := <-done; err != nil { t.Fatal(err) }
+}
+
+
+func TestGracefulShutdownDeadline(t *testing.T) {
+release := make(chan struct{})
+defer close(release)
+entered := make(chan struct{}, 1)
+url, cancel, done := startGraceful(t, config.Shutdown{TimeoutMS: 100}, nil, http.HandlerFunc(func(http.ResponseWriter, *http.Request) { entered <- struct{}{}; <-release }))
+go func() { if resp, err := http.Get(url); err == nil { resp.Body.Close() } }()
+<-entered
+start := time.Now()
+cancel()
+err := <-done
+if !errors.Is(err, context.DeadlineExceeded) { t.Fatalf("want deadline error, got %v", err) }
+if time.Since(start) > 2*time.Second { t.Fatalf("deadline not enforced: %v", time.Since(start)) }
+}
@@ -0,0 +1,153 @@
+package test
+
+
+import (
+"context"
+"encoding/json"
+"errors"
+"net/http"
+"net/http/httptest"
+"strings"
+"sync"
+"sync/atomic"
+"testing"
+"time"
+"example.com/api-gateway/config"
+"example.com/api-gateway/internal/health"
+httpx "example.com/api-gateway/internal/http"
+"github.com/gin-gonic/gin"
+"go.uber.org/zap"
+)
+
+
+func readyRouter(ready *health.Readiness) *gin.Engine {
+gin.SetMode(gin.TestMode)
+return httpx.NewRouter(httpx.Deps{Config: config.Root{}, Log: zap.NewNop(), Health: ready})
+}
+
+
+// readyAdmin is the admin listener, open to httptest's default peer.
+func readyAdmin(t *testing.T, ready *health.Readiness) *gin.Engine {
+d := adminDeps(config.Admin{Address: "127.0.0.1:0", Auth: config.AdminAuth{AllowIPs: []string{"192.0.2.0/24"}}})
+d.Health = ready
+r, err := httpx.NewAdminRouter(d)
+if err != nil { t.Fatal(err) }
+return r
+}
+
+
+func probe(t *testing.T, r http.Handler, path string) (int, health.Report) {
+t.Helper()
+w := httptest.NewRecorder()
+r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+var rep health.Report
+if path == "/health/ready" { if err := json.Unmarshal(w.Body.Bytes(), &rep); err != nil { t.Fatalf("body %q: %v", w.Body.String(), err) } }
+return w.Code, rep
+}
+
+
+func ok(context.Context) error { return nil }
+
+
+func TestReadinessBreakdown(t *testing.T) {
+ready := health.NewReadiness(time.Second)
+ready.Register(health.Func("database", ok))
+ready.RegisterOptional(health.Func("redis", func(context.Context) error { return errors.New("connection refused") }))
+r := readyAdmin(t, ready)
+
+code, rep := probe(t, r, "/health/ready")
+if code != 200 || rep.Status != "ready" || len(rep.Components) != 2 { t.Fatalf("optional failure must not fail readiness: %d %+v", code, rep) }
+db, rd := rep.Components[0], rep.Components[1]
+if db.Name != "database" || db.Status != "up" || !db.Critical || db.LatencyMS < 0 { t.Fatalf("database: %+v", db) }
+if rd.Name != "redis" || rd.Status != "degraded" || rd.Critical || rd.Error != "connection refused" { t.Fatalf("redis: %+v", rd) }
+
+ready.Register(health.Func("billing", func(context.Context) error { return errors.New("boom") }))
+code, rep = probe(t, r, "/health/ready")
+if code != 503 || rep.Status != "not_ready" || rep.Components[0].Name != "billing" || rep.Components[0].Status != "down" { t.Fatalf("critical failure: %d %+v", code, rep) }
+if code, _ := probe(t, r, "/health/live"); code != 200 { t.Fatalf("liveness must ignore dependencies: %d", code) }
+}
+
+
+func TestReadinessPublicBrief(t *testing.T) {
+ready := health.NewReadiness(time.Second)
+ready.Register(health.Func("database", func(context.Context) error { return errors.New("dial tcp 10.0.3.7:3306: connection refused") }))
+w := call(readyRouter(ready), "/health/ready", "", nil)
+if w.Code != 503 || w.Body.String() != `{"status":"not_ready","components":[{"name":"database","status":"down"}]}` { t.Fatalf("public body: %d %s", w.Code, w.Body) }
+w = call(readyAdmin(t, ready), "/health/ready", "", nil)
+if w.Code != 503 || !strings.Contains(w.Body.String(), "10.0.3.7:3306") || !strings.Contains(w.Body.String(), "latencyMs") { t.Fatalf("admin body: %d %s", w.Code, w.Body) }
+}
+
+
+func TestReadinessCachedAndShared(t *testing.T) {
+var runs atomic.Int32
+release := make(chan struct{})
+ready := health.NewReadiness(time.Second)
+ready.Register(health.Func("database", func(context.Context) error { runs.Add(1); <-release; return nil }))
+r := readyRouter(ready)
+
+var wg sync.WaitGroup
+codes := make([]int, 20)
+for i := range codes {
+wg.Add(1)
+go func() { defer wg.Done(); codes[i], _ = probe(t, r, "/health/ready") }()
+}
+time.Sleep(50 * time.Millisecond)
+close(release)
+wg.Wait()
+for _, c := range codes { if c != 200 { t.Fatalf("codes: %v", codes) } }
+if n := runs.Load(); n != 1 { t.Fatalf("concurrent probes ran the checks %d times", n) }
+
+probe(t, r, "/health/ready")
+if n := runs.Load(); n != 1 { t.Fatalf("a fresh report was not reused: %d runs", n) }
+time.Sleep(1100 * time.Millisecond)
+probe(t, r, "/health/ready")
+if n := runs.Load(); n != 2 { t.Fatalf("stale report reused: %d runs", n) }
+
+// a new check is not hidden by the cached report
+ready.Register(health.Func("billing", func(context.Context) error { return errors.New("boom") }))
+if code, _ := probe(t, r, "/health/ready"); code != 503 { t.Fatalf("after Register: %d", code) }
+}
+
+
+func TestReadinessTimeout(t *testing.T) {
+ready := health.NewReadiness(50 * time.Millisecond)
+block := make(chan struct{})
+defer close(block)
+ready.Register(health.Func("stuck", func(context.Context) error { <-block; return nil })) // ignores ctx
+start := time.Now()
+rep := ready.Check(context.Background())
+if time.Since(start) > time.Second { t.Fatalf("check not bounded by the timeout: %v", time.Since(start)) }
+if rep.Ready() || rep.Components[0].Status != "down" || rep.Components[0].Error != context.DeadlineExceeded.Error() { t.Fatalf("%+v", rep) }
+}
+
+
+func TestReadinessDraining(t *testing.T) {
+ready := health.NewReadiness(0)
+ready.Register(health.Func("database", ok))
+r := readyRouter(ready)
+if code, _ := probe(t, r, "/health/ready"); code != 200 { t.Fatalf("before drain: %d", code) }
+ready.SetDraining()
+code, rep := probe(t, r, "/health/ready")
+if code != 503 || rep.Status != "draining" { t.Fatalf("after drain: %d %+v", code, rep) }
+if code, _ := probe(t, r, "/health/live"); code != 200 { t.Fatalf("still live while draining: %d", code) }
+}
+
+
+func TestReadinessHTTPUpstream(t *testing.T) {
+var status atomic.Int32
+status.Store(200)
+up := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) { w.WriteHeader(int(status.Load())) }))
+defer up.Close()
+c := health.HTTP("billing", up.URL, nil)
+if err := c.Check(context.Background()); err != nil { t.Fatalf("healthy upstream: %v", err) }
+status.Store(503)
+if err := c.Check(context.Background()); err == nil { t.Fatal("5xx must be a failure") }
+up.Close()
+if err := c.Check(context.Background()); err == nil { t.Fatal("unreachable upstream must be a failure") }
+}
+
+
+func TestReadinessWithoutChecks(t *testing.T) {
+code, rep := probe(t, readyRouter(nil), "/health/ready")
+if code != 200 || rep.Status != "ready" { t.Fatalf("%d %+v", code, rep) }
+}
@@ -0,0 +1,72 @@
+package test
+
+
+import (
+"context"
+"strings"
+"testing"
+"time"
+"example.com/api-gateway/config"
+"example.com/api-gateway/internal/rate"
+"github.com/alicebob/miniredis/v2"
+"go.uber.org/zap"
+)
+
+
+// hybridCounter returns the Redis key holding k's count, or "".
+func hybridCounter(mr *miniredis.Miniredis, k string) string {
+for _, key := range mr.Keys() {
+if strings.HasPrefix(key, "rl:h:"+k+":") { return key }
+}
+return ""
+}
+
+
+func TestHybridLimiterSyncAfterReset(t *testing.T) {
+mr, c := miniClient(t)
+cfg := config.RateLimit{RequestsPerMinute: 2, Burst: 0, MaxLocalPending: 1, SyncIntervalMS: 10}
+a := rate.NewHybridLimiter(c, cfg, nil, zap.NewNop())
+defer a.(rate.Stopper).Stop()
+b := rate.NewHybridLimiter(c, cfg, nil, zap.NewNop())
+defer b.(rate.Stopper).Stop()
+
+a.Allow("k"); a.Allow("k")
+if ok, _ := a.Allow("k"); ok { t.Fatal("want deny once the window is used up") }
+waitFor(t, func() bool { return hybridCounter(mr, "k") != "" })
+
+// Another replica resets the key; a re-reads the saturated key on its next
+// sync and lets it through again. Its push recreates the counter, which
+// must still expire.
+if err := b.(rate.Inspector).Reset(context.Background(), "k"); err != nil { t.Fatal(err) }
+waitFor(t, func() bool { ok, _ := a.Allow("k"); return ok })
+if k := hybridCounter(mr, "k"); k == "" || mr.TTL(k) <= 0 { t.Fatalf("recreated counter %q without expiry", k) }
+}
+
+
+func TestHybridLimiterSyncSkipsIdleKeys(t *testing.T) {
+mr, c := miniClient(t)
+cfg := config.RateLimit{RequestsPerMinute: 100, MaxLocalPending: 50, SyncIntervalMS: 5}
+l := rate.NewHybridLimiter(c, cfg, nil, zap.NewNop())
+defer l.(rate.Stopper).Stop()
+for _, k := range []string{"a", "b", "c"} { l.Allow(k) }
+waitFor(t, func() bool { return hybridCounter(mr, "a") != "" && hybridCounter(mr, "b") != "" && hybridCounter(mr, "c") != "" })
+
+time.Sleep(20 * time.Millisecond) // let the push that created the counters finish
+n := mr.CommandCount()
+time.Sleep(50 * time.Millisecond) // ~10 ticks with nothing pending
+if d := mr.CommandCount() - n; d != 0 { t.Fatalf("idle keys cost %d Redis commands", d) }
+}
+
+
+func TestHybridLimiterCapsTrackedKeys(t *testing.T) {
+mr, c := miniClient(t)
+cfg := config.RateLimit{RequestsPerMinute: 100, MaxKeys: 1, MaxLocalPending: 50, SyncIntervalMS: 3_600_000}
+l := rate.NewHybridLimiter(c, cfg, nil, zap.NewNop())
+l.Allow("a"); l.Allow("a")
+l.Allow("b") // evicts a, whose unpushed hits go to Redis right away
+if k := hybridCounter(mr, "a"); k == "" { t.Fatal("evicted key's hits were lost") } else if v, _ := mr.Get(k); v != "2" { t.Fatalf("a: %s", v) }
+st, _ := l.(rate.Inspector).Inspect(context.Background(), "a")
+if st.Used != 2 { t.Fatalf("a after eviction: %+v", st) }
+l.(rate.Stopper).Stop()
+if k := hybridCounter(mr, "b"); k == "" { t.Fatal("tracked key not flushed on Stop") }
+}
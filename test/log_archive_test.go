@@ -0,0 +1,173 @@
+package test
+
+
+import (
+"bufio"
+"compress/gzip"
+"context"
+"net/http"
+"net/http/httptest"
+"os"
+"path/filepath"
+"strings"
+"testing"
+"time"
+"example.com/api-gateway/config"
+"example.com/api-gateway/internal/handlers"
+rlog "example.com/api-gateway/internal/redis"
+"github.com/gin-gonic/gin"
+"go.uber.org/zap"
+)
+
+
+func writeArchive(t *testing.T, path string, lines ...string) {
+t.Helper()
+f, err := os.Create(path)
+if err != nil { t.Fatal(err) }
+zw := gzip.NewWriter(f)
+for _, l := range lines { _, _ = zw.Write([]byte(l + "\n")) }
+if err := zw.Close(); err != nil { t.Fatal(err) }
+_ = f.Close()
+}
+
+
+func archiveRouter(a *rlog.Archiver) *gin.Engine {
+gin.SetMode(gin.TestMode)
+h := handlers.NewLogArchiveHandler(a)
+r := gin.New()
+r.GET("/api/logs/archive", h.List)
+r.GET("/api/logs/archive/:day", h.Download)
+return r
+}
+
+
+func TestLogArchiveDownloadJoinsClasses(t *testing.T) {
+dir := t.TempDir()
+writeArchive(t, filepath.Join(dir, "2026-01-02.ndjson.gz"), `{"message":"a"}`)
+writeArchive(t, filepath.Join(dir, "2026-01-02.access.ndjson.gz"), `{"message":"http"}`, `{"message":"http"}`)
+writeArchive(t, filepath.Join(dir, "2026-01-03.ndjson.gz"), `{"message":"other day"}`)
+a := rlog.NewArchiver(nil, config.LogArchive{Dir: dir}, nil)
+if a == nil { t.Fatal("archiver with a dir must not be nil") }
+files, err := a.Files("")
+if err != nil || len(files) != 3 { t.Fatalf("want 3 files, got %v %v", files, err) }
+if files[0].Day != "2026-01-02" || files[0].Class != "" || files[1].Class != "access" { t.Fatalf("unexpected order %v", files) }
+
+r := archiveRouter(a)
+w := httptest.NewRecorder()
+r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/logs/archive/2026-01-02", nil))
+if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "application/gzip" { t.Fatalf("got %d %v", w.Code, w.Header()) }
+zr, err := gzip.NewReader(w.Body) // multistream: one member per class file
+if err != nil { t.Fatal(err) }
+n := 0
+for sc := bufio.NewScanner(zr); sc.Scan(); { n++ }
+if n != 3 { t.Fatalf("want 3 lines from both class files, got %d", n) }
+
+w = httptest.NewRecorder()
+r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/logs/archive/2026-01-02?class=default", nil))
+zr, _ = gzip.NewReader(w.Body)
+n = 0
+for sc := bufio.NewScanner(zr); sc.Scan(); { n++ }
+if n != 1 { t.Fatalf("want only the default class, got %d lines", n) }
+}
+
+
+func TestLogArchiveDownloadErrors(t *testing.T) {
+r := archiveRouter(rlog.NewArchiver(nil, config.LogArchive{Dir: t.TempDir()}, nil))
+for path, want := range map[string]int{
+"/api/logs/archive/2026-01-02":   http.StatusNotFound,
+"/api/logs/archive/2026-13-40":   http.StatusBadRequest,
+"/api/logs/archive/yesterday":    http.StatusBadRequest,
+} {
+w := httptest.NewRecorder()
+r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+if w.Code != want { t.Fatalf("%s: want %d, got %d", path, want, w.Code) }
+}
+if rlog.NewArchiver(nil, config.LogArchive{}, nil) != nil { t.Fatal("no dir must disable archival") }
+w := httptest.NewRecorder()
+archiveRouter(nil).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/logs/archive", nil))
+if w.Code != http.StatusNotFound { t.Fatalf("disabled archival: want 404, got %d", w.Code) }
+}
+
+
+// readArchive returns the lines of a gzipped archive file.
+func readArchive(t *testing.T, path string) []string {
+t.Helper()
+f, err := os.Open(path)
+if err != nil { t.Fatal(err) }
+defer f.Close()
+zr, err := gzip.NewReader(f)
+if err != nil { t.Fatal(err) }
+var lines []string
+for sc := bufio.NewScanner(zr); sc.Scan(); { lines = append(lines, sc.Text()) }
+return lines
+}
+
+
+func TestArchiverExportsDueListsOnceAndPrunes(t *testing.T) {
+mr, c := miniClient(t)
+ctx := context.Background()
+st := rlog.NewLogStore(c, config.LogStore{Retention: config.Retention{Days: 7, Levels: map[string]int{"error": 30}}})
+dir := t.TempDir()
+a := rlog.NewArchiver(st, config.LogArchive{Dir: dir, AheadHours: 24, KeepDays: 5}, zap.NewNop())
+today := time.Now().UTC()
+day := func(d int) string { return today.AddDate(0, 0, -d).Format("2006-01-02") }
+
+c.LPush(ctx, "logs:"+day(1), `{"message":"old"}`, `{"message":"mid"}`, `{"message":"new"}`)
+mr.SetTTL("logs:"+day(1), time.Hour) // due
+c.LPush(ctx, "logs:"+day(1)+":error", `{"message":"e"}`)
+mr.SetTTL("logs:"+day(1)+":error", 10*24*time.Hour) // not within ahead_hours yet
+c.LPush(ctx, "logs:"+day(2), `{"message":"no ttl"}`) // never expires, never archived
+writeArchive(t, filepath.Join(dir, day(10)+".ndjson.gz"), `{}`)
+writeArchive(t, filepath.Join(dir, day(3)+".ndjson.gz"), `{}`)
+
+// A failed export leaves no archive behind, so the next pass retries it.
+part := filepath.Join(dir, day(1)+".ndjson.gz.part")
+if err := os.Mkdir(part, 0o755); err != nil { t.Fatal(err) }
+if n, err := a.RunOnce(ctx); n != 0 || err == nil || !strings.Contains(err.Error(), "logs:"+day(1)) { t.Fatalf("blocked export: %d %v", n, err) }
+if _, err := os.Stat(filepath.Join(dir, day(1)+".ndjson.gz")); !os.IsNotExist(err) { t.Fatal("failed export left an archive") }
+_ = os.Remove(part)
+
+if n, err := a.RunOnce(ctx); n != 1 || err != nil { t.Fatalf("export: %d %v", n, err) }
+if got := strings.Join(readArchive(t, filepath.Join(dir, day(1)+".ndjson.gz")), ","); got != `{"message":"old"},{"message":"mid"},{"message":"new"}` { t.Fatalf("want oldest first, got %s", got) }
+if parts, _ := filepath.Glob(filepath.Join(dir, "*.part")); len(parts) != 0 { t.Fatalf("temporary files left: %v", parts) }
+if n, err := a.RunOnce(ctx); n != 0 || err != nil { t.Fatalf("re-export: %d %v", n, err) }
+
+files, _ := a.Files("")
+var names []string
+for _, f := range files { names = append(names, f.Name) }
+if got := strings.Join(names, ","); got != day(3)+".ndjson.gz,"+day(1)+".ndjson.gz" { t.Fatalf("after pruning: %s", got) }
+}
+
+
+func TestLogQueryPagesDayListsAcrossDays(t *testing.T) {
+_, c := miniClient(t)
+ctx := context.Background()
+st := rlog.NewLogStore(c, config.LogStore{Retention: config.Retention{Days: 7, Levels: map[string]int{"error": 30}}})
+today := time.Now().UTC()
+td, yd := today.Format("2006-01-02"), today.AddDate(0, 0, -1).Format("2006-01-02")
+entry := func(day, clock, msg string) string { return `{"ts":"` + day + "T" + clock + `Z","level":"INFO","message":"` + msg + `"}` }
+// Day lists are LPUSHed: pushing oldest first leaves them newest first.
+c.LPush(ctx, "logs:"+yd, entry(yd, "10:00:01", "y1"), entry(yd, "10:00:03", "y3"), entry(yd, "10:00:05", "y5"))
+c.LPush(ctx, "logs:"+yd+":error", entry(yd, "10:00:02", "y2"), entry(yd, "10:00:04", "y4"))
+c.LPush(ctx, "logs:"+td, entry(td, "00:00:01", "t1"), entry(td, "00:00:03", "t3"))
+c.LPush(ctx, "logs:"+td+":error", entry(td, "00:00:02", "t2"))
+
+// No stream entries: a From in the past makes the query historical.
+f := rlog.LogFilter{From: today.AddDate(0, 0, -1).Truncate(24 * time.Hour)}
+var pages, cursors []string
+cursor := ""
+for i := 0; i < 10; i++ {
+items, next, err := st.Query(ctx, f, 2, cursor)
+if err != nil { t.Fatal(err) }
+pages = append(pages, messages(items))
+if next == "" { break }
+if i == 0 { c.LPush(ctx, "logs:"+td, entry(td, "00:00:04", "t4")) } // arrives while paging
+cursors = append(cursors, next)
+cursor = next
+}
+// Cursors count what is left of each class list from its oldest end, so
+// the entry pushed meanwhile shifts nothing. A full last page still
+// carries a cursor; following it yields nothing.
+if got := strings.Join(pages, "|"); got != "t3,t2|t1,y5|y4,y3|y2,y1|" { t.Fatalf("pages: %s", got) }
+if got := strings.Join(cursors, " "); got != "d:"+td+":1,0 d:"+yd+":2,2 d:"+yd+":1,1 d:"+yd+":0,0" { t.Fatalf("cursors: %s", got) }
+}
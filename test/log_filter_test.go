@@ -0,0 +1,45 @@
+package test
+
+
+import (
+"testing"
+"time"
+rds "example.com/api-gateway/internal/redis"
+)
+
+
+func TestLogFilterMatch(t *testing.T) {
+e := rds.LogEntry{
+Timestamp: "2025-10-21T12:00:00Z", Level: "WARN", Message: "Upstream Timeout",
+Context: map[string]any{"requestId": "r1", "userID": "7", "path": "/api/users/7", "status": float64(504)},
+}
+at := time.Date(2025, 10, 21, 12, 0, 0, 0, time.UTC)
+
+match := []rds.LogFilter{
+{},
+{Level: "warn"},
+{From: at, To: at},
+{From: at.Add(500 * time.Millisecond), To: at.Add(500 * time.Millisecond)}, // bounds cover whole seconds
+{RequestID: "r1", UserID: "7"},
+{PathPrefix: "/api/users"},
+{StatusClass: 5},
+{Text: "timeout"},
+}
+for i, f := range match {
+if !f.Match(e) { t.Fatalf("filter %d: want match", i) }
+}
+
+miss := []rds.LogFilter{
+{Level: "ERROR"},
+{From: at.Add(time.Second)},
+{To: at.Add(-time.Second)},
+{To: at.Add(-500 * time.Millisecond)},
+{RequestID: "r2"},
+{PathPrefix: "/auth"},
+{StatusClass: 4},
+{Text: "refused"},
+}
+for i, f := range miss {
+if f.Match(e) { t.Fatalf("filter %d: want no match", i) }
+}
+}
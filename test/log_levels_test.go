@@ -0,0 +1,47 @@
+package test
+
+
+import (
+"os"
+"path/filepath"
+"strings"
+"testing"
+"example.com/api-gateway/config"
+"example.com/api-gateway/internal/logger"
+"go.uber.org/zap/zapcore"
+)
+
+
+func TestLevelsOverridesByLoggerName(t *testing.T) {
+l, err := logger.NewLevels("info", map[string]string{"rate": "debug", "rate.redis": "error"})
+if err != nil { t.Fatal(err) }
+cases := map[string]zapcore.Level{"": zapcore.InfoLevel, "access": zapcore.InfoLevel, "rate": zapcore.DebugLevel,
+"rate.memory": zapcore.DebugLevel, "rate.redis": zapcore.ErrorLevel, "ratelimit": zapcore.InfoLevel}
+for name, want := range cases {
+if got := l.For(name); got != want { t.Fatalf("%q: want %v, got %v", name, want, got) }
+}
+if _, err := logger.NewLevels("loud", nil); err == nil { t.Fatal("want error for unknown level") }
+}
+
+
+func TestLoggerLevelChangesAtRuntime(t *testing.T) {
+dir := t.TempDir()
+log, ctl, err := logger.New(config.Logging{Level: "info", File: config.LogFile{Path: "-"},
+Sinks: []config.LogSink{{Type: "file", File: config.FileSink{Dir: dir}}}}, nil)
+if err != nil { t.Fatal(err) }
+
+rl := log.Named("rate")
+rl.Debug("hidden")
+ctl.Levels.SetOverride("rate", zapcore.DebugLevel)
+rl.Debug("shown")
+log.Debug("base still info")
+ctl.Levels.SetBase(zapcore.ErrorLevel)
+ctl.Levels.ClearOverride("rate")
+rl.Warn("hidden too")
+ctl.Close()
+
+b, _ := os.ReadFile(filepath.Join(dir, "rate.ndjson"))
+out := string(b)
+if !strings.Contains(out, "shown") || strings.Contains(out, "hidden") { t.Fatalf("unexpected rate log: %q", out) }
+if _, err := os.Stat(filepath.Join(dir, "app.ndjson")); err == nil { t.Fatal("base debug entry should be filtered") }
+}
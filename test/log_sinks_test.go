@@ -0,0 +1,162 @@
+package test
+
+
+import (
+"bufio"
+"io"
+"net"
+"net/http"
+"net/http/httptest"
+"os"
+"path/filepath"
+"runtime"
+"strconv"
+"strings"
+"sync/atomic"
+"testing"
+"time"
+"example.com/api-gateway/config"
+"example.com/api-gateway/internal/logger"
+"go.uber.org/zap"
+"go.uber.org/zap/zapcore"
+)
+
+
+// sinkLogger wires one sink into a zap logger; close flushes it.
+func sinkLogger(t *testing.T, cfg config.LogSink) (*zap.Logger, func()) {
+t.Helper()
+s, err := logger.OpenSink(cfg)
+if err != nil { t.Fatal(err) }
+core := logger.NewSinkCore(s, cfg, zapcore.DebugLevel)
+return zap.New(core), func() { _ = core.Close() }
+}
+
+
+func TestSyslogSinkUDP(t *testing.T) {
+pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+if err != nil { t.Fatal(err) }
+defer pc.Close()
+
+l, closeSink := sinkLogger(t, config.LogSink{Type: "syslog", Syslog: config.SyslogSink{Network: "udp", Address: pc.LocalAddr().String()}})
+l.Named("access").Warn("slow upstream", zap.String("path", `/a"b]`))
+closeSink()
+
+buf := make([]byte, 2048)
+_ = pc.SetReadDeadline(time.Now().Add(2 * time.Second))
+n, _, err := pc.ReadFrom(buf)
+if err != nil { t.Fatal(err) }
+msg := string(buf[:n])
+// facility local0 (16) * 8 + warning (4) = 132
+if !strings.HasPrefix(msg, "<132>1 ") { t.Fatalf("bad header: %q", msg) }
+if !strings.Contains(msg, " api-gateway ") || !strings.Contains(msg, " access [ctx@32473 ") { t.Fatalf("bad fields: %q", msg) }
+if !strings.Contains(msg, `path="/a\"b\]"`) || !strings.HasSuffix(msg, "] slow upstream") { t.Fatalf("bad sd/msg: %q", msg) }
+}
+
+
+func TestSyslogSinkTCPOctetCounting(t *testing.T) {
+ln, err := net.Listen("tcp", "127.0.0.1:0")
+if err != nil { t.Fatal(err) }
+defer ln.Close()
+got := make(chan string, 1)
+go func() {
+conn, err := ln.Accept()
+if err != nil { return }
+defer conn.Close()
+b, _ := io.ReadAll(conn)
+got <- string(b)
+}()
+
+l, closeSink := sinkLogger(t, config.LogSink{Type: "syslog", Syslog: config.SyslogSink{Network: "tcp", Address: ln.Addr().String()}})
+l.Info("one")
+l.Error("two")
+closeSink()
+
+r := bufio.NewReader(strings.NewReader(<-got))
+for _, want := range []string{"one", "two"} {
+prefix, err := r.ReadString(' ')
+if err != nil { t.Fatal(err) }
+size, err := strconv.Atoi(strings.TrimSpace(prefix))
+if err != nil { t.Fatal(err) }
+frame := make([]byte, size)
+if _, err := io.ReadFull(r, frame); err != nil { t.Fatal(err) }
+if !strings.HasSuffix(string(frame), " "+want) { t.Fatalf("frame %q, want message %q", frame, want) }
+}
+}
+
+
+func TestHTTPSinkBatchesAndRetries(t *testing.T) {
+var calls atomic.Int32
+bodies := make(chan string, 4)
+srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+if calls.Add(1) == 1 { w.WriteHeader(http.StatusServiceUnavailable); return }
+if r.Header.Get("Content-Type") != "application/x-ndjson" || r.Header.Get("X-Token") != "t" { w.WriteHeader(http.StatusBadRequest); return }
+b, _ := io.ReadAll(r.Body)
+bodies <- string(b)
+}))
+defer srv.Close()
+
+l, closeSink := sinkLogger(t, config.LogSink{Type: "http", BatchSize: 3, RetryBackoffMS: 10,
+HTTP: config.HTTPSink{URL: srv.URL, Headers: map[string]string{"X-Token": "t"}}})
+l.Info("a"); l.Info("b"); l.Info("c")
+closeSink()
+
+if calls.Load() != 2 { t.Fatalf("want 1 failure + 1 retry, got %d calls", calls.Load()) }
+body := <-bodies
+if lines := strings.Count(body, "\n"); lines != 3 { t.Fatalf("want one batch of 3 lines, got %d: %q", lines, body) }
+}
+
+
+func TestHTTPSinkPermanentErrorNotRetried(t *testing.T) {
+var calls atomic.Int32
+srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+calls.Add(1)
+w.WriteHeader(http.StatusBadRequest)
+}))
+defer srv.Close()
+
+l, closeSink := sinkLogger(t, config.LogSink{Type: "http", RetryBackoffMS: 10, HTTP: config.HTTPSink{URL: srv.URL}})
+l.Info("x")
+closeSink()
+if calls.Load() != 1 { t.Fatalf("400 should not be retried, got %d calls", calls.Load()) }
+}
+
+
+func TestFileSinkPerCategory(t *testing.T) {
+dir := t.TempDir()
+l, closeSink := sinkLogger(t, config.LogSink{Type: "file", File: config.FileSink{Dir: dir}})
+l.Info("plain")
+l.Named("access").Info("GET /health")
+l.Named("access").Info("GET /users")
+closeSink()
+
+for file, lines := range map[string]int{"app.ndjson": 1, "access.ndjson": 2} {
+b, err := os.ReadFile(filepath.Join(dir, file))
+if err != nil { t.Fatal(err) }
+if n := strings.Count(string(b), "\n"); n != lines { t.Fatalf("%s: want %d lines, got %d", file, lines, n) }
+}
+}
+
+
+func TestOpenSinkRejectsBadConfig(t *testing.T) {
+for _, c := range []config.LogSink{
+{Type: "kafka"},
+{Type: "syslog", Syslog: config.SyslogSink{Network: "sctp", Address: "x"}},
+{Type: "http"},
+} {
+if _, err := logger.OpenSink(c); err == nil { t.Fatalf("want error for %+v", c) }
+}
+}
+
+
+func TestLoggerNewClosesOutputsOnFailure(t *testing.T) {
+dir := t.TempDir()
+before := runtime.NumGoroutine()
+_, _, err := logger.New(config.Logging{Level: "info", File: config.LogFile{Path: filepath.Join(dir, "app.log")},
+Sinks: []config.LogSink{{Type: "file", File: config.FileSink{Dir: dir}}, {Type: "http", HTTP: config.HTTPSink{URL: "http://127.0.0.1:1"}}, {Type: "carrier-pigeon"}}}, nil)
+if err == nil || !strings.Contains(err.Error(), "carrier-pigeon") { t.Fatalf("want the bad sink reported, got %v", err) }
+// The sinks opened before the failing one are closed, workers included.
+waitFor(t, func() bool { return runtime.NumGoroutine() <= before })
+
+_, _, err = logger.New(config.Logging{Level: "info", RedisLevel: "loud", File: config.LogFile{Path: filepath.Join(dir, "app.log")}}, nil)
+if err == nil { t.Fatal("want an error for a bad redis_level") }
+}
@@ -0,0 +1,187 @@
+package test
+
+
+import (
+"context"
+"errors"
+"fmt"
+"strings"
+"testing"
+"time"
+"example.com/api-gateway/config"
+"example.com/api-gateway/internal/auth"
+httpx "example.com/api-gateway/internal/http"
+rds "example.com/api-gateway/internal/redis"
+"github.com/gin-gonic/gin"
+"go.uber.org/zap"
+)
+
+
+func saveN(t *testing.T, st *rds.LogStore, n int) {
+t.Helper()
+for i := 0; i < n; i++ {
+if err := st.Save(context.Background(), rds.LogEntry{Timestamp: time.Now().UTC().Format(time.RFC3339), Level: "INFO", Message: fmt.Sprintf("m%d", i)}); err != nil { t.Fatal(err) }
+}
+}
+
+
+func messages(items []rds.LogEntry) string {
+out := make([]string, len(items))
+for i, e := range items { out[i] = e.Message }
+return strings.Join(out, ",")
+}
+
+
+func TestLogStorePagesStreamThenLegacyList(t *testing.T) {
+_, c := miniClient(t)
+ctx := context.Background()
+st := rds.NewLogStore(c, config.LogStore{})
+c.RPush(ctx, "logs:recent", `{"ts":"2024-01-01T00:00:00Z","level":"INFO","message":"old0"}`, `{"ts":"2024-01-01T00:00:00Z","level":"INFO","message":"old1"}`, `{"ts":"2024-01-01T00:00:00Z","level":"INFO","message":"old2"}`)
+saveN(t, st, 5)
+
+var pages []string
+cursor := ""
+for i := 0; i < 10; i++ {
+items, next, err := st.LoadRecent(ctx, 3, cursor)
+if err != nil { t.Fatal(err) }
+pages = append(pages, messages(items))
+if next == "" { break }
+if i == 0 && items[0].ID == "" { t.Fatal("stream entries carry their ID") }
+cursor = next
+}
+if got := strings.Join(pages, "|"); got != "m4,m3,m2|m1,m0,old0|old1,old2" { t.Fatalf("pages: %s", got) }
+}
+
+
+func TestLogStoreRejectsForeignCursors(t *testing.T) {
+_, c := miniClient(t)
+ctx := context.Background()
+st := rds.NewLogStore(c, config.LogStore{})
+saveN(t, st, 2)
+for _, cur := range []string{"legacy:x", "nonsense", "(0-1"} {
+if _, _, err := st.LoadRecent(ctx, 10, cur); !errors.Is(err, rds.ErrBadCursor) { t.Fatalf("LoadRecent %q: %v", cur, err) }
+}
+for _, cur := range []string{"legacy:3", "nonsense", "d:2024-13-01:0"} {
+if _, _, err := st.Query(ctx, rds.LogFilter{Level: "INFO"}, 10, cur); !errors.Is(err, rds.ErrBadCursor) { t.Fatalf("Query %q: %v", cur, err) }
+}
+
+gin.SetMode(gin.TestMode)
+cfg := config.Root{}
+cfg.Security.JWT = config.JWT{Secret: "s", TTLMinutes: 5}
+r := httpx.NewRouter(httpx.Deps{Config: cfg, Log: zap.NewNop(), LogStore: st})
+admin, _ := auth.Sign(cfg.Security.JWT, "admin-1", "admin")
+if w := do(r, "GET", "/api/logs?level=info&cursor=legacy:3", "198.51.100.3:4000", admin, ""); w.Code != 400 || !strings.Contains(w.Body.String(), "unfiltered") { t.Fatalf("legacy cursor with filters: %d %s", w.Code, w.Body) }
+if w := do(r, "GET", "/api/logs?cursor=bogus", "198.51.100.3:4000", admin, ""); w.Code != 400 { t.Fatalf("bad cursor: %d", w.Code) }
+if w := do(r, "GET", "/api/logs?level=info&limit=1", "198.51.100.3:4000", admin, ""); w.Code != 200 || !strings.Contains(w.Body.String(), `"count":1`) { t.Fatalf("filtered: %d %s", w.Code, w.Body) }
+for _, q := range []string{"level=info&limit=2000000000", "limit=2000000000"} {
+if w := do(r, "GET", "/api/logs?"+q, "198.51.100.3:4000", admin, ""); w.Code != 200 || !strings.Contains(w.Body.String(), `"count":2`) { t.Fatalf("huge limit %s: %d %s", q, w.Code, w.Body) }
+}
+}
+
+
+func TestLogConsumerAcksAndReclaims(t *testing.T) {
+mr, c := miniClient(t)
+ctx := context.Background()
+st := rds.NewLogStore(c, config.LogStore{})
+
+// c1 fails its batch: the entry stays pending
+failed := make(chan struct{}, 1)
+ctx1, stop1 := context.WithCancel(ctx)
+c1 := rds.NewConsumer(st, "ship", "c1", func(context.Context, []rds.LogEntry) error { failed <- struct{}{}; return errors.New("disk full") }, zap.NewNop())
+done1 := make(chan struct{})
+go func() { c1.Run(ctx1); close(done1) }()
+waitFor(t, func() bool { return c.Exists(ctx, st.Stream()).Val() == 1 })
+saveN(t, st, 1)
+select {
+case <-failed:
+case <-time.After(5 * time.Second): t.Fatal("c1 got nothing")
+}
+stop1()
+<-done1
+if n := c.XPending(ctx, st.Stream(), "ship").Val().Count; n != 1 { t.Fatalf("pending after failure: %d", n) }
+
+// once idle past the claim time, c2 takes it over and acknowledges it
+mr.SetTime(time.Now().Add(2 * time.Minute))
+got := make(chan []rds.LogEntry, 1)
+ctx2, stop2 := context.WithCancel(ctx)
+defer stop2()
+c2 := rds.NewConsumer(st, "ship", "c2", func(_ context.Context, b []rds.LogEntry) error { got <- b; return nil }, zap.NewNop())
+go c2.Run(ctx2)
+select {
+case b := <-got:
+if len(b) != 1 || b[0].Message != "m0" { t.Fatalf("reclaimed %+v", b) }
+case <-time.After(5 * time.Second): t.Fatal("c2 did not reclaim")
+}
+waitFor(t, func() bool { return c.XPending(ctx, st.Stream(), "ship").Val().Count == 0 })
+}
+
+
+func TestLogQueryTimeBoundsCoverWholeSeconds(t *testing.T) {
+_, c := miniClient(t)
+ctx := context.Background()
+st := rds.NewLogStore(c, config.LogStore{})
+saveN(t, st, 1)
+items, _, _ := st.LoadRecent(ctx, 1, "")
+at, _ := time.Parse(time.RFC3339, items[0].Timestamp)
+
+// The stream ID carries milliseconds, the timestamp whole seconds: both the
+// stream range and the per-day lists must treat To as the end of its second.
+for _, from := range []time.Time{{}, at.Add(-48 * time.Hour)} {
+if got, _, err := st.Query(ctx, rds.LogFilter{From: from, To: at}, 10, ""); err != nil || messages(got) != "m0" { t.Fatalf("from %v to %v: %q %v", from, at, messages(got), err) }
+if got, _, _ := st.Query(ctx, rds.LogFilter{From: from, To: at.Add(-time.Second)}, 10, ""); len(got) != 0 { t.Fatalf("from %v: entry after To returned", from) }
+}
+}
+
+
+func TestLogByRequestIDReportsTruncatedScan(t *testing.T) {
+_, c := miniClient(t)
+ctx := context.Background()
+st := rds.NewLogStore(c, config.LogStore{})
+saveN(t, st, 5001)
+
+items, truncated, err := st.ByRequestID(ctx, "unknown")
+if err != nil || len(items) != 0 || !truncated { t.Fatalf("want an empty, truncated scan: %d %v %v", len(items), truncated, err) }
+
+gin.SetMode(gin.TestMode)
+cfg := config.Root{}
+cfg.Security.JWT = config.JWT{Secret: "s", TTLMinutes: 5}
+r := httpx.NewRouter(httpx.Deps{Config: cfg, Log: zap.NewNop(), LogStore: st})
+admin, _ := auth.Sign(cfg.Security.JWT, "admin-1", "admin")
+if w := do(r, "GET", "/api/logs/unknown", "198.51.100.3:4000", admin, ""); w.Code != 200 || !strings.Contains(w.Body.String(), `"truncated":true`) { t.Fatalf("truncated lookup: %d %s", w.Code, w.Body) }
+}
+
+
+// waitFor polls cond for up to 5s.
+func waitFor(t *testing.T, cond func() bool) {
+t.Helper()
+for deadline := time.Now().Add(5 * time.Second); !cond(); time.Sleep(10 * time.Millisecond) {
+if time.Now().After(deadline) { t.Fatal("condition not met in time") }
+}
+}
+
+
+func TestLogStoreFilesEntriesUnderTheirTimestampDay(t *testing.T) {
+_, c := miniClient(t)
+ctx := context.Background()
+st := rds.NewLogStore(c, config.LogStore{Retention: config.Retention{Days: 3}})
+now := time.Now().UTC()
+yesterday := now.AddDate(0, 0, -1)
+for _, e := range []rds.LogEntry{
+{Timestamp: yesterday.Format(time.RFC3339), Level: "INFO", Message: "replayed"},
+{Timestamp: now.AddDate(0, 0, -10).Format(time.RFC3339), Level: "INFO", Message: "expired"},
+{Timestamp: "garbage", Level: "INFO", Message: "undated"},
+} {
+if err := st.Save(ctx, e); err != nil { t.Fatal(err) }
+}
+
+dayKey := "logs:" + yesterday.Format("2006-01-02")
+if got := c.LRange(ctx, dayKey, 0, -1).Val(); len(got) != 1 || !strings.Contains(got[0], "replayed") { t.Fatalf("%s: %v", dayKey, got) }
+if ttl := c.TTL(ctx, dayKey).Val(); ttl <= 0 || ttl > 3*24*time.Hour { t.Fatalf("past day ttl: %v", ttl) }
+if got := c.LRange(ctx, "logs:"+now.Format("2006-01-02"), 0, -1).Val(); len(got) != 1 || !strings.Contains(got[0], "undated") { t.Fatalf("today: %v", got) }
+if n := c.Exists(ctx, "logs:"+now.AddDate(0, 0, -10).Format("2006-01-02")).Val(); n != 0 { t.Fatal("list recreated past its retention") }
+if n := c.XLen(ctx, st.Stream()).Val(); n != 3 { t.Fatalf("stream keeps every entry: %d", n) }
+
+// a historical range finds the entry in yesterday's list
+items, _, err := st.Query(ctx, rds.LogFilter{From: yesterday.Add(-time.Minute), To: yesterday.Add(time.Minute)}, 10, "")
+if err != nil || messages(items) != "replayed" { t.Fatalf("range over the replayed entry: %v %v", messages(items), err) }
+}
@@ -0,0 +1,160 @@
+package test
+
+
+import (
+"bufio"
+"context"
+"errors"
+"io"
+"net/http"
+"net/http/httptest"
+"strings"
+"testing"
+"time"
+"example.com/api-gateway/config"
+"example.com/api-gateway/internal/handlers"
+rds "example.com/api-gateway/internal/redis"
+"github.com/gin-gonic/gin"
+"github.com/gorilla/websocket"
+"go.uber.org/zap"
+)
+
+
+// tailRouter serves a live tail of a fresh store at /s.
+func tailRouter(t *testing.T) (*rds.LogStore, *rds.Tailer, *handlers.LogStreamHandler, *gin.Engine) {
+t.Helper()
+_, c := miniClient(t)
+st := rds.NewLogStore(c, config.LogStore{})
+tail := rds.NewTailer(st, zap.NewNop())
+tail.Start()
+t.Cleanup(tail.Stop)
+h := handlers.NewLogStreamHandler(tail)
+h.Heartbeat = 50 * time.Millisecond
+gin.SetMode(gin.TestMode)
+r := gin.New()
+r.GET("/s", h.Stream)
+return st, tail, h, r
+}
+
+
+func saveLevel(t *testing.T, st *rds.LogStore, level, msg string) {
+t.Helper()
+if err := st.Save(context.Background(), rds.LogEntry{Timestamp: time.Now().UTC().Format(time.RFC3339), Level: level, Message: msg}); err != nil { t.Fatal(err) }
+}
+
+
+// readUntil reads SSE lines until one has prefix and returns them all.
+func readUntil(t *testing.T, br *bufio.Reader, prefix string) []string {
+t.Helper()
+var lines []string
+for {
+line, err := br.ReadString('\n')
+if err != nil { t.Fatalf("after %q: %v", lines, err) }
+lines = append(lines, strings.TrimSuffix(line, "\n"))
+if strings.HasPrefix(line, prefix) { return lines }
+}
+}
+
+
+// pipeWriter is a ResponseWriter whose writes block until the test reads them.
+type pipeWriter struct {
+h http.Header
+w *io.PipeWriter
+}
+
+func (p *pipeWriter) Header() http.Header { return p.h }
+func (p *pipeWriter) Write(b []byte) (int, error) { return p.w.Write(b) }
+func (p *pipeWriter) WriteHeader(int) {}
+func (p *pipeWriter) Flush() {}
+
+
+func TestLogTailSSEFramingAndUnsubscribe(t *testing.T) {
+st, tail, _, r := tailRouter(t)
+srv := httptest.NewServer(r)
+defer srv.Close()
+
+resp, err := http.Get(srv.URL + "/s?level=error")
+if err != nil { t.Fatal(err) }
+if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" { t.Fatalf("content type %q", ct) }
+br := bufio.NewReader(resp.Body)
+if got := readUntil(t, br, ": connected"); len(got) != 1 { t.Fatalf("first line: %q", got) }
+readUntil(t, br, ": ping")
+if n := tail.Subscribers(); n != 1 { t.Fatalf("subscribers: %d", n) }
+
+saveLevel(t, st, "INFO", "skip")
+saveLevel(t, st, "ERROR", "boom")
+lines := readUntil(t, br, "data: {")
+got := strings.Join(lines, "\n")
+if strings.Contains(got, "skip") { t.Fatalf("filtered entry delivered: %s", got) }
+ev := lines[len(lines)-3:]
+if !strings.HasPrefix(ev[0], "id: ") || ev[1] != "event: log" || !strings.Contains(ev[2], `"message":"boom"`) { t.Fatalf("framing: %q", ev) }
+if blank, _ := br.ReadString('\n'); blank != "\n" { t.Fatalf("event not terminated: %q", blank) }
+
+resp.Body.Close()
+waitFor(t, func() bool { return tail.Subscribers() == 0 })
+}
+
+
+func TestLogTailSSEReportsDropped(t *testing.T) {
+st, tail, h, r := tailRouter(t)
+h.Buffer = 1
+h.Heartbeat = time.Hour
+pr, pw := io.Pipe()
+ctx, cancel := context.WithCancel(context.Background())
+defer cancel()
+req := httptest.NewRequest("GET", "/s", nil).WithContext(ctx)
+done := make(chan struct{})
+go func() { defer close(done); r.ServeHTTP(&pipeWriter{h: http.Header{}, w: pw}, req) }()
+
+br := bufio.NewReader(pr)
+readUntil(t, br, ": connected")
+readUntil(t, br, "")
+time.Sleep(200 * time.Millisecond) // entries saved before the tailer's first XREAD "$" are not delivered
+// The handler blocks writing the first entry while we don't read; of the
+// rest one fits the buffer and the others are dropped.
+for _, m := range []string{"e1", "e2", "e3", "e4"} { saveLevel(t, st, "INFO", m) }
+time.Sleep(300 * time.Millisecond)
+
+got := strings.Join(readUntil(t, br, "event: dropped"), "\n")
+if !strings.Contains(got, `"message":"e1"`) { t.Fatalf("first entry: %s", got) }
+line, _ := br.ReadString('\n')
+if !strings.HasPrefix(line, "data: ") || line == "data: 0\n" { t.Fatalf("dropped count: %q", line) }
+if next := strings.Join(readUntil(t, br, "data: {"), "\n"); !strings.Contains(next, "event: log") { t.Fatalf("entry after drop report: %s", next) }
+
+cancel()
+pr.Close()
+<-done
+if n := tail.Subscribers(); n != 0 { t.Fatalf("subscribers after disconnect: %d", n) }
+}
+
+
+func TestLogTailWebSocketDeliversAndClosesOnShutdown(t *testing.T) {
+st, tail, _, r := tailRouter(t)
+srv := httptest.NewServer(r)
+defer srv.Close()
+
+ws, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http")+"/s?q=boom", nil)
+if err != nil { t.Fatal(err) }
+defer ws.Close()
+waitFor(t, func() bool { return tail.Subscribers() == 1 })
+
+saveLevel(t, st, "INFO", "quiet")
+saveLevel(t, st, "ERROR", "boom")
+_ = ws.SetReadDeadline(time.Now().Add(5 * time.Second))
+var e rds.LogEntry
+if err := ws.ReadJSON(&e); err != nil || e.Message != "boom" || e.ID == "" { t.Fatalf("entry %+v: %v", e, err) }
+
+tail.Stop()
+var ce *websocket.CloseError
+if _, _, err := ws.ReadMessage(); !errors.As(err, &ce) || ce.Code != websocket.CloseGoingAway { t.Fatalf("want going-away close, got %v", err) }
+waitFor(t, func() bool { return tail.Subscribers() == 0 })
+}
+
+
+func TestLogTailRejectsBadFilters(t *testing.T) {
+_, tail, _, r := tailRouter(t)
+for _, q := range []string{"from=bad", "to=yesterday", "status=abc"} {
+if w := do(r, "GET", "/s?"+q, "198.51.100.3:4000", "", ""); w.Code != 400 || !strings.Contains(w.Body.String(), "error") { t.Fatalf("%s: %d %s", q, w.Code, w.Body) }
+}
+if n := tail.Subscribers(); n != 0 { t.Fatalf("rejected requests subscribed: %d", n) }
+}
@@ -0,0 +1,39 @@
+package test
+
+
+import (
+"strconv"
+"sync/atomic"
+"testing"
+"example.com/api-gateway/config"
+"example.com/api-gateway/internal/rate"
+"go.uber.org/zap"
+)
+
+
+// benchLimiter runs Allow in parallel over a rotating key space (many client IPs).
+// With maxKeys below keys the limiter evicts continuously, like during a scan.
+func benchLimiter(b *testing.B, shards, keys, maxKeys int) {
+cfg := config.RateLimit{RequestsPerMinute: 600, Burst: 100, Shards: shards, MaxKeys: maxKeys}
+l := rate.NewMemoryLimiter(cfg, zap.NewNop())
+defer l.(rate.Stopper).Stop()
+
+names := make([]string, keys)
+for i := range names { names[i] = "10.0." + strconv.Itoa(i/256) + "." + strconv.Itoa(i%256) }
+var worker atomic.Uint64
+b.ReportAllocs()
+b.ResetTimer()
+b.RunParallel(func(pb *testing.PB) {
+i := int(worker.Add(7919)) // each goroutine starts at a different offset
+for pb.Next() {
+l.Allow(names[i%keys])
+i++
+}
+})
+}
+
+
+func BenchmarkMemoryLimiterParallel_1Shard(b *testing.B)        { benchLimiter(b, 1, 10000, 20000) }
+func BenchmarkMemoryLimiterParallel_32Shards(b *testing.B)      { benchLimiter(b, 32, 10000, 20000) }
+func BenchmarkMemoryLimiterParallel_ScanEvicting(b *testing.B)  { benchLimiter(b, 32, 10000, 5000) }
+func BenchmarkMemoryLimiterParallel_HotKey(b *testing.B)        { benchLimiter(b, 32, 1, 0) }
@@ -19,6 +19,7 @@ cfg := config.RateLimit{
 }
 
 l := rate.NewMemoryLimiter(cfg, zap.NewNop())
+defer l.(rate.Stopper).Stop()
 // 1st: allowed
 if ok, _ := l.Allow("k"); !ok { t.Fatal("want allow #1") }
 // 2nd: burst -> allowed
@@ -27,4 +28,32 @@ if ok, _ := l.Allow("k"); !ok { t.Fatal("want allow #2") }
 if ok, _ := l.Allow("k"); ok { t.Fatal("want deny #3") }
 time.Sleep(time.Second * 31) // refill half a minute -> at least one token
 if ok, _ := l.Allow("k"); !ok { t.Fatal("want allow after refill") }
-}
\ No newline at end of file
+}
+
+func TestMemoryLimiterMaxKeysEvictsLRU(t *testing.T) {
+cfg := config.RateLimit{RequestsPerMinute: 1, Burst: 1, Shards: 1, MaxKeys: 2}
+l := rate.NewMemoryLimiter(cfg, zap.NewNop())
+defer l.(rate.Stopper).Stop()
+
+if ok, _ := l.Allow("a"); !ok { t.Fatal("want allow a") }
+if ok, _ := l.Allow("a"); ok { t.Fatal("want deny a (bucket empty)") }
+// Two fresh keys push "a" out of the single shard (cap 2).
+l.Allow("b")
+l.Allow("c")
+// "a" was evicted, so it starts over with a full bucket.
+if ok, _ := l.Allow("a"); !ok { t.Fatal("want allow a after eviction") }
+}
+
+
+func TestMemoryLimiterMaxKeysPrefersFullBuckets(t *testing.T) {
+cfg := config.RateLimit{RequestsPerMinute: 600, Burst: 5, Shards: 1, MaxKeys: 2} // a token every 100ms
+l := rate.NewMemoryLimiter(cfg, zap.NewNop())
+defer l.(rate.Stopper).Stop()
+
+for i := 0; i < 5; i++ { l.Allow("a") } // drained, and the coldest key
+l.Allow("b")                             // one token short; refills first
+time.Sleep(120 * time.Millisecond)
+l.Allow("c") // must evict the full "b", not the drained "a"
+if ok, _ := l.Allow("a"); !ok { t.Fatal("want allow a: one token refilled") }
+if ok, _ := l.Allow("a"); ok { t.Fatal("drained bucket was evicted and came back full") }
+}
@@ -0,0 +1,99 @@
+package test
+
+
+import (
+"net/http"
+"net/http/httptest"
+"testing"
+"example.com/api-gateway/config"
+"example.com/api-gateway/internal/http/middleware"
+"example.com/api-gateway/internal/rate"
+"github.com/gin-gonic/gin"
+"github.com/prometheus/client_golang/prometheus"
+"go.uber.org/zap"
+)
+
+
+// metricValue sums the samples of name whose labels include want.
+func metricValue(t *testing.T, name string, want map[string]string) float64 {
+t.Helper()
+mfs, err := prometheus.DefaultGatherer.Gather()
+if err != nil { t.Fatal(err) }
+total := 0.0
+for _, mf := range mfs {
+if mf.GetName() != name { continue }
+for _, m := range mf.GetMetric() {
+labels := map[string]string{}
+for _, lp := range m.GetLabel() { labels[lp.GetName()] = lp.GetValue() }
+ok := true
+for k, v := range want { if labels[k] != v { ok = false } }
+if !ok { continue }
+switch {
+case m.GetCounter() != nil: total += m.GetCounter().GetValue()
+case m.GetHistogram() != nil: total += float64(m.GetHistogram().GetSampleCount())
+case m.GetGauge() != nil: total += m.GetGauge().GetValue()
+}
+}
+}
+return total
+}
+
+
+func serve(r http.Handler, method, path string, hdr map[string]string) int {
+req := httptest.NewRequest(method, path, nil)
+for k, v := range hdr { req.Header.Set(k, v) }
+w := httptest.NewRecorder()
+r.ServeHTTP(w, req)
+return w.Code
+}
+
+
+func TestHTTPMetricsUseRouteTemplates(t *testing.T) {
+gin.SetMode(gin.TestMode)
+r := gin.New()
+r.Use(gin.Recovery(), middleware.Metrics())
+r.GET("/things/:id", func(c *gin.Context) { c.Status(http.StatusOK) })
+r.GET("/boom", func(c *gin.Context) { panic("boom") })
+
+route := map[string]string{"route": "/things/:id", "method": "GET", "status": "2xx"}
+before := metricValue(t, "gateway_http_requests_total", route)
+for _, id := range []string{"1", "2", "3"} { serve(r, "GET", "/things/"+id, nil) }
+if got := metricValue(t, "gateway_http_requests_total", route) - before; got != 3 { t.Fatalf("want 3 requests on the template, got %v", got) }
+if got := metricValue(t, "gateway_http_request_duration_seconds", route); got < 3 { t.Fatalf("want latency samples, got %v", got) }
+
+unmatched := map[string]string{"route": "unmatched", "status": "4xx"}
+before = metricValue(t, "gateway_http_requests_total", unmatched)
+serve(r, "GET", "/random/a", nil)
+serve(r, "PROPFIND", "/random/b", nil)
+if got := metricValue(t, "gateway_http_requests_total", unmatched) - before; got != 2 { t.Fatalf("raw paths must not become labels, got %v", got) }
+if metricValue(t, "gateway_http_requests_total", map[string]string{"method": "OTHER"}) < 1 { t.Fatal("unknown methods must map to OTHER") }
+
+panicked := map[string]string{"route": "/boom", "status": "5xx"}
+if code := serve(r, "GET", "/boom", nil); code != http.StatusInternalServerError { t.Fatalf("want 500, got %d", code) }
+if metricValue(t, "gateway_http_requests_total", panicked) != 1 { t.Fatal("panics must count as 5xx") }
+if metricValue(t, "gateway_http_in_flight_requests", nil) != 0 { t.Fatal("in-flight gauge must return to 0") }
+}
+
+
+func TestAuthAndRateLimitCounters(t *testing.T) {
+gin.SetMode(gin.TestMode)
+cfg := config.RateLimit{RequestsPerMinute: 1, Burst: 1}
+lim := rate.NewMemoryLimiter(cfg, zap.NewNop())
+r := gin.New()
+r.GET("/limited", middleware.RateLimitIP(lim, nil, 1, nil), func(c *gin.Context) { c.Status(http.StatusOK) })
+r.GET("/private", middleware.Authenticated(config.JWT{Secret: "s"}), func(c *gin.Context) { c.Status(http.StatusOK) })
+
+allowed := map[string]string{"policy": "memory", "result": "allowed"}
+denied := map[string]string{"policy": "memory", "result": "denied"}
+a0, d0 := metricValue(t, "gateway_ratelimit_decisions_total", allowed), metricValue(t, "gateway_ratelimit_decisions_total", denied)
+for i := 0; i < 5; i++ { serve(r, "GET", "/limited", nil) }
+a, d := metricValue(t, "gateway_ratelimit_decisions_total", allowed)-a0, metricValue(t, "gateway_ratelimit_decisions_total", denied)-d0
+if a+d != 5 || d == 0 { t.Fatalf("want 5 decisions with denials, got allowed=%v denied=%v", a, d) }
+
+missing := map[string]string{"reason": "missing_token"}
+invalid := map[string]string{"reason": "invalid_token"}
+m0, i0 := metricValue(t, "gateway_auth_failures_total", missing), metricValue(t, "gateway_auth_failures_total", invalid)
+serve(r, "GET", "/private", nil)
+serve(r, "GET", "/private", map[string]string{"Authorization": "Bearer not.a.jwt"})
+if metricValue(t, "gateway_auth_failures_total", missing)-m0 != 1 || metricValue(t, "gateway_auth_failures_total", invalid)-i0 != 1 { t.Fatal("auth failures not counted by reason") }
+}
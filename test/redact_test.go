@@ -0,0 +1,137 @@
+package test
+
+
+import (
+"os"
+"path/filepath"
+"strings"
+"testing"
+"example.com/api-gateway/config"
+"example.com/api-gateway/internal/logger"
+"example.com/api-gateway/internal/redact"
+"go.uber.org/zap"
+"go.uber.org/zap/zapcore"
+)
+
+
+func newRedactor(t *testing.T, cfg config.Redaction) *redact.Redactor {
+t.Helper()
+cfg.Enabled = true
+r, err := redact.New(cfg)
+if err != nil { t.Fatal(err) }
+return r
+}
+
+
+func TestRedactBuiltinPatterns(t *testing.T) {
+r := newRedactor(t, config.Redaction{Builtin: []string{"email", "bearer", "jwt", "password", "card"}})
+cases := map[string]string{
+"mail ann@example.com now":                 "mail [REDACTED] now",
+"Authorization: Bearer abc.def-123":        "Authorization: Bearer [REDACTED]",
+"tok eyJhbGciOi.eyJzdWIiOiIx.sig_-x end":   "tok [REDACTED] end",
+`{"username":"a","password":"hunter2"}`:    `{"username":"a","password":[REDACTED]}`,
+"password=hunter2&x=1":                     "password=[REDACTED]&x=1",
+"card 4111 1111 1111 1111 ok":              "card [REDACTED] ok",
+"order 1234567890123 not a card":           "order 1234567890123 not a card", // fails Luhn
+}
+for in, want := range cases {
+if got := r.String(in); got != want { t.Fatalf("%q: want %q, got %q", in, want, got) }
+}
+}
+
+
+func TestRedactFieldsAndHashMode(t *testing.T) {
+r := newRedactor(t, config.Redaction{HashKey: "k",
+Fields:   []config.RedactField{{Name: "Password"}, {Name: "ip", Mode: "hash"}},
+Patterns: []config.RedactPattern{{Name: "ssn", Regex: `ssn:(?P<v>\d{3}-\d{2}-\d{4})`}}})
+
+m := r.Map(map[string]any{"password": "x", "ip": "10.0.0.1", "note": "ssn:123-45-6789",
+"nested": map[string]any{"PASSWORD": 42}, "path": "/users"})
+if m["password"] != redact.Masked || m["path"] != "/users" || m["note"] != "ssn:"+redact.Masked { t.Fatalf("unexpected %v", m) }
+if m["nested"].(map[string]any)["PASSWORD"] != redact.Masked { t.Fatal("nested field not redacted") }
+h := m["ip"].(string)
+if !strings.HasPrefix(h, "hash:") || r.Map(map[string]any{"ip": "10.0.0.1"})["ip"] != h { t.Fatalf("hash should be stable: %v", h) }
+if r.Map(map[string]any{"ip": "10.0.0.2"})["ip"] == h { t.Fatal("different values should hash differently") }
+}
+
+
+func TestRedactConfigErrorsAndDisabled(t *testing.T) {
+bad := []config.Redaction{
+{Enabled: true, Mode: "shred"},
+{Enabled: true, Builtin: []string{"ssn"}},
+{Enabled: true, Patterns: []config.RedactPattern{{Name: "x", Regex: "("}}},
+{Enabled: true, Fields: []config.RedactField{{Name: "x", Mode: "zap"}}},
+{Enabled: true, Fields: []config.RedactField{{Name: "ip", Mode: "hash"}}}, // no hash_key
+}
+for _, c := range bad {
+if _, err := redact.New(c); err == nil { t.Fatalf("want error for %+v", c) }
+}
+r, err := redact.New(config.Redaction{Builtin: []string{"email"}})
+if err != nil || r != nil { t.Fatal("disabled config should yield a nil redactor") }
+if r.String("a@b.io") != "a@b.io" { t.Fatal("nil redactor must pass values through") }
+}
+
+
+func TestLoggerRedactsBeforeWriting(t *testing.T) {
+dir := t.TempDir()
+log, ctl, err := logger.New(config.Logging{Level: "info", File: config.LogFile{Path: "-"},
+Sinks:     []config.LogSink{{Type: "file", File: config.FileSink{Dir: dir}}},
+Redaction: config.Redaction{Enabled: true, Fields: []config.RedactField{{Name: "token"}}, Builtin: []string{"email"}}}, nil)
+if err != nil { t.Fatal(err) }
+log.With(zap.String("token", "s3cret")).Info("login for bob@example.com", zap.String("contact", "bob@example.com"), zap.Int("n", 1))
+ctl.Close()
+
+b, _ := os.ReadFile(filepath.Join(dir, "app.ndjson"))
+out := string(b)
+if strings.Contains(out, "s3cret") || strings.Contains(out, "bob@example.com") { t.Fatalf("leaked: %s", out) }
+if !strings.Contains(out, `"n":1`) { t.Fatalf("other fields should pass through: %s", out) }
+}
+
+
+type loginAttempt struct{ user, password string }
+
+func (a loginAttempt) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+enc.AddString("user", a.user)
+enc.AddString("password", a.password)
+return nil
+}
+
+
+type contacts []string
+
+func (c contacts) MarshalLogArray(enc zapcore.ArrayEncoder) error {
+for _, s := range c { enc.AppendString(s) }
+return nil
+}
+
+
+func TestLoggerRedactsObjectsArraysAndInline(t *testing.T) {
+dir := t.TempDir()
+log, ctl, err := logger.New(config.Logging{Level: "info", File: config.LogFile{Path: "-"},
+Sinks:     []config.LogSink{{Type: "file", File: config.FileSink{Dir: dir}}},
+Redaction: config.Redaction{Enabled: true, Fields: []config.RedactField{{Name: "password"}}, Builtin: []string{"email"}}}, nil)
+if err != nil { t.Fatal(err) }
+a := loginAttempt{"bob@example.com", "hunter2"}
+log.Info("attempt", zap.Object("login", a), zap.Array("cc", contacts{"ann@example.com", "ok"}), zap.Inline(a))
+ctl.Close()
+
+b, _ := os.ReadFile(filepath.Join(dir, "app.ndjson"))
+out := string(b)
+if strings.Contains(out, "hunter2") || strings.Contains(out, "@example.com") { t.Fatalf("leaked: %s", out) }
+if !strings.Contains(out, `"login":{"password":"[REDACTED]","user":"[REDACTED]"}`) || !strings.Contains(out, `"cc":["[REDACTED]","ok"]`) { t.Fatalf("structure lost: %s", out) }
+}
+
+
+func TestLoggerRedactionKeepsSinkLevels(t *testing.T) {
+dir := t.TempDir()
+log, ctl, err := logger.New(config.Logging{Level: "debug", File: config.LogFile{Path: "-"},
+Sinks:     []config.LogSink{{Type: "file", Level: "warn", File: config.FileSink{Dir: dir}}},
+Redaction: config.Redaction{Enabled: true, Builtin: []string{"email"}}}, nil)
+if err != nil { t.Fatal(err) }
+log.Info("below the sink level")
+log.Warn("for bob@example.com")
+ctl.Close()
+
+b, _ := os.ReadFile(filepath.Join(dir, "app.ndjson"))
+if out := string(b); strings.Contains(out, "below the sink level") || !strings.Contains(out, "for [REDACTED]") { t.Fatalf("%s", out) }
+}
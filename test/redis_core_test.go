@@ -0,0 +1,87 @@
+package test
+
+
+import (
+"context"
+"errors"
+"io"
+"net"
+"testing"
+"time"
+"example.com/api-gateway/config"
+"example.com/api-gateway/internal/logger"
+rds "example.com/api-gateway/internal/redis"
+"go.uber.org/zap"
+)
+
+
+// stalledRedis accepts connections and never answers, like a Redis that hangs.
+func stalledRedis(t *testing.T) rds.Client {
+t.Helper()
+ln, err := net.Listen("tcp", "127.0.0.1:0")
+if err != nil { t.Fatal(err) }
+t.Cleanup(func() { ln.Close() })
+go func() {
+for {
+conn, err := ln.Accept()
+if err != nil { return }
+t.Cleanup(func() { conn.Close() })
+go io.Copy(io.Discard, conn)
+}
+}()
+c := rds.Dial(config.Redis{Addresses: []string{ln.Addr().String()}})
+t.Cleanup(func() { c.Close() })
+return c
+}
+
+
+func TestRedisCoreLevelAndFields(t *testing.T) {
+_, c := miniClient(t)
+st := rds.NewLogStore(c, config.LogStore{})
+a, _ := rds.NewAsyncLogger(st, config.AsyncLog{FlushIntervalMS: 10})
+a.Start()
+log, ctl, err := logger.New(config.Logging{Level: "debug", RedisLevel: "warn", File: config.LogFile{Path: "-"}}, a)
+if err != nil { t.Fatal(err) }
+defer ctl.Close()
+
+svc := log.Named("svc").With(zap.String("user", "u1"))
+svc.Info("below redis_level")
+svc.Warn("slow query", zap.Int("ms", 250), zap.Error(errors.New("timeout")), zap.Strings("tables", []string{"a", "b"}))
+svc.Error("failed")
+log.Named(logger.AccessLogger).Error("access entries are enqueued by the router")
+if err := a.Stop(context.Background()); err != nil { t.Fatal(err) }
+
+items, _, err := st.LoadRecent(context.Background(), 10, "")
+if err != nil { t.Fatal(err) }
+if got := messages(items); got != "failed,slow query" { t.Fatalf("stored: %s", got) }
+warn, errEntry := items[1], items[0]
+if warn.Level != "WARN" || errEntry.Level != "ERROR" { t.Fatalf("levels: %s %s", warn.Level, errEntry.Level) }
+if _, err := time.Parse(time.RFC3339, warn.Timestamp); err != nil { t.Fatalf("timestamp %q: %v", warn.Timestamp, err) }
+ctx := warn.Context
+if ctx["user"] != "u1" || ctx["ms"] != float64(250) || ctx["error"] != "timeout" || ctx["logger"] != "svc" { t.Fatalf("fields: %v", ctx) }
+if tables, _ := ctx["tables"].([]any); len(tables) != 2 || tables[0] != "a" { t.Fatalf("array field: %v", ctx["tables"]) }
+if caller, _ := ctx["caller"].(string); caller == "" { t.Fatalf("caller missing: %v", ctx) }
+if _, ok := ctx["stack"]; ok { t.Fatal("warn entries carry no stack") }
+if stack, _ := errEntry.Context["stack"].(string); stack == "" { t.Fatalf("error entry without stack: %v", errEntry.Context) }
+}
+
+
+func TestRedisCoreNeverBlocksOnStalledRedis(t *testing.T) {
+a, _ := rds.NewAsyncLogger(rds.NewLogStore(stalledRedis(t), config.LogStore{}), config.AsyncLog{Buffer: 8, BatchSize: 4, FlushIntervalMS: 10})
+a.Start()
+log, ctl, err := logger.New(config.Logging{Level: "info", File: config.LogFile{Path: "-"}}, a)
+if err != nil { t.Fatal(err) }
+defer ctl.Close()
+
+const n = 100
+start := time.Now()
+for i := 0; i < n; i++ { log.Info("burst", zap.Int("i", i)) }
+if took := time.Since(start); took > time.Second { t.Fatalf("logging waited on Redis: %v for %d entries", took, n) }
+if st := a.Stats(); st.Dropped == 0 || st.Queued > 8 { t.Fatalf("full queue should drop: %+v", st) }
+
+ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+defer cancel()
+if err := a.Stop(ctx); !errors.Is(err, context.DeadlineExceeded) { t.Fatalf("Stop: %v", err) }
+st := a.Stats()
+if st.Saved != 0 || st.Dropped+st.Failed != n { t.Fatalf("every entry is either dropped or failed: %+v", st) }
+}
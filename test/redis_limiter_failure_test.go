@@ -0,0 +1,67 @@
+package test
+
+
+import (
+"testing"
+"time"
+"example.com/api-gateway/config"
+"example.com/api-gateway/internal/rate"
+rds "example.com/api-gateway/internal/redis"
+"go.uber.org/zap"
+)
+
+
+// deadRedis points at a port nothing listens on, so every command fails fast.
+func deadRedis() rds.Client { return rds.Dial(config.Redis{Addresses: []string{"127.0.0.1:1"}}) }
+
+
+func TestRedisLimiterFailureModes(t *testing.T) {
+base := config.RateLimit{RequestsPerMinute: 1, Burst: 1}
+
+open := base; open.FailureMode = "open"
+l := rate.NewRedisLimiter(deadRedis(), open, nil, zap.NewNop())
+if ok, _ := l.Allow("k"); !ok { t.Fatal("open: want allow while redis is down") }
+
+closed := base; closed.FailureMode = "closed"
+l = rate.NewRedisLimiter(deadRedis(), closed, nil, zap.NewNop())
+if ok, retry := l.Allow("k"); ok || retry <= 0 { t.Fatal("closed: want deny with retry while redis is down") }
+
+local := base; local.FailureMode = "local"
+l = rate.NewRedisLimiter(deadRedis(), local, nil, zap.NewNop())
+defer l.(rate.Stopper).Stop()
+if ok, _ := l.Allow("k"); !ok { t.Fatal("local: want allow #1 from fallback bucket") }
+if ok, _ := l.Allow("k"); ok { t.Fatal("local: want deny #2 once fallback bucket is empty") }
+}
+
+
+func TestMonitorStopWithoutStart(t *testing.T) {
+mon := rds.NewMonitor(deadRedis(), 0, true, zap.NewNop())
+done := make(chan struct{})
+go func() { mon.Stop(); mon.Stop(); close(done) }()
+select {
+case <-done:
+case <-time.After(time.Second):
+t.Fatal("Stop without Start hangs")
+}
+mon.Start() // no-op after Stop
+}
+
+
+func TestRedisLimiterSkipsRedisWhenMonitorDown(t *testing.T) {
+c := deadRedis()
+mon := rds.NewMonitor(c, 0, false, zap.NewNop()) // startup ping failed
+cfg := config.RateLimit{RequestsPerMinute: 1, Burst: 1, FailureMode: "closed"}
+l := rate.NewRedisLimiter(c, cfg, mon, zap.NewNop())
+if ok, _ := l.Allow("k"); ok { t.Fatal("want closed-mode deny without touching redis") }
+}
+
+
+func TestHybridLimiterCountsLocallyWhenRedisDown(t *testing.T) {
+cfg := config.RateLimit{RequestsPerMinute: 2, Burst: 0, FailureMode: "local", MaxLocalPending: 1, SyncIntervalMS: 10}
+l := rate.NewHybridLimiter(deadRedis(), cfg, nil, zap.NewNop())
+defer l.(rate.Stopper).Stop()
+// Inline flushes fail, but the hits stay counted locally.
+if ok, _ := l.Allow("k"); !ok { t.Fatal("want allow #1") }
+if ok, _ := l.Allow("k"); !ok { t.Fatal("want allow #2") }
+if ok, retry := l.Allow("k"); ok || retry <= 0 { t.Fatal("want deny #3 with retry") }
+}
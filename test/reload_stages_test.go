@@ -0,0 +1,131 @@
+package test
+
+
+import (
+"net/http"
+"net/http/httptest"
+"sync/atomic"
+"testing"
+"example.com/api-gateway/config"
+httpx "example.com/api-gateway/internal/http"
+"example.com/api-gateway/internal/rate"
+"example.com/api-gateway/internal/reload"
+"github.com/gin-gonic/gin"
+"go.uber.org/zap"
+)
+
+
+// spyLimiter records Stop.
+type spyLimiter struct {
+rate.Limiter
+stopped atomic.Bool
+}
+
+func (s *spyLimiter) Stop() { s.stopped.Store(true) }
+
+
+func stagesConfig(origin string, burst int) config.Root {
+cfg := config.Root{}
+cfg.Security.JWT = config.JWT{Secret: "s", TTLMinutes: 5}
+cfg.Server.CORS = config.CORS{AllowedOrigins: []string{origin}}
+cfg.RateLimit = config.RateLimit{Enabled: true, Strategy: "memory", RequestsPerMinute: 1, Burst: burst}
+return cfg
+}
+
+
+// liveStages serves cfg through a Live whose reloader loads *next; built
+// collects every limiter made on the way.
+func liveStages(t *testing.T, cfg config.Root, next *config.Root, admin *httpx.Swappable) (*httpx.Swappable, *reload.Reloader, *[]*spyLimiter) {
+t.Helper()
+gin.SetMode(gin.TestMode)
+built := &[]*spyLimiter{}
+newLimiter := func(rc config.RateLimit) rate.Limiter {
+l := &spyLimiter{Limiter: rate.NewMemoryLimiter(rc, zap.NewNop())}
+*built = append(*built, l)
+return l
+}
+newConcurrency := func(config.Concurrency) rate.ConcurrencyLimiter { return nil }
+d := httpx.Deps{Config: cfg, Log: zap.NewNop(), Limiter: newLimiter(cfg.RateLimit)}
+public := httpx.NewSwappable(httpx.NewRouter(d))
+live := httpx.NewLive(d, public, admin, newLimiter, newConcurrency)
+r := reload.New(nil, cfg, func() (config.Root, error) { return *next, nil }, zap.NewNop())
+for _, s := range live.Stages() { r.Register(s) }
+return public, r, built
+}
+
+
+func fromOrigin(h http.Handler, origin string) *httptest.ResponseRecorder {
+req := httptest.NewRequest(http.MethodGet, "/users", nil)
+req.RemoteAddr = "203.0.113.9:4000"
+req.Header.Set("Origin", origin)
+w := httptest.NewRecorder()
+h.ServeHTTP(w, req)
+return w
+}
+
+
+func TestReloadRouterStageSwapsCORSAndLimits(t *testing.T) {
+cfg := stagesConfig("https://a.example", 1)
+next := stagesConfig("https://b.example", 3)
+public, r, built := liveStages(t, cfg, &next, nil)
+
+if w := fromOrigin(public, "https://b.example"); w.Code != 401 || w.Header().Get("Access-Control-Allow-Origin") != "" { t.Fatalf("before: %d %v", w.Code, w.Header()) }
+if w := fromOrigin(public, "https://b.example"); w.Code != 429 { t.Fatalf("burst 1: %d", w.Code) }
+
+if res := r.Reload("test"); res.Outcome != reload.Applied { t.Fatalf("%+v", res) }
+if len(*built) != 2 || !(*built)[0].stopped.Load() || (*built)[1].stopped.Load() { t.Fatal("the old limiter must be stopped, the new one kept") }
+codes := []int{}
+for i := 0; i < 4; i++ {
+w := fromOrigin(public, "https://b.example")
+if w.Header().Get("Access-Control-Allow-Origin") != "https://b.example" { t.Fatalf("new CORS policy not live: %v", w.Header()) }
+codes = append(codes, w.Code)
+}
+if codes[0] != 401 || codes[2] != 401 || codes[3] != 429 { t.Fatalf("new limiter (burst 3) not live: %v", codes) }
+
+// CORS alone keeps the limiter and its state
+next.Server.CORS.AllowedOrigins = []string{"https://c.example"}
+if res := r.Reload("test"); res.Outcome != reload.Applied { t.Fatalf("%+v", res) }
+if len(*built) != 2 || (*built)[1].stopped.Load() { t.Fatal("limiter replaced for a CORS change") }
+if w := fromOrigin(public, "https://c.example"); w.Code != 429 { t.Fatalf("limiter state lost: %d", w.Code) }
+}
+
+
+func TestReloadRouterStageFailureKeepsLiveHandler(t *testing.T) {
+cfg := stagesConfig("https://a.example", 1)
+cfg.Server.Admin.Address = "127.0.0.1:0" // no auth: the admin engine cannot be rebuilt
+next := cfg
+next.Server.CORS.AllowedOrigins = []string{"https://b.example"}
+next.RateLimit.Burst = 5
+admin := httpx.NewSwappable(http.NotFoundHandler())
+public, r, built := liveStages(t, cfg, &next, admin)
+
+res := r.Reload("test")
+if res.Outcome != reload.Rejected || res.Error == "" { t.Fatalf("%+v", res) }
+if len(*built) != 2 || (*built)[0].stopped.Load() || !(*built)[1].stopped.Load() { t.Fatal("the limiter built for the failed reload must be stopped, the live one kept") }
+if w := fromOrigin(public, "https://b.example"); w.Header().Get("Access-Control-Allow-Origin") != "" { t.Fatal("failed reload swapped the handler") }
+if w := fromOrigin(public, "https://a.example"); w.Header().Get("Access-Control-Allow-Origin") != "https://a.example" || w.Code != 429 { t.Fatalf("live handler changed: %d %v", w.Code, w.Header()) }
+}
+
+
+func TestReloadRouterStageIgnoresAccessListRefresh(t *testing.T) {
+gin.SetMode(gin.TestMode)
+cfg := stagesConfig("https://a.example", 1)
+built := 0
+newLimiter := func(rc config.RateLimit) rate.Limiter { built++; return rate.NewMemoryLimiter(rc, zap.NewNop()) }
+d := httpx.Deps{Config: cfg, Log: zap.NewNop(), Limiter: newLimiter(cfg.RateLimit)}
+live := httpx.NewLive(d, httpx.NewSwappable(httpx.NewRouter(d)), nil, newLimiter, func(config.Concurrency) rate.ConcurrencyLimiter { return nil })
+defer live.Stop()
+
+// access_list_refresh_ms is restart-only; it must not cost the buckets
+// when it changes together with a router path.
+next := cfg
+next.Server.CORS.AllowedOrigins = []string{"https://b.example"}
+next.RateLimit.AccessListRefreshMS = 1234
+for _, s := range live.Stages() {
+if s.Name != "router" { continue }
+commit, err := s.Prepare(cfg, next)
+if err != nil { t.Fatal(err) }
+commit()
+}
+if built != 1 || live.Deps().Limiter != d.Limiter { t.Fatalf("limiter rebuilt (%d built)", built) }
+}
@@ -0,0 +1,173 @@
+package test
+
+
+import (
+"context"
+"net/http"
+"net/http/httptest"
+"path/filepath"
+"strings"
+"testing"
+"time"
+"example.com/api-gateway/config"
+"example.com/api-gateway/internal/auth"
+"example.com/api-gateway/internal/domain"
+"example.com/api-gateway/internal/handlers"
+"example.com/api-gateway/internal/health"
+"example.com/api-gateway/internal/http/middleware"
+"example.com/api-gateway/internal/repository"
+"example.com/api-gateway/internal/service"
+"example.com/api-gateway/internal/tracing"
+apperr "example.com/api-gateway/pkg/errors"
+"github.com/gin-gonic/gin"
+sdktrace "go.opentelemetry.io/otel/sdk/trace"
+"go.opentelemetry.io/otel/sdk/trace/tracetest"
+"go.opentelemetry.io/otel/trace"
+"go.uber.org/zap"
+"go.uber.org/zap/zaptest/observer"
+)
+
+
+// memRepo is an in-memory UserRepository keyed by email.
+type memRepo struct{ users map[string]*domain.User }
+
+func (m memRepo) Create(_ context.Context, u *domain.User) error { m.users[u.Email] = u; return nil }
+func (m memRepo) GetByID(_ context.Context, id string) (*domain.User, error) { return nil, repository.ErrNotFound }
+func (m memRepo) GetByEmail(_ context.Context, email string) (*domain.User, error) {
+if u, ok := m.users[email]; ok { return u, nil }
+return nil, repository.ErrNotFound
+}
+func (m memRepo) List(context.Context, int, int) ([]domain.User, error) { return nil, nil }
+func (m memRepo) Update(context.Context, *domain.User) error { return nil }
+func (m memRepo) Delete(context.Context, string) error { return nil }
+
+
+func installMemTracing(t *testing.T) (*tracetest.InMemoryExporter, *sdktrace.TracerProvider) {
+t.Helper()
+exp := tracetest.NewInMemoryExporter()
+tp := tracing.Install(exp, config.Tracing{ServiceName: "test"})
+t.Cleanup(func() { _ = tp.Shutdown(context.Background()) })
+return exp, tp
+}
+
+
+func spanByName(spans tracetest.SpanStubs, name string) (tracetest.SpanStub, bool) {
+for _, s := range spans { if s.Name == name { return s, true } }
+return tracetest.SpanStub{}, false
+}
+
+
+func TestTracingLoginSpansContinueIncomingTrace(t *testing.T) {
+exp, tp := installMemTracing(t)
+hash, _ := auth.Hash("secret123")
+repo := memRepo{users: map[string]*domain.User{"a@b.c": {ID: "u1", Email: "a@b.c", PasswordHash: hash, Role: "user", Active: true}}}
+authSvc := service.NewAuthService(repo, config.JWT{Secret: "s", TTLMinutes: 1}, zap.NewNop())
+
+gin.SetMode(gin.TestMode)
+r := gin.New()
+r.Use(middleware.RequestID(), middleware.Tracing())
+r.POST("/auth/login", handlers.NewAuthHandler(authSvc).Login)
+
+const parent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+req := httptest.NewRequest(http.MethodPost, "/auth/login", strings.NewReader(`{"email":"a@b.c","password":"secret123"}`))
+req.Header.Set("Content-Type", "application/json")
+req.Header.Set("traceparent", parent)
+w := httptest.NewRecorder()
+r.ServeHTTP(w, req)
+if w.Code != http.StatusOK { t.Fatalf("login failed: %d %s", w.Code, w.Body.String()) }
+_ = tp.ForceFlush(context.Background())
+
+spans := exp.GetSpans()
+server, ok := spanByName(spans, "POST /auth/login")
+if !ok { t.Fatalf("no server span in %v", spans) }
+if server.SpanContext.TraceID().String() != "4bf92f3577b34da6a3ce929d0e0e4736" { t.Fatal("server span must continue the incoming trace") }
+if server.Parent.SpanID().String() != "00f067aa0ba902b7" || server.SpanKind != trace.SpanKindServer { t.Fatalf("bad server span %+v", server) }
+login, ok := spanByName(spans, "AuthService.Login")
+if !ok || login.Parent.SpanID() != server.SpanContext.SpanID() { t.Fatal("AuthService.Login must be a child of the server span") }
+verify, ok := spanByName(spans, "bcrypt.Verify")
+if !ok || verify.Parent.SpanID() != login.SpanContext.SpanID() { t.Fatal("bcrypt.Verify must be a child of AuthService.Login") }
+}
+
+
+func TestTracingTransportPropagatesTraceparent(t *testing.T) {
+_, _ = installMemTracing(t)
+var got string
+upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { got = r.Header.Get("traceparent") }))
+defer upstream.Close()
+
+ctx, span := tracing.Tracer("test").Start(context.Background(), "parent")
+req, _ := http.NewRequestWithContext(ctx, http.MethodGet, upstream.URL, nil)
+resp, err := (&http.Client{Transport: tracing.Transport(nil)}).Do(req)
+if err != nil { t.Fatal(err) }
+resp.Body.Close()
+span.End()
+if req.Header.Get("traceparent") != "" { t.Fatal("caller's request must not be modified") }
+if !strings.Contains(got, span.SpanContext().TraceID().String()) { t.Fatalf("upstream got traceparent %q, want trace %s", got, span.SpanContext().TraceID()) }
+}
+
+
+func TestErrorEnvelopeCarriesTraceID(t *testing.T) {
+_, _ = installMemTracing(t)
+gin.SetMode(gin.TestMode)
+r := gin.New()
+r.Use(middleware.RequestID(), middleware.Tracing())
+var traceID string
+r.GET("/fail", func(c *gin.Context) { traceID = tracing.TraceID(c.Request.Context()); apperr.JSON(c, 400, "bad_request", "nope") })
+w := httptest.NewRecorder()
+r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/fail", nil))
+if traceID == "" || !strings.Contains(w.Body.String(), `"traceId":"`+traceID+`"`) { t.Fatalf("want traceId %q in %s", traceID, w.Body.String()) }
+}
+
+
+func TestTracingReadinessUpstreamSeesTraceparent(t *testing.T) {
+_, _ = installMemTracing(t)
+got := make(chan string, 1)
+upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { got <- r.Header.Get("traceparent") }))
+defer upstream.Close()
+ready := health.NewReadiness(time.Second)
+ready.Register(health.HTTP("billing", upstream.URL, nil))
+
+req := httptest.NewRequest(http.MethodGet, "/health/ready", nil)
+req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+w := httptest.NewRecorder()
+readyRouter(ready).ServeHTTP(w, req)
+if w.Code != 200 { t.Fatalf("%d %s", w.Code, w.Body) }
+if tp := <-got; !strings.HasPrefix(tp, "00-4bf92f3577b34da6a3ce929d0e0e4736-") { t.Fatalf("upstream got traceparent %q", tp) }
+
+// background requests (no span) are sent untouched
+if err := health.HTTP("billing", upstream.URL, nil).Check(context.Background()); err != nil { t.Fatal(err) }
+if tp := <-got; tp != "" { t.Fatalf("untraced request got traceparent %q", tp) }
+}
+
+
+func TestTracingServiceAndRepoLogsCarryTraceID(t *testing.T) {
+_, _ = installMemTracing(t)
+core, logs := observer.New(zap.InfoLevel)
+authSvc := service.NewAuthService(memRepo{users: map[string]*domain.User{}}, config.JWT{Secret: "s", TTLMinutes: 1}, zap.New(core))
+ctx, span := tracing.Tracer("test").Start(context.Background(), "request")
+if _, _, err := authSvc.Login(ctx, "nobody@b.c", "x"); err == nil { t.Fatal("unknown user logged in") }
+
+repo, err := repository.NewGormRepo("sqlite", filepath.Join(t.TempDir(), "t.db"), zap.New(core))
+if err != nil { t.Fatal(err) }
+repo.Close()
+if _, err := repo.GetByID(ctx, "u1"); err == nil { t.Fatal("closed db answered") }
+span.End()
+
+want := span.SpanContext().TraceID().String()
+for _, msg := range []string{"login rejected", "query failed"} {
+e := logs.FilterMessage(msg).All()
+if len(e) != 1 || e[0].ContextMap()["trace_id"] != want { t.Fatalf("%s: %+v", msg, e) }
+}
+}
+
+
+func TestTracingSampleRatioZeroRecordsNothing(t *testing.T) {
+zero := 0.0
+for _, tc := range []struct{ ratio *float64; sampled bool }{{nil, true}, {&zero, false}} {
+tp := tracing.Install(tracetest.NewInMemoryExporter(), config.Tracing{SampleRatio: tc.ratio})
+_, span := tp.Tracer("test").Start(context.Background(), "op")
+if got := span.SpanContext().IsSampled(); got != tc.sampled { t.Errorf("ratio %v: sampled=%v", tc.ratio, got) }
+span.End()
+_ = tp.Shutdown(context.Background())
+}
+}